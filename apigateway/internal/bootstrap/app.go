@@ -13,6 +13,7 @@ import (
 	"github.com/locvowork/employee_management_sample/apigateway/internal/logger"
 	"github.com/locvowork/employee_management_sample/apigateway/internal/repository"
 	"github.com/locvowork/employee_management_sample/apigateway/internal/service"
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
 	"github.com/locvowork/employee_management_sample/apigateway/pkg/googlecloud"
 )
 
@@ -63,7 +64,12 @@ func (a *App) Initialize(ctx context.Context) error {
 	// Initialize dependencies
 	empRepo := repository.NewEmployeeRepository(db)
 	empSvc := service.NewEmployeeService(empRepo)
-	empHandler := handler.NewEmployeeHandler(empSvc)
+	// Locale bundles are loaded once and negotiated per export request
+	locales, err := excel.LoadLocales("locales", "en")
+	if err != nil {
+		logger.ErrorLog(ctx, fmt.Sprintf("failed to load locale bundles: %v", err))
+	}
+	empHandler := handler.NewEmployeeHandler(empSvc, locales)
 	compHandler := handler.NewComparisonHandler()
 
 	// Initialize GCP Datastore Client
@@ -75,22 +81,70 @@ func (a *App) Initialize(ctx context.Context) error {
 	a.GCP = gcpClient
 	gcpHandler := handler.NewGCPDemoHandler(gcpClient)
 
+	// Report templates are served by name and reloaded when their files change;
+	// an invalid template keeps serving its last valid version
+	reports := excel.NewReportRegistry(config.DefaultEnvConfig.REPORTS_DIR).SetLogger(reportLogger{ctx: ctx})
+	reportHandler := handler.NewReportHandler(reports, empSvc, locales)
+	if err := reports.Reload(); err != nil {
+		logger.ErrorLog(ctx, fmt.Sprintf("failed to load report templates: %v", err))
+	}
+	go reports.Watch(ctx, config.DefaultEnvConfig.REPORTS_RELOAD_INTERVAL)
+
+	// Large exports run as background jobs rendering to local files
+	exportJobSvc, err := service.NewExportJobService(reports, service.ExportJobConfig{
+		Dir:       config.DefaultEnvConfig.EXPORT_JOBS_DIR,
+		Workers:   config.DefaultEnvConfig.EXPORT_JOBS_WORKERS,
+		QueueSize: config.DefaultEnvConfig.EXPORT_JOBS_QUEUE_SIZE,
+		Retention: config.DefaultEnvConfig.EXPORT_JOBS_RETENTION,
+		Locales:   locales,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize export jobs: %w", err)
+	}
+	exportJobSvc.Start(ctx)
+	exportJobHandler := handler.NewExportJobHandler(exportJobSvc)
+
+	// Schedules declared by the report templates write to the outbox; runs are recorded in Postgres
+	scheduleRepo := repository.NewScheduleRunRepository(db)
+	if err := scheduleRepo.EnsureSchema(ctx); err != nil {
+		logger.ErrorLog(ctx, fmt.Sprintf("failed to create schedule tables: %v", err))
+	}
+	scheduler := service.NewReportScheduler(reports, scheduleRepo, service.ReportSchedulerConfig{
+		OutboxDir: config.DefaultEnvConfig.SCHEDULE_OUTBOX_DIR,
+		Interval:  config.DefaultEnvConfig.SCHEDULE_CHECK_INTERVAL,
+		Locales:   locales,
+	})
+	if err := scheduler.Start(ctx); err != nil {
+		logger.ErrorLog(ctx, fmt.Sprintf("failed to start report scheduler: %v", err))
+	}
+	scheduleHandler := handler.NewScheduleHandler(scheduler)
+
 	// Register Middlewares
 	a.RegisterMiddlewares()
 
 	// Register Routes
-	a.RegisterRoutes(empHandler, compHandler, gcpHandler)
+	a.RegisterRoutes(empHandler, compHandler, gcpHandler, reportHandler, exportJobHandler, scheduleHandler)
 
 	return nil
 }
 
+// reportLogger writes the template (re)loads of the report registry to the application log.
+type reportLogger struct {
+	ctx context.Context
+}
+
+func (l reportLogger) Log(format string, args ...interface{}) {
+	logger.InfoLog(l.ctx, format, args...)
+}
+
 func (a *App) RegisterMiddlewares() {
+	a.Echo.Use(middleware.RequestID())
 	a.Echo.Use(middleware.Logger())
 	a.Echo.Use(middleware.Recover())
 	a.Echo.Use(middleware.CORS())
 }
 
-func (a *App) RegisterRoutes(empHandler *handler.EmployeeHandler, compHandler *handler.ComparisonHandler, gcpHandler *handler.GCPDemoHandler) {
+func (a *App) RegisterRoutes(empHandler *handler.EmployeeHandler, compHandler *handler.ComparisonHandler, gcpHandler *handler.GCPDemoHandler, reportHandler *handler.ReportHandler, exportJobHandler *handler.ExportJobHandler, scheduleHandler *handler.ScheduleHandler) {
 	a.Echo.POST("/employees", empHandler.CreateHandler)
 	a.Echo.GET("/employees/:id", empHandler.GetHandler)
 	a.Echo.PUT("/employees/:id", empHandler.UpdateHandler)
@@ -101,12 +155,27 @@ func (a *App) RegisterRoutes(empHandler *handler.EmployeeHandler, compHandler *h
 	exportGroup := a.Echo.Group("/export")
 	exportGroup.GET("/fluent", empHandler.ExportFluentConfigHandler)
 	exportGroup.GET("/yaml", empHandler.ExportFromYAMLHandler)
+	exportGroup.POST("/jobs", exportJobHandler.CreateJobHandler)
+	exportGroup.GET("/jobs/:id", exportJobHandler.GetJobHandler)
+	exportGroup.GET("/jobs/:id/file", exportJobHandler.DownloadJobHandler)
 
 	exportGroupV2 := a.Echo.Group("/export/v2")
 	exportGroupV2.GET("/fluent", empHandler.ExportFluentConfigHandler)
 	exportGroupV2.GET("/yaml", empHandler.ExportV2FromYAMLHandler)
 	exportGroupV2.GET("/largedata", empHandler.ExportLargeDataHandler)
 	exportGroupV2.GET("/perf", empHandler.ExportLargeColumnHandler)
+	exportGroupV2.POST("/verify", empHandler.VerifyV2UploadHandler)
+
+	a.Echo.GET("/reports", reportHandler.ListReportsHandler)
+	a.Echo.GET("/reports/:name", reportHandler.ExportReportHandler)
+	a.Echo.POST("/reports/:name/diff", reportHandler.DiffReportHandler)
+
+	scheduleGroup := a.Echo.Group("/schedules")
+	scheduleGroup.GET("", scheduleHandler.ListSchedulesHandler)
+	scheduleGroup.POST("/:report/:schedule/trigger", scheduleHandler.TriggerScheduleHandler)
+	scheduleGroup.POST("/:report/:schedule/pause", scheduleHandler.PauseScheduleHandler)
+	scheduleGroup.POST("/:report/:schedule/resume", scheduleHandler.ResumeScheduleHandler)
+	scheduleGroup.GET("/:report/:schedule/runs", scheduleHandler.ListRunsHandler)
 
 	compGroup := a.Echo.Group("/comparison")
 	compGroup.GET("/wiki/tpl", compHandler.ExportWikiTPL)
@@ -27,6 +27,17 @@ type envConfig struct {
 	APP_PORT string
 	// gcp config
 	GCP_PROJECT_ID string
+	// report registry config
+	REPORTS_DIR             string
+	REPORTS_RELOAD_INTERVAL time.Duration
+	// export job config
+	EXPORT_JOBS_DIR        string
+	EXPORT_JOBS_WORKERS    int
+	EXPORT_JOBS_QUEUE_SIZE int
+	EXPORT_JOBS_RETENTION  time.Duration
+	// report schedule config
+	SCHEDULE_OUTBOX_DIR     string
+	SCHEDULE_CHECK_INTERVAL time.Duration
 }
 
 func LoadEnvConfig() error {
@@ -34,18 +45,26 @@ func LoadEnvConfig() error {
 	_ = godotenv.Load()
 
 	DefaultEnvConfig = &envConfig{
-		DB_HOST:              getEnvString("DB_HOST", "localhost"),
-		DB_PORT:              getEnvInt("DB_PORT", 5432),
-		DB_USER:              getEnvString("DB_USER", "postgres"),
-		DB_PASSWORD:          getEnvString("DB_PASSWORD", "postgres"),
-		DB_NAME:              getEnvString("DB_NAME", "postgres"),
-		DB_SSL_MODE:          getEnvString("DB_SSL_MODE", "disable"),
-		DB_CONN_MAX_LIFETIME: getEnvDuration("DB_CONN_MAX_LIFETIME", 20*time.Minute),
-		DB_MAX_IDLE_CONNS:    getEnvInt("DB_MAX_IDLE_CONNS", 10),
-		DB_MAX_OPEN_CONNS:    getEnvInt("DB_MAX_OPEN_CONNS", 100),
-		LOG_FILE_PATH:        getEnvString("LOG_FILE_PATH", ""),
-		APP_PORT:             getEnvString("APP_PORT", "8080"),
-		GCP_PROJECT_ID:       getEnvString("GCP_PROJECT_ID", "demo-project"),
+		DB_HOST:                 getEnvString("DB_HOST", "localhost"),
+		DB_PORT:                 getEnvInt("DB_PORT", 5432),
+		DB_USER:                 getEnvString("DB_USER", "postgres"),
+		DB_PASSWORD:             getEnvString("DB_PASSWORD", "postgres"),
+		DB_NAME:                 getEnvString("DB_NAME", "postgres"),
+		DB_SSL_MODE:             getEnvString("DB_SSL_MODE", "disable"),
+		DB_CONN_MAX_LIFETIME:    getEnvDuration("DB_CONN_MAX_LIFETIME", 20*time.Minute),
+		DB_MAX_IDLE_CONNS:       getEnvInt("DB_MAX_IDLE_CONNS", 10),
+		DB_MAX_OPEN_CONNS:       getEnvInt("DB_MAX_OPEN_CONNS", 100),
+		LOG_FILE_PATH:           getEnvString("LOG_FILE_PATH", ""),
+		APP_PORT:                getEnvString("APP_PORT", "8080"),
+		GCP_PROJECT_ID:          getEnvString("GCP_PROJECT_ID", "demo-project"),
+		REPORTS_DIR:             getEnvString("REPORTS_DIR", "reports"),
+		REPORTS_RELOAD_INTERVAL: getEnvDuration("REPORTS_RELOAD_INTERVAL", 10*time.Second),
+		EXPORT_JOBS_DIR:         getEnvString("EXPORT_JOBS_DIR", "exports"),
+		EXPORT_JOBS_WORKERS:     getEnvInt("EXPORT_JOBS_WORKERS", 2),
+		EXPORT_JOBS_QUEUE_SIZE:  getEnvInt("EXPORT_JOBS_QUEUE_SIZE", 100),
+		EXPORT_JOBS_RETENTION:   getEnvDuration("EXPORT_JOBS_RETENTION", 24*time.Hour),
+		SCHEDULE_OUTBOX_DIR:     getEnvString("SCHEDULE_OUTBOX_DIR", "outbox"),
+		SCHEDULE_CHECK_INTERVAL: getEnvDuration("SCHEDULE_CHECK_INTERVAL", 30*time.Second),
 	}
 	return nil
 }
@@ -1,6 +1,9 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // EmployeeFilter defines criteria for listing employees
 type EmployeeFilter struct {
@@ -22,4 +25,18 @@ type EmployeeRepository interface {
 	GetDepartmentHistory(ctx context.Context, empID int) ([]DeptEmp, error)
 	GetManagers(ctx context.Context, deptNo string) ([]DeptManager, error)
 	GetTitle(ctx context.Context, empID int) (*Title, error)
+	ListDepartmentSalaries(ctx context.Context, asOf time.Time) ([]DepartmentSalary, error)
+}
+
+// ScheduleRunRepository records the runs of scheduled reports and the paused schedules
+type ScheduleRunRepository interface {
+	// EnsureSchema creates the tables of the repository if they do not exist
+	EnsureSchema(ctx context.Context) error
+	CreateRun(ctx context.Context, run *ScheduleRun) error
+	FinishRun(ctx context.Context, run *ScheduleRun) error
+	// ListRuns returns the latest runs of a schedule, newest first
+	ListRuns(ctx context.Context, report, schedule string, limit int) ([]ScheduleRun, error)
+	SetPaused(ctx context.Context, report, schedule string, paused bool) error
+	// PausedSchedules returns the paused schedules keyed by "report/schedule"
+	PausedSchedules(ctx context.Context) (map[string]bool, error)
 }
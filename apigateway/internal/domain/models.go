@@ -50,6 +50,17 @@ type Title struct {
 	ToDate   time.Time `json:"to_date" db:"to_date"`
 }
 
+// DepartmentSalary aggregates the salaries of the employees of a department at a date
+type DepartmentSalary struct {
+	DeptNo        string  `json:"dept_no" db:"dept_no"`
+	DeptName      string  `json:"dept_name" db:"dept_name"`
+	Employees     int     `json:"employees" db:"employees"`
+	TotalSalary   int64   `json:"total_salary" db:"total_salary"`
+	AverageSalary float64 `json:"average_salary" db:"average_salary"`
+	MinSalary     int     `json:"min_salary" db:"min_salary"`
+	MaxSalary     int     `json:"max_salary" db:"max_salary"`
+}
+
 // EmployeeReport represents the aggregated employee data for reporting
 type EmployeeReport struct {
 	Employee          Employee      `json:"employee"`
@@ -58,3 +69,85 @@ type EmployeeReport struct {
 	DepartmentHistory []DeptEmp     `json:"department_history"`
 	ManagementHistory []DeptManager `json:"management_history"`
 }
+
+// ExportJobStatus is the state of an asynchronous export job
+type ExportJobStatus string
+
+const (
+	ExportJobQueued    ExportJobStatus = "queued"
+	ExportJobRunning   ExportJobStatus = "running"
+	ExportJobSucceeded ExportJobStatus = "succeeded"
+	ExportJobFailed    ExportJobStatus = "failed"
+)
+
+// ExportJobRequest describes a report export to run in the background
+type ExportJobRequest struct {
+	Report         string              `json:"report"`
+	Format         string              `json:"format"`
+	Params         map[string][]string `json:"params"`
+	Role           string              `json:"role,omitempty"`
+	Lang           string              `json:"lang,omitempty"`
+	AcceptLanguage string              `json:"-"`
+}
+
+// ExportJobProgress reports how far a running export job is
+type ExportJobProgress struct {
+	Stage        string `json:"stage"`   // queued, loading, rendering, done
+	Percent      int    `json:"percent"` // Estimated from the stage
+	BytesWritten int64  `json:"bytes_written"`
+}
+
+// ExportJob is an asynchronous report export and its result file
+type ExportJob struct {
+	ID         string            `json:"id"`
+	Report     string            `json:"report"`
+	Format     string            `json:"format"`
+	Status     ExportJobStatus   `json:"status"`
+	Progress   ExportJobProgress `json:"progress"`
+	Error      string            `json:"error,omitempty"`
+	FileName   string            `json:"file_name,omitempty"`
+	Size       int64             `json:"size,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	StartedAt  *time.Time        `json:"started_at,omitempty"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+	ExpiresAt  time.Time         `json:"expires_at"`
+}
+
+// ScheduleRunStatus is the outcome of a scheduled report run
+type ScheduleRunStatus string
+
+const (
+	ScheduleRunRunning   ScheduleRunStatus = "running"
+	ScheduleRunSucceeded ScheduleRunStatus = "succeeded"
+	ScheduleRunFailed    ScheduleRunStatus = "failed"
+	ScheduleRunSkipped   ScheduleRunStatus = "skipped" // The previous run of the schedule was still running
+)
+
+// ScheduleRun represents the reporting.schedule_run table
+type ScheduleRun struct {
+	ID          string            `json:"id" db:"id"`
+	Report      string            `json:"report" db:"report"`
+	Schedule    string            `json:"schedule" db:"schedule"`
+	Trigger     string            `json:"trigger" db:"trigger"` // cron or manual
+	Status      ScheduleRunStatus `json:"status" db:"status"`
+	ScheduledAt time.Time         `json:"scheduled_at" db:"scheduled_at"`
+	StartedAt   time.Time         `json:"started_at" db:"started_at"`
+	FinishedAt  *time.Time        `json:"finished_at,omitempty" db:"finished_at"`
+	File        string            `json:"file,omitempty" db:"file"`
+	Size        int64             `json:"size,omitempty" db:"size"`
+	Error       string            `json:"error,omitempty" db:"error"`
+}
+
+// ReportScheduleStatus describes a schedule declared by a report template
+type ReportScheduleStatus struct {
+	Report    string       `json:"report"`
+	Schedule  string       `json:"schedule"`
+	Cron      string       `json:"cron"`
+	Timezone  string       `json:"timezone,omitempty"`
+	Format    string       `json:"format"`
+	OutputDir string       `json:"output_dir"`
+	Paused    bool         `json:"paused"`
+	Running   bool         `json:"running"`
+	NextRun   *time.Time   `json:"next_run,omitempty"`
+	LastRun   *ScheduleRun `json:"last_run,omitempty"`
+}
@@ -9,9 +9,10 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/locvowork/employee_management_sample/apigateway/internal/logger"
+	"github.com/locvowork/employee_management_sample/apigateway/internal/service/serviceutils"
 	"github.com/locvowork/employee_management_sample/apigateway/pkg/dataflow"
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
 	"github.com/locvowork/employee_management_sample/apigateway/pkg/pipeline"
-	"github.com/locvowork/employee_management_sample/apigateway/pkg/simpleexcelv2"
 )
 
 type WikiPerson struct {
@@ -192,7 +193,7 @@ func (h *ComparisonHandler) ExportWikiStreaming(c echo.Context) error {
 	c.Response().Header().Set(echo.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
 
 	// FIXED: Use correct exporter creation
-	exporter := simpleexcelv2.NewExcelDataExporter()
+	exporter := excel.NewExcelDataExporter()
 	
 	// FIXED: Use AddSheet method
 	sheet := exporter.AddSheet("Wikipedia People")
@@ -205,11 +206,11 @@ func (h *ComparisonHandler) ExportWikiStreaming(c echo.Context) error {
 	defer streamer.Close()
 
 	// FIXED: Configure section for streaming
-	sheet.AddSection(&simpleexcelv2.SectionConfig{
+	sheet.AddSection(&excel.SectionConfig{
 		ID:         "wiki-data",
 		Title:      "Wikipedia People Export (Streaming)",
 		ShowHeader: true,
-		Columns: []simpleexcelv2.ColumnConfig{
+		Columns: []excel.ColumnConfig{
 			{FieldName: "Name", Header: "Person Name", Width: 40},
 			{FieldName: "URL", Header: "Wiki URL", Width: 60},
 		},
@@ -247,13 +248,13 @@ func (h *ComparisonHandler) ExportWikiStreaming(c echo.Context) error {
 }
 
 func (h *ComparisonHandler) exportToExcel(c echo.Context, data []WikiPerson, filename string) error {
-	exporter := simpleexcelv2.NewExcelDataExporter()
+	exporter := excel.NewExcelDataExporter()
 
 	sheet := exporter.AddSheet("Wikipedia People")
 
-	section := &simpleexcelv2.SectionConfig{
+	section := &excel.SectionConfig{
 		Title: "Extracted Names from Wikipedia",
-		Columns: []simpleexcelv2.ColumnConfig{
+		Columns: []excel.ColumnConfig{
 			{FieldName: "Name", Header: "Person Name", Width: 40},
 			{FieldName: "URL", Header: "Wiki URL", Width: 60},
 		},
@@ -278,27 +279,32 @@ func (h *ComparisonHandler) ExportWikiStreamingV2(c echo.Context) error {
 		"https://en.wikipedia.org/wiki/Timeline_of_ancient_Greek_mathematicians",
 	}
 
+	format, err := excel.ParseExportFormat(c.QueryParam("format"))
+	if err != nil {
+		return serviceutils.ResponseError(c, http.StatusBadRequest, "Invalid format", err)
+	}
+
 	// 1. Prepare Exporter (V2)
-	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename=wiki_names_streaming_v2.xlsx")
-	c.Response().Header().Set(echo.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename=wiki_names_streaming_v2."+string(format))
+	c.Response().Header().Set(echo.HeaderContentType, format.ContentType())
 
-	exporter := simpleexcelv2.NewExcelDataExporter()
+	exporter := excel.NewExcelDataExporter()
 	exporter.AddSheet("Wikipedia People").
-		AddSection(&simpleexcelv2.SectionConfig{
-			Type:  simpleexcelv2.SectionTypeTitleOnly,
+		AddSection(&excel.SectionConfig{
+			Type:  excel.SectionTypeTitleOnly,
 			Title: "Wikipedia People Export (V2 Stream)",
 		}).
-		AddSection(&simpleexcelv2.SectionConfig{
+		AddSection(&excel.SectionConfig{
 			ID:         "wiki-data",
 			ShowHeader: true,
-			Columns: []simpleexcelv2.ColumnConfig{
+			Columns: []excel.ColumnConfig{
 				{FieldName: "Name", Header: "Person Name", Width: 40},
 				{FieldName: "URL", Header: "Wiki URL", Width: 60},
 			},
 		})
 
 	// FIXED: Use correct StartStream method
-	streamer, err := exporter.StartStream(c.Response().Writer)
+	streamer, err := exporter.StartStreamFormat(c.Response().Writer, format, excel.CSVOptions{BOM: true})
 	if err != nil {
 		logger.ErrorLog(ctx, "Failed to start stream: %v", err)
 		return err
@@ -390,7 +396,7 @@ sheets:
 `
 
 	// 2. Initialize Exporter from YAML
-	exporter, err := simpleexcelv2.NewExcelDataExporterFromYamlConfig(yamlConfig)
+	exporter, err := excel.NewExcelDataExporterFromYamlConfig(yamlConfig)
 	if err != nil {
 		logger.ErrorLog(ctx, "Failed to init exporter: %v", err)
 		return c.String(http.StatusInternalServerError, "Exporter init failed")
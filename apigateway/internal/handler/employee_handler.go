@@ -11,15 +11,19 @@ import (
 	"github.com/locvowork/employee_management_sample/apigateway/internal/logger"
 	"github.com/locvowork/employee_management_sample/apigateway/internal/service"
 	"github.com/locvowork/employee_management_sample/apigateway/internal/service/serviceutils"
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
 	"github.com/locvowork/employee_management_sample/apigateway/pkg/simpleexcel"
 )
 
 type EmployeeHandler struct {
-	svc service.EmployeeService
+	svc     service.EmployeeService
+	locales *excel.Locales
 }
 
-func NewEmployeeHandler(svc service.EmployeeService) *EmployeeHandler {
-	return &EmployeeHandler{svc: svc}
+// NewEmployeeHandler creates an employee handler. locales are the bundles exports are translated with;
+// nil exports untranslated.
+func NewEmployeeHandler(svc service.EmployeeService, locales *excel.Locales) *EmployeeHandler {
+	return &EmployeeHandler{svc: svc, locales: locales}
 }
 
 func (h *EmployeeHandler) CreateHandler(c echo.Context) error {
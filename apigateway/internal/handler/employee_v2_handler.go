@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -12,9 +13,20 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/locvowork/employee_management_sample/apigateway/internal/logger"
 	"github.com/locvowork/employee_management_sample/apigateway/internal/service/serviceutils"
-	"github.com/locvowork/employee_management_sample/apigateway/pkg/simpleexcelv2"
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
+	"github.com/xuri/excelize/v2"
 )
 
+// exportLocale returns the locale bundle for an export request, chosen from the bundles loaded at startup
+// by the ?lang= parameter or the Accept-Language header. Exports fall back to untranslated headers and
+// default formats (nil) if the bundles could not be loaded.
+func exportLocale(c echo.Context, locales *excel.Locales) *excel.LocaleBundle {
+	if locales == nil {
+		return nil
+	}
+	return locales.Negotiate(c.QueryParam("lang"), c.Request().Header.Get("Accept-Language"))
+}
+
 func (h *EmployeeHandler) ExportV2FromYAMLHandler(c echo.Context) error {
 	// YAML configuration
 	yamlConfig := ""
@@ -29,23 +41,26 @@ func (h *EmployeeHandler) ExportV2FromYAMLHandler(c echo.Context) error {
 	copy(productSectionOriginal, productSectionEditable)
 
 	// Initialize exporter with inline config
-	exporter, err := simpleexcelv2.NewExcelDataExporterFromYamlConfig(yamlConfig)
+	exporter, err := excel.NewExcelDataExporterFromYamlConfig(yamlConfig)
 	if err != nil {
 		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to parse inline report config", err)
 	}
 
-	// Register a simple currency formatter for demonstration
-	exporter.RegisterFormatter("currency", func(v interface{}) interface{} {
-		if val, ok := v.(float64); ok {
-			return fmt.Sprintf("$%.2f", val)
-		}
-		return v
-	})
+	// Built-in formatters such as "currency(USD,2)" need no registration; catch typos in the YAML early
+	if err := exporter.ValidateFormatters(); err != nil {
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Invalid report config", err)
+	}
+
+	exporter.SetLocale(exportLocale(c, h.locales))
 
 	// Bind data
 	exporter.
 		BindSectionData("product_section_editable", productSectionEditable).
 		BindSectionData("product_section_original", productSectionOriginal)
+	if err := exporter.ValidateExpressions(); err != nil {
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Invalid report config", err)
+	}
+	exporter.SetRequestID(c.Response().Header().Get(echo.HeaderXRequestID))
 
 	// Export to bytes
 	excelBytes, err := exporter.ToBytes()
@@ -63,6 +78,50 @@ func (h *EmployeeHandler) ExportV2FromYAMLHandler(c echo.Context) error {
 	return err
 }
 
+// VerifyV2UploadHandler reads the report metadata of an uploaded workbook and
+// rejects workbooks generated from a different version of report_config_v2.yaml.
+func (h *EmployeeHandler) VerifyV2UploadHandler(c echo.Context) error {
+	data, err := os.ReadFile("report_config_v2.yaml")
+	if err != nil {
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to read YAML file", err)
+	}
+	exporter, err := excel.NewExcelDataExporterFromYamlConfig(string(data))
+	if err != nil {
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to parse report config", err)
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return serviceutils.ResponseError(c, http.StatusBadRequest, "Missing file", err)
+	}
+	src, err := fileHeader.Open()
+	if err != nil {
+		return serviceutils.ResponseError(c, http.StatusBadRequest, "Failed to open file", err)
+	}
+	defer src.Close()
+
+	f, err := excelize.OpenReader(src)
+	if err != nil {
+		return serviceutils.ResponseError(c, http.StatusBadRequest, "Invalid Excel file", err)
+	}
+	defer f.Close()
+
+	meta, err := exporter.VerifyTemplate(f)
+	switch {
+	case errors.Is(err, excel.ErrNoReportMetadata):
+		return serviceutils.ResponseError(c, http.StatusUnprocessableEntity, "File was not generated by this report", err)
+	case errors.Is(err, excel.ErrStaleTemplate):
+		return serviceutils.ResponseError(c, http.StatusConflict, "File was generated from an outdated template", err)
+	case err != nil:
+		return serviceutils.ResponseError(c, http.StatusBadRequest, "Failed to read report metadata", err)
+	}
+	return serviceutils.ResponseSuccess(c, http.StatusOK, "Template verified", meta)
+}
+
+// minRowsPerSheet is the smallest max_rows_per_sheet accepted by ExportLargeDataHandler: smaller limits
+// would spread an export over thousands of sheets, or leave no room for the repeated title and header.
+const minRowsPerSheet = 100
+
 func (h *EmployeeHandler) ExportLargeDataHandler(c echo.Context) error {
 	// Generate large dataset
 	count := 50000
@@ -79,13 +138,13 @@ func (h *EmployeeHandler) ExportLargeDataHandler(c echo.Context) error {
 	}
 
 	// Create and configure exporter
-	exporter := simpleexcelv2.NewExcelDataExporter().
+	exporter := excel.NewExcelDataExporter().
 		AddSheet("Large Export").
-		AddSection(&simpleexcelv2.SectionConfig{
+		AddSection(&excel.SectionConfig{
 			Title:      fmt.Sprintf("Bulk Products Export (%d rows)", count),
 			Data:       data,
 			ShowHeader: true,
-			Columns: []simpleexcelv2.ColumnConfig{
+			Columns: []excel.ColumnConfig{
 				{FieldName: "Name", Header: "Product Name", Width: 30},
 				{FieldName: "Price", Header: "Unit Price", Width: 15},
 				{FieldName: "Category", Header: "Category", Width: 20},
@@ -93,13 +152,35 @@ func (h *EmployeeHandler) ExportLargeDataHandler(c echo.Context) error {
 			},
 		}).
 		Build()
+	exporter.SetLocale(exportLocale(c, h.locales))
+
+	// Rows beyond the limit continue on "Large Export (2)", ... (default: Excel's 1,048,576 rows)
+	if v := c.QueryParam("max_rows_per_sheet"); v != "" {
+		maxRows, err := strconv.Atoi(v)
+		if err != nil {
+			return serviceutils.ResponseError(c, http.StatusBadRequest, "Invalid max_rows_per_sheet", err)
+		}
+		if maxRows < minRowsPerSheet {
+			return serviceutils.ResponseError(c, http.StatusBadRequest, "Invalid max_rows_per_sheet",
+				fmt.Errorf("max_rows_per_sheet must be at least %d", minRowsPerSheet))
+		}
+		exporter.SetMaxRowsPerSheet(maxRows)
+	}
 
 	// Set headers for file download
 	c.Response().Header().Set(echo.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
 	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="large_products_%d.xlsx"`, count))
 
 	// Stream directly to response
-	return exporter.ToWriter(c.Response().Writer)
+	splits, err := exporter.ToWriterSplits(c.Response().Writer)
+	if err != nil {
+		return err
+	}
+	for _, split := range splits {
+		logger.InfoLog(c.Request().Context(), "ExportLargeDataHandler: sheet %s continued on %s after %d rows of section %s",
+			split.Sheet, split.NextSheet, split.RowsBefore, split.SectionID)
+	}
+	return nil
 }
 
 func generateRandomProducts(count int) []Product {
@@ -251,13 +332,19 @@ func (h *EmployeeHandler) ExportLargeColumnHandler(c echo.Context) error {
 	editableItems := generateLargeItems(count)
 
 	// Initialize exporter
-	exporter, err := simpleexcelv2.NewExcelDataExporterFromYamlConfig(yamlConfig)
+	exporter, err := excel.NewExcelDataExporterFromYamlConfig(yamlConfig)
 	if err != nil {
 		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to parse YAML config", err)
 	}
-
-	// Start Stream
-	streamer, err := exporter.StartStream(c.Response())
+	exporter.SetLocale(exportLocale(c, h.locales))
+
+	// Start Stream; the export is aborted if the client disconnects
+	streamer, err := exporter.StartStreamContext(ctx, c.Response(), excel.StreamOptions{
+		OnProgress: func(p excel.StreamProgress) {
+			logger.InfoLog(ctx, "ExportLargeColumnHandler progress: section=%s rows=%d total=%d bytes=%d elapsed=%s done=%t",
+				p.SectionID, p.SectionRows, p.TotalRows, p.BytesFlushed, p.Elapsed, p.Done)
+		},
+	})
 	if err != nil {
 		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to start stream", err)
 	}
@@ -275,12 +362,20 @@ func (h *EmployeeHandler) ExportLargeColumnHandler(c echo.Context) error {
 			end = len(editableItems)
 		}
 		if err := streamer.Write("large_column_editable", editableItems[i:end]); err != nil {
+			if errors.Is(err, excel.ErrStreamCanceled) {
+				logger.InfoLog(ctx, "ExportLargeColumnHandler canceled: %v", err)
+				return nil
+			}
 			return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to write editable batch", err)
 		}
 	}
 
 	// Close Stream
 	if err := streamer.Close(); err != nil {
+		if errors.Is(err, excel.ErrStreamCanceled) {
+			logger.InfoLog(ctx, "ExportLargeColumnHandler canceled: %v", err)
+			return nil
+		}
 		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to close stream", err)
 	}
 
@@ -0,0 +1,27 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/locvowork/employee_management_sample/apigateway/internal/handler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportLargeDataRejectsSmallRowLimits(t *testing.T) {
+	e := echo.New()
+	h := handler.NewEmployeeHandler(nil, nil)
+
+	for _, v := range []string{"abc", "-1", "0", "1", "99"} {
+		req := httptest.NewRequest(http.MethodGet, "/export/v2/largedata?max_rows_per_sheet="+v, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, h.ExportLargeDataHandler(c)) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code, "max_rows_per_sheet=%s", v)
+			assert.Contains(t, rec.Body.String(), "Invalid max_rows_per_sheet")
+		}
+	}
+}
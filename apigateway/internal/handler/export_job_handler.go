@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/locvowork/employee_management_sample/apigateway/internal/domain"
+	"github.com/locvowork/employee_management_sample/apigateway/internal/service"
+	"github.com/locvowork/employee_management_sample/apigateway/internal/service/serviceutils"
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
+)
+
+// ExportJobHandler runs report exports in the background, for exports too large to render within a request.
+type ExportJobHandler struct {
+	svc service.ExportJobService
+}
+
+func NewExportJobHandler(svc service.ExportJobService) *ExportJobHandler {
+	return &ExportJobHandler{svc: svc}
+}
+
+// exportJobRequest is the body of POST /export/jobs. Params are passed to the section providers
+// like the query parameters of GET /reports/:name.
+type exportJobRequest struct {
+	Report string            `json:"report"`
+	Format string            `json:"format"`
+	Params map[string]string `json:"params"`
+	Lang   string            `json:"lang"`
+}
+
+// CreateJobHandler enqueues a registry report and returns the job with 202 Accepted.
+// Jobs have no role, so they are redacted with the restricted policy, as for GET /reports/:name.
+func (h *ExportJobHandler) CreateJobHandler(c echo.Context) error {
+	var req exportJobRequest
+	if err := c.Bind(&req); err != nil {
+		return serviceutils.ResponseError(c, http.StatusBadRequest, "Invalid request body", err)
+	}
+	params := make(map[string][]string, len(req.Params))
+	for k, v := range req.Params {
+		params[k] = []string{v}
+	}
+
+	job, err := h.svc.Submit(c.Request().Context(), domain.ExportJobRequest{
+		Report:         req.Report,
+		Format:         req.Format,
+		Params:         params,
+		Lang:           req.Lang,
+		AcceptLanguage: c.Request().Header.Get("Accept-Language"),
+	})
+	switch {
+	case errors.Is(err, service.ErrInvalidExportJob):
+		return serviceutils.ResponseError(c, http.StatusBadRequest, "Invalid export job", err)
+	case errors.Is(err, excel.ErrReportNotFound):
+		return serviceutils.ResponseError(c, http.StatusNotFound, "Report not found", err)
+	case errors.Is(err, service.ErrExportQueueFull):
+		return serviceutils.ResponseError(c, http.StatusServiceUnavailable, "Too many export jobs, try again later", err)
+	case err != nil:
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to create export job", err)
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, "/export/jobs/"+job.ID)
+	return serviceutils.ResponseSuccess(c, http.StatusAccepted, "Export job queued", job)
+}
+
+// GetJobHandler returns the status and progress of a job.
+func (h *ExportJobHandler) GetJobHandler(c echo.Context) error {
+	job, err := h.svc.Get(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, service.ErrExportJobNotFound) {
+		return serviceutils.ResponseError(c, http.StatusNotFound, "Export job not found", err)
+	}
+	if err != nil {
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to get export job", err)
+	}
+	return serviceutils.ResponseSuccess(c, http.StatusOK, "Export job found", job)
+}
+
+// DownloadJobHandler downloads the file of a succeeded job.
+func (h *ExportJobHandler) DownloadJobHandler(c echo.Context) error {
+	job, f, err := h.svc.Open(c.Request().Context(), c.Param("id"))
+	switch {
+	case errors.Is(err, service.ErrExportJobNotFound):
+		return serviceutils.ResponseError(c, http.StatusNotFound, "Export job not found", err)
+	case errors.Is(err, service.ErrExportJobNotReady):
+		return serviceutils.ResponseError(c, http.StatusConflict, "Export job has not succeeded", err)
+	case err != nil:
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to open export file", err)
+	}
+	defer f.Close()
+
+	c.Response().Header().Set(echo.HeaderContentType, excel.ExportFormat(job.Format).ReportContentType())
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, job.FileName))
+	http.ServeContent(c.Response(), c.Request(), job.FileName, *job.FinishedAt, f)
+	return nil
+}
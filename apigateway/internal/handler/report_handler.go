@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/locvowork/employee_management_sample/apigateway/internal/domain"
+	"github.com/locvowork/employee_management_sample/apigateway/internal/logger"
+	"github.com/locvowork/employee_management_sample/apigateway/internal/service"
+	"github.com/locvowork/employee_management_sample/apigateway/internal/service/serviceutils"
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
+	"github.com/xuri/excelize/v2"
+)
+
+// errInvalidReportParam is returned by section providers for invalid query parameters.
+var errInvalidReportParam = errors.New("invalid report parameter")
+
+// ReportHandler serves the templates of a report registry.
+type ReportHandler struct {
+	registry *excel.ReportRegistry
+	locales  *excel.Locales
+}
+
+// NewReportHandler creates a report handler and registers the section providers of the application.
+// locales are the bundles exports are translated with; nil exports untranslated.
+func NewReportHandler(registry *excel.ReportRegistry, svc service.EmployeeService, locales *excel.Locales) *ReportHandler {
+	registry.
+		RegisterProvider("products", func(ctx context.Context, params url.Values) (interface{}, error) {
+			count, err := intParam(params, "count", 100)
+			if err != nil {
+				return nil, err
+			}
+			return generateRandomProducts(count), nil
+		}).
+		RegisterProvider("employees", func(ctx context.Context, params url.Values) (interface{}, error) {
+			limit, err := intParam(params, "limit", 100)
+			if err != nil {
+				return nil, err
+			}
+			offset, err := intParam(params, "offset", 0)
+			if err != nil {
+				return nil, err
+			}
+			return svc.List(ctx, domain.EmployeeFilter{Limit: limit, Offset: offset})
+		}).
+		RegisterProvider("department_salaries", func(ctx context.Context, params url.Values) (interface{}, error) {
+			asOf, err := dateParam(params, "as_of", time.Now())
+			if err != nil {
+				return nil, err
+			}
+			return svc.ListDepartmentSalaries(ctx, asOf)
+		})
+	return &ReportHandler{registry: registry, locales: locales}
+}
+
+// intParam reads a non-negative integer query parameter.
+func intParam(params url.Values, name string, fallback int) (int, error) {
+	v := params.Get(name)
+	if v == "" {
+		return fallback, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("%w: %s must be a non-negative integer", errInvalidReportParam, name)
+	}
+	return i, nil
+}
+
+// dateParam reads a YYYY-MM-DD query parameter.
+func dateParam(params url.Values, name string, fallback time.Time) (time.Time, error) {
+	v := params.Get(name)
+	if v == "" {
+		return fallback, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be a date (YYYY-MM-DD)", errInvalidReportParam, name)
+	}
+	return t, nil
+}
+
+// ListReportsHandler lists the reports of the registry.
+func (h *ReportHandler) ListReportsHandler(c echo.Context) error {
+	return serviceutils.ResponseSuccess(c, http.StatusOK, "Reports listed successfully", h.registry.Reports())
+}
+
+// ExportReportHandler renders a report by name: GET /reports/:name?format=xlsx|csv|json, where csv is a ZIP
+// archive with one CSV per section. The other query parameters are passed to the section providers. Requests
+// are not authenticated, so templates with redaction roles are exported with the restricted policy; the role
+// is never taken from the request.
+func (h *ReportHandler) ExportReportHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	name := c.Param("name")
+	format, err := excel.ParseReportFormat(c.QueryParam("format"))
+	if err != nil {
+		return serviceutils.ResponseError(c, http.StatusBadRequest, "Invalid format", err)
+	}
+
+	exporter, err := h.registry.NewExporter(ctx, name, c.QueryParams())
+	switch {
+	case errors.Is(err, excel.ErrReportNotFound):
+		return serviceutils.ResponseError(c, http.StatusNotFound, "Report not found", err)
+	case errors.Is(err, errInvalidReportParam):
+		return serviceutils.ResponseError(c, http.StatusBadRequest, "Invalid report parameters", err)
+	case err != nil:
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to load report data", err)
+	}
+
+	exporter.SetLocale(exportLocale(c, h.locales))
+	if err := exporter.SetRestrictedRole(); err != nil && !errors.Is(err, excel.ErrNoRedactionRoles) {
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to apply redaction", err)
+	}
+	if err := exporter.ValidateExpressions(); err != nil {
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Invalid report config", err)
+	}
+	exporter.SetRequestID(c.Response().Header().Get(echo.HeaderXRequestID))
+
+	// Render before writing headers, so errors still get a JSON response
+	var buf bytes.Buffer
+	if err := exporter.ToFormat(&buf, format); err != nil {
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to generate report", err)
+	}
+	logger.InfoLog(ctx, "ExportReportHandler: report %s exported as %s (%d bytes)", name, format, buf.Len())
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, name, format.ReportExtension()))
+	return c.Blob(http.StatusOK, format.ReportContentType(), buf.Bytes())
+}
+
+// DiffReportHandler compares a workbook that came back from review with the workbook originally exported:
+// POST /reports/:name/diff with the multipart files "original" and "modified". It returns the change report,
+// or with ?highlight=true the returned workbook with the changes highlighted.
+func (h *ReportHandler) DiffReportHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+	name := c.Param("name")
+	exporter, err := h.registry.Template(name)
+	switch {
+	case errors.Is(err, excel.ErrReportNotFound):
+		return serviceutils.ResponseError(c, http.StatusNotFound, "Report not found", err)
+	case err != nil:
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to load report", err)
+	}
+
+	original, err := formWorkbook(c, "original")
+	if err != nil {
+		return serviceutils.ResponseError(c, http.StatusBadRequest, "Invalid original workbook", err)
+	}
+	defer original.Close()
+	modified, err := formWorkbook(c, "modified")
+	if err != nil {
+		return serviceutils.ResponseError(c, http.StatusBadRequest, "Invalid modified workbook", err)
+	}
+	defer modified.Close()
+
+	diff, err := exporter.DiffWorkbooks(original, modified)
+	switch {
+	case errors.Is(err, excel.ErrNoReportMetadata), errors.Is(err, excel.ErrNoSectionLayout):
+		return serviceutils.ResponseError(c, http.StatusUnprocessableEntity, "Original workbook was not exported from this report", err)
+	case errors.Is(err, excel.ErrStaleTemplate):
+		return serviceutils.ResponseError(c, http.StatusConflict, "Original workbook was generated from an outdated template", err)
+	case err != nil:
+		return serviceutils.ResponseError(c, http.StatusBadRequest, "Failed to compare workbooks", err)
+	}
+	logger.InfoLog(ctx, "DiffReportHandler: report %s: %d cells changed (%d locked), %d rows added, %d rows removed",
+		name, diff.Summary.CellsChanged, diff.Summary.LockedCellsChanged, diff.Summary.RowsAdded, diff.Summary.RowsRemoved)
+
+	if highlight, _ := strconv.ParseBool(c.QueryParam("highlight")); !highlight {
+		return serviceutils.ResponseSuccess(c, http.StatusOK, "Workbooks compared successfully", diff)
+	}
+	if err := excel.HighlightDiff(modified, diff); err != nil {
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to highlight changes", err)
+	}
+	var buf bytes.Buffer
+	if err := modified.Write(&buf); err != nil {
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to write workbook", err)
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s_diff.xlsx"`, name))
+	return c.Blob(http.StatusOK, excel.FormatXLSX.ContentType(), buf.Bytes())
+}
+
+// formWorkbook opens the workbook uploaded in a multipart form field.
+func formWorkbook(c echo.Context, field string) (*excelize.File, error) {
+	fileHeader, err := c.FormFile(field)
+	if err != nil {
+		return nil, err
+	}
+	src, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	return excelize.OpenReader(src)
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/locvowork/employee_management_sample/apigateway/internal/service"
+	"github.com/locvowork/employee_management_sample/apigateway/internal/service/serviceutils"
+)
+
+// ScheduleHandler manages the recurring exports declared by the schedules of report templates.
+type ScheduleHandler struct {
+	svc service.ReportScheduler
+}
+
+func NewScheduleHandler(svc service.ReportScheduler) *ScheduleHandler {
+	return &ScheduleHandler{svc: svc}
+}
+
+// ListSchedulesHandler lists the schedules with their next and last run.
+func (h *ScheduleHandler) ListSchedulesHandler(c echo.Context) error {
+	list, err := h.svc.List(c.Request().Context())
+	if err != nil {
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to list schedules", err)
+	}
+	return serviceutils.ResponseSuccess(c, http.StatusOK, "Schedules listed successfully", list)
+}
+
+// TriggerScheduleHandler starts a run now and returns it with 202 Accepted. A run is refused with
+// 409 Conflict while the previous run of the schedule is still running.
+func (h *ScheduleHandler) TriggerScheduleHandler(c echo.Context) error {
+	run, err := h.svc.Trigger(c.Request().Context(), c.Param("report"), c.Param("schedule"))
+	switch {
+	case errors.Is(err, service.ErrScheduleNotFound):
+		return serviceutils.ResponseError(c, http.StatusNotFound, "Schedule not found", err)
+	case errors.Is(err, service.ErrScheduleRunning):
+		return serviceutils.ResponseError(c, http.StatusConflict, "Schedule is already running", err)
+	case err != nil:
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to trigger schedule", err)
+	}
+	return serviceutils.ResponseSuccess(c, http.StatusAccepted, "Schedule triggered", run)
+}
+
+// PauseScheduleHandler stops the cron runs of a schedule until it is resumed.
+func (h *ScheduleHandler) PauseScheduleHandler(c echo.Context) error {
+	return h.setPaused(c, true)
+}
+
+// ResumeScheduleHandler resumes a paused schedule; runs missed while paused are not caught up.
+func (h *ScheduleHandler) ResumeScheduleHandler(c echo.Context) error {
+	return h.setPaused(c, false)
+}
+
+func (h *ScheduleHandler) setPaused(c echo.Context, paused bool) error {
+	ctx := c.Request().Context()
+	report, schedule := c.Param("report"), c.Param("schedule")
+	var err error
+	if paused {
+		err = h.svc.Pause(ctx, report, schedule)
+	} else {
+		err = h.svc.Resume(ctx, report, schedule)
+	}
+	switch {
+	case errors.Is(err, service.ErrScheduleNotFound):
+		return serviceutils.ResponseError(c, http.StatusNotFound, "Schedule not found", err)
+	case err != nil:
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to update schedule", err)
+	}
+	if paused {
+		return serviceutils.ResponseSuccess(c, http.StatusOK, "Schedule paused", nil)
+	}
+	return serviceutils.ResponseSuccess(c, http.StatusOK, "Schedule resumed", nil)
+}
+
+// ListRunsHandler lists the runs of a schedule, newest first: GET /schedules/:report/:schedule/runs?limit=20.
+func (h *ScheduleHandler) ListRunsHandler(c echo.Context) error {
+	limit, err := intParam(c.QueryParams(), "limit", 20)
+	if err != nil {
+		return serviceutils.ResponseError(c, http.StatusBadRequest, "Invalid limit", err)
+	}
+	runs, err := h.svc.Runs(c.Request().Context(), c.Param("report"), c.Param("schedule"), limit)
+	switch {
+	case errors.Is(err, service.ErrScheduleNotFound):
+		return serviceutils.ResponseError(c, http.StatusNotFound, "Schedule not found", err)
+	case err != nil:
+		return serviceutils.ResponseError(c, http.StatusInternalServerError, "Failed to list schedule runs", err)
+	}
+	return serviceutils.ResponseSuccess(c, http.StatusOK, "Schedule runs listed successfully", runs)
+}
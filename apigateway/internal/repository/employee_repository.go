@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/locvowork/employee_management_sample/apigateway/internal/domain"
 	"github.com/locvowork/employee_management_sample/apigateway/internal/repository/builder"
@@ -13,6 +14,7 @@ var (
 	salaryTable      = "employees.salary"
 	deptEmpTable     = "employees.dept_emp"
 	deptManagerTable = "employees.dept_manager"
+	departmentTable  = "employees.department"
 )
 
 type employeeRepository struct {
@@ -194,3 +196,30 @@ func (r *employeeRepository) GetTitle(ctx context.Context, empID int) (*domain.T
 	}
 	return &t, nil
 }
+
+func (r *employeeRepository) ListDepartmentSalaries(ctx context.Context, asOf time.Time) ([]domain.DepartmentSalary, error) {
+	// The builder has no GROUP BY, so the aggregate is written out
+	query := `SELECT d.dept_no, d.dept_name, COUNT(*), SUM(s.salary), AVG(s.salary), MIN(s.salary), MAX(s.salary)
+		FROM ` + deptEmpTable + ` de
+		JOIN ` + departmentTable + ` d ON d.dept_no = de.dept_no
+		JOIN ` + salaryTable + ` s ON s.employee_id = de.emp_no
+		WHERE $1 BETWEEN de.from_date AND de.to_date AND $1 BETWEEN s.from_date AND s.to_date
+		GROUP BY d.dept_no, d.dept_name
+		ORDER BY d.dept_no`
+
+	rows, err := r.db.QueryContext(ctx, query, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var salaries []domain.DepartmentSalary
+	for rows.Next() {
+		var ds domain.DepartmentSalary
+		if err := rows.Scan(&ds.DeptNo, &ds.DeptName, &ds.Employees, &ds.TotalSalary, &ds.AverageSalary, &ds.MinSalary, &ds.MaxSalary); err != nil {
+			return nil, err
+		}
+		salaries = append(salaries, ds)
+	}
+	return salaries, rows.Err()
+}
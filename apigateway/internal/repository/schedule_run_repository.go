@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/locvowork/employee_management_sample/apigateway/internal/domain"
+	"github.com/locvowork/employee_management_sample/apigateway/internal/repository/builder"
+)
+
+var (
+	scheduleRunTable   = "reporting.schedule_run"
+	scheduleStateTable = "reporting.schedule_state"
+)
+
+const scheduleRunSchema = `
+CREATE SCHEMA IF NOT EXISTS reporting;
+
+CREATE TABLE IF NOT EXISTS reporting.schedule_run (
+	id           TEXT PRIMARY KEY,
+	report       TEXT NOT NULL,
+	schedule     TEXT NOT NULL,
+	trigger      TEXT NOT NULL,
+	status       TEXT NOT NULL,
+	scheduled_at TIMESTAMPTZ NOT NULL,
+	started_at   TIMESTAMPTZ NOT NULL,
+	finished_at  TIMESTAMPTZ,
+	file         TEXT NOT NULL DEFAULT '',
+	size         BIGINT NOT NULL DEFAULT 0,
+	error        TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS schedule_run_schedule_idx ON reporting.schedule_run (report, schedule, started_at DESC);
+
+CREATE TABLE IF NOT EXISTS reporting.schedule_state (
+	report   TEXT NOT NULL,
+	schedule TEXT NOT NULL,
+	paused   BOOLEAN NOT NULL DEFAULT FALSE,
+	PRIMARY KEY (report, schedule)
+);
+`
+
+type scheduleRunRepository struct {
+	db *sql.DB
+}
+
+// NewScheduleRunRepository creates a new instance of ScheduleRunRepository
+func NewScheduleRunRepository(db *sql.DB) domain.ScheduleRunRepository {
+	return &scheduleRunRepository{db: db}
+}
+
+func (r *scheduleRunRepository) EnsureSchema(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, scheduleRunSchema)
+	return err
+}
+
+func (r *scheduleRunRepository) CreateRun(ctx context.Context, run *domain.ScheduleRun) error {
+	b := builder.NewSQLBuilder()
+	query, args := b.Insert(scheduleRunTable, "id", "report", "schedule", "trigger", "status", "scheduled_at", "started_at", "finished_at", "file", "size", "error").
+		Values(run.ID, run.Report, run.Schedule, run.Trigger, string(run.Status), run.ScheduledAt, run.StartedAt, run.FinishedAt, run.File, run.Size, run.Error).
+		Build()
+
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *scheduleRunRepository) FinishRun(ctx context.Context, run *domain.ScheduleRun) error {
+	b := builder.NewSQLBuilder()
+	query, args := b.Update(scheduleRunTable).
+		Set("status", string(run.Status)).
+		Set("finished_at", run.FinishedAt).
+		Set("file", run.File).
+		Set("size", run.Size).
+		Set("error", run.Error).
+		Where("id = ?", run.ID).
+		Build()
+
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *scheduleRunRepository) ListRuns(ctx context.Context, report, schedule string, limit int) ([]domain.ScheduleRun, error) {
+	b := builder.NewSQLBuilder()
+	b.Select("id", "report", "schedule", "trigger", "status", "scheduled_at", "started_at", "finished_at", "file", "size", "error").
+		From(scheduleRunTable).
+		Where("report = ?", report).
+		Where("schedule = ?", schedule).
+		OrderBy("started_at DESC")
+	if limit > 0 {
+		b.Limit(limit)
+	}
+
+	query, args := b.Build()
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []domain.ScheduleRun
+	for rows.Next() {
+		var run domain.ScheduleRun
+		var status string
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&run.ID, &run.Report, &run.Schedule, &run.Trigger, &status, &run.ScheduledAt, &run.StartedAt,
+			&finishedAt, &run.File, &run.Size, &run.Error); err != nil {
+			return nil, err
+		}
+		run.Status = domain.ScheduleRunStatus(status)
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func (r *scheduleRunRepository) SetPaused(ctx context.Context, report, schedule string, paused bool) error {
+	b := builder.NewSQLBuilder()
+	query, args := b.Insert(scheduleStateTable, "report", "schedule", "paused").
+		Values(report, schedule, paused).
+		OnConflict("(report, schedule) DO UPDATE SET paused = EXCLUDED.paused").
+		Build()
+
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *scheduleRunRepository) PausedSchedules(ctx context.Context) (map[string]bool, error) {
+	b := builder.NewSQLBuilder()
+	query, args := b.Select("report", "schedule").
+		From(scheduleStateTable).
+		Where("paused = ?", true).
+		Build()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	paused := make(map[string]bool)
+	for rows.Next() {
+		var report, schedule string
+		if err := rows.Scan(&report, &schedule); err != nil {
+			return nil, err
+		}
+		paused[report+"/"+schedule] = true
+	}
+	return paused, rows.Err()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/locvowork/employee_management_sample/apigateway/internal/domain"
 )
@@ -15,6 +16,7 @@ type EmployeeService interface {
 	Delete(ctx context.Context, id int) error
 	List(ctx context.Context, filter domain.EmployeeFilter) ([]domain.Employee, error)
 	GetReport(ctx context.Context, id int) (*domain.EmployeeReport, error)
+	ListDepartmentSalaries(ctx context.Context, asOf time.Time) ([]domain.DepartmentSalary, error)
 }
 
 type employeeService struct {
@@ -45,6 +47,10 @@ func (s *employeeService) List(ctx context.Context, filter domain.EmployeeFilter
 	return s.repo.List(ctx, filter)
 }
 
+func (s *employeeService) ListDepartmentSalaries(ctx context.Context, asOf time.Time) ([]domain.DepartmentSalary, error) {
+	return s.repo.ListDepartmentSalaries(ctx, asOf)
+}
+
 func (s *employeeService) GetReport(ctx context.Context, id int) (*domain.EmployeeReport, error) {
 	emp, err := s.repo.GetByID(ctx, id)
 	if err != nil {
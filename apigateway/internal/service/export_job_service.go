@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/locvowork/employee_management_sample/apigateway/internal/domain"
+	"github.com/locvowork/employee_management_sample/apigateway/internal/logger"
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
+)
+
+var (
+	ErrExportJobNotFound = errors.New("export job not found")
+	ErrExportJobNotReady = errors.New("export job has no file")
+	ErrExportQueueFull   = errors.New("export queue is full")
+	ErrInvalidExportJob  = errors.New("invalid export job")
+)
+
+// ExportJobConfig configures the worker pool and file storage of export jobs
+type ExportJobConfig struct {
+	Dir       string        // Directory of the result files
+	Workers   int           // Jobs rendered at the same time (default 2)
+	QueueSize int           // Jobs waiting for a worker before Submit fails (default 100)
+	Retention time.Duration // Jobs and their files are removed this long after they finish (default 24h)
+	Locales   *excel.Locales
+}
+
+// ExportJobService renders registry reports in the background and keeps the result files until they expire
+type ExportJobService interface {
+	Submit(ctx context.Context, req domain.ExportJobRequest) (*domain.ExportJob, error)
+	Get(ctx context.Context, id string) (*domain.ExportJob, error)
+	// Open returns the result file of a succeeded job; the caller closes it
+	Open(ctx context.Context, id string) (*domain.ExportJob, *os.File, error)
+	// Start runs the workers and the expiry of old jobs until ctx is done
+	Start(ctx context.Context)
+}
+
+type exportJob struct {
+	job domain.ExportJob
+	req domain.ExportJobRequest
+}
+
+type exportJobService struct {
+	registry *excel.ReportRegistry
+	cfg      ExportJobConfig
+	queue    chan *exportJob
+	now      func() time.Time
+
+	mu   sync.RWMutex
+	jobs map[string]*exportJob
+}
+
+func NewExportJobService(registry *excel.ReportRegistry, cfg ExportJobConfig) (ExportJobService, error) {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 2
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = 24 * time.Hour
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	return &exportJobService{
+		registry: registry,
+		cfg:      cfg,
+		queue:    make(chan *exportJob, cfg.QueueSize),
+		now:      time.Now,
+		jobs:     make(map[string]*exportJob),
+	}, nil
+}
+
+func (s *exportJobService) Submit(ctx context.Context, req domain.ExportJobRequest) (*domain.ExportJob, error) {
+	format, err := excel.ParseReportFormat(req.Format)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExportJob, err)
+	}
+	req.Format = string(format)
+	if !s.hasReport(req.Report) {
+		return nil, fmt.Errorf("%w: %s", excel.ErrReportNotFound, req.Report)
+	}
+
+	id, err := newRandomID()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	j := &exportJob{
+		req: req,
+		job: domain.ExportJob{
+			ID:        id,
+			Report:    req.Report,
+			Format:    req.Format,
+			Status:    domain.ExportJobQueued,
+			Progress:  domain.ExportJobProgress{Stage: "queued"},
+			CreatedAt: now,
+			ExpiresAt: now.Add(s.cfg.Retention),
+		},
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case s.queue <- j:
+	default:
+		return nil, ErrExportQueueFull
+	}
+	s.jobs[id] = j
+	logger.InfoLog(ctx, "export job %s queued: report %s as %s", id, req.Report, req.Format)
+	job := j.job
+	return &job, nil
+}
+
+func (s *exportJobService) hasReport(name string) bool {
+	for _, info := range s.registry.Reports() {
+		if info.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *exportJobService) Get(ctx context.Context, id string) (*domain.ExportJob, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrExportJobNotFound
+	}
+	job := j.job
+	return &job, nil
+}
+
+func (s *exportJobService) Open(ctx context.Context, id string) (*domain.ExportJob, *os.File, error) {
+	job, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != domain.ExportJobSucceeded {
+		return job, nil, fmt.Errorf("%w: job is %s", ErrExportJobNotReady, job.Status)
+	}
+	f, err := os.Open(s.filePath(job))
+	if err != nil {
+		return job, nil, fmt.Errorf("failed to open export file: %w", err)
+	}
+	return job, f, nil
+}
+
+func (s *exportJobService) filePath(job *domain.ExportJob) string {
+	return filepath.Join(s.cfg.Dir, job.ID+"."+excel.ExportFormat(job.Format).ReportExtension())
+}
+
+func (s *exportJobService) Start(ctx context.Context) {
+	for i := 0; i < s.cfg.Workers; i++ {
+		go s.work(ctx)
+	}
+	go s.expire(ctx)
+}
+
+func (s *exportJobService) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-s.queue:
+			s.run(ctx, j)
+		}
+	}
+}
+
+// update changes a job under the lock
+func (s *exportJobService) update(j *exportJob, fn func(job *domain.ExportJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&j.job)
+}
+
+func (s *exportJobService) run(ctx context.Context, j *exportJob) {
+	s.mu.RLock()
+	_, ok := s.jobs[j.job.ID]
+	s.mu.RUnlock()
+	if !ok {
+		return // Expired while queued
+	}
+
+	started := s.now()
+	s.update(j, func(job *domain.ExportJob) {
+		job.Status = domain.ExportJobRunning
+		job.StartedAt = &started
+		job.Progress = domain.ExportJobProgress{Stage: "loading", Percent: 10}
+	})
+
+	size, err := s.render(ctx, j)
+	finished := s.now()
+	s.update(j, func(job *domain.ExportJob) {
+		job.FinishedAt = &finished
+		job.ExpiresAt = finished.Add(s.cfg.Retention)
+		if err != nil {
+			job.Status = domain.ExportJobFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = domain.ExportJobSucceeded
+		job.FileName = job.Report + "." + excel.ExportFormat(job.Format).ReportExtension()
+		job.Size = size
+		job.Progress = domain.ExportJobProgress{Stage: "done", Percent: 100, BytesWritten: size}
+	})
+	if err != nil {
+		logger.ErrorLog(ctx, "export job %s failed: %v", j.job.ID, err)
+		return
+	}
+	logger.InfoLog(ctx, "export job %s finished in %s (%d bytes)", j.job.ID, finished.Sub(started), size)
+}
+
+// render writes the report of a job to its result file and returns the file size
+func (s *exportJobService) render(ctx context.Context, j *exportJob) (int64, error) {
+	req := j.req
+	exporter, err := newReportExporter(ctx, s.registry, s.cfg.Locales, reportExport{
+		Report:         req.Report,
+		Params:         url.Values(req.Params),
+		Role:           req.Role,
+		Lang:           req.Lang,
+		AcceptLanguage: req.AcceptLanguage,
+		RequestID:      j.job.ID,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.update(j, func(job *domain.ExportJob) {
+		job.Progress = domain.ExportJobProgress{Stage: "rendering", Percent: 50}
+	})
+	return writeExportFile(s.filePath(&j.job), func(w io.Writer) error {
+		return exporter.ToFormat(&progressWriter{w: w, report: func(n int64) {
+			s.update(j, func(job *domain.ExportJob) { job.Progress.BytesWritten = n })
+		}}, excel.ExportFormat(req.Format))
+	})
+}
+
+// progressWriter reports the bytes written to the result file
+type progressWriter struct {
+	w      io.Writer
+	n      int64
+	report func(n int64)
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.n += int64(n)
+	pw.report(pw.n)
+	return n, err
+}
+
+// expire removes expired jobs and their files, and files left over by earlier runs of the service
+func (s *exportJobService) expire(ctx context.Context) {
+	interval := s.cfg.Retention / 10
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.removeExpired(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *exportJobService) removeExpired(ctx context.Context) {
+	now := s.now()
+	known := make(map[string]bool)
+
+	s.mu.Lock()
+	for id, j := range s.jobs {
+		if j.job.Status == domain.ExportJobRunning || now.Before(j.job.ExpiresAt) {
+			known[id] = true
+			continue
+		}
+		delete(s.jobs, id)
+		if j.job.Status == domain.ExportJobSucceeded {
+			os.Remove(s.filePath(&j.job))
+		}
+		logger.InfoLog(ctx, "export job %s expired", id)
+	}
+	s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		logger.ErrorLog(ctx, fmt.Sprintf("failed to read export directory: %v", err))
+		return
+	}
+	for _, entry := range entries {
+		id := strings.SplitN(entry.Name(), ".", 2)[0]
+		if entry.IsDir() || known[id] {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && now.Sub(info.ModTime()) > s.cfg.Retention {
+			os.Remove(filepath.Join(s.cfg.Dir, entry.Name()))
+		}
+	}
+}
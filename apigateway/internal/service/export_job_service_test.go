@@ -0,0 +1,158 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/locvowork/employee_management_sample/apigateway/internal/domain"
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exportJobTemplate = `
+name: "Items"
+sheets:
+  - name: "Items"
+    sections:
+      - id: "items"
+        show_header: true
+        columns:
+          - field_name: "Name"
+            header: "Name"
+`
+
+type exportJobItem struct {
+	Name string
+}
+
+func newTestExportJobService(t *testing.T, cfg ExportJobConfig) *exportJobService {
+	reports := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(reports, "items.yaml"), []byte(exportJobTemplate), 0o644))
+	registry := excel.NewReportRegistry(reports)
+	registry.RegisterProvider("items", func(ctx context.Context, params url.Values) (interface{}, error) {
+		if params.Get("fail") != "" {
+			return nil, errors.New("provider failed")
+		}
+		return []exportJobItem{{Name: params.Get("name")}}, nil
+	})
+	require.NoError(t, registry.Reload())
+
+	cfg.Dir = t.TempDir()
+	svc, err := NewExportJobService(registry, cfg)
+	require.NoError(t, err)
+	return svc.(*exportJobService)
+}
+
+// readBundleFile returns a file of a CSV bundle (the csv format of report exports)
+func readBundleFile(t *testing.T, data []byte, name string) string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	f, err := zr.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+	b, err := ioutil.ReadAll(f)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func waitForJob(t *testing.T, svc ExportJobService, id string) *domain.ExportJob {
+	for i := 0; i < 200; i++ {
+		job, err := svc.Get(context.Background(), id)
+		require.NoError(t, err)
+		if job.Status == domain.ExportJobSucceeded || job.Status == domain.ExportJobFailed {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return nil
+}
+
+func TestExportJobLifecycle(t *testing.T) {
+	svc := newTestExportJobService(t, ExportJobConfig{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc.Start(ctx)
+
+	job, err := svc.Submit(ctx, domain.ExportJobRequest{Report: "items", Format: "csv", Params: map[string][]string{"name": {"Widget"}}})
+	require.NoError(t, err)
+	assert.Equal(t, domain.ExportJobQueued, job.Status)
+
+	job = waitForJob(t, svc, job.ID)
+	assert.Equal(t, domain.ExportJobSucceeded, job.Status)
+	assert.Equal(t, "items.zip", job.FileName)
+	assert.Equal(t, domain.ExportJobProgress{Stage: "done", Percent: 100, BytesWritten: job.Size}, job.Progress)
+
+	_, f, err := svc.Open(ctx, job.ID)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(f)
+	f.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "Name\nWidget\n", readBundleFile(t, data, "Items/items.csv"))
+
+	failed, err := svc.Submit(ctx, domain.ExportJobRequest{Report: "items", Params: map[string][]string{"fail": {"1"}}})
+	require.NoError(t, err)
+	failed = waitForJob(t, svc, failed.ID)
+	assert.Equal(t, domain.ExportJobFailed, failed.Status)
+	assert.Equal(t, "report items, section items: provider failed", failed.Error)
+	_, _, err = svc.Open(ctx, failed.ID)
+	assert.True(t, errors.Is(err, ErrExportJobNotReady))
+}
+
+func TestExportJobSubmitErrors(t *testing.T) {
+	svc := newTestExportJobService(t, ExportJobConfig{QueueSize: 1})
+	ctx := context.Background()
+
+	_, err := svc.Submit(ctx, domain.ExportJobRequest{Report: "items", Format: "pdf"})
+	assert.True(t, errors.Is(err, ErrInvalidExportJob))
+	_, err = svc.Submit(ctx, domain.ExportJobRequest{Report: "invoices"})
+	assert.True(t, errors.Is(err, excel.ErrReportNotFound))
+
+	// Without workers the queue fills up
+	_, err = svc.Submit(ctx, domain.ExportJobRequest{Report: "items"})
+	assert.NoError(t, err)
+	_, err = svc.Submit(ctx, domain.ExportJobRequest{Report: "items"})
+	assert.Equal(t, ErrExportQueueFull, err)
+
+	_, err = svc.Get(ctx, "missing")
+	assert.Equal(t, ErrExportJobNotFound, err)
+}
+
+func TestExportJobExpiry(t *testing.T) {
+	svc := newTestExportJobService(t, ExportJobConfig{Retention: time.Hour})
+	ctx := context.Background()
+
+	job, err := svc.Submit(ctx, domain.ExportJobRequest{Report: "items", Format: "xlsx"})
+	require.NoError(t, err)
+	svc.run(ctx, <-svc.queue)
+	job, err = svc.Get(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.ExportJobSucceeded, job.Status)
+	path := filepath.Join(svc.cfg.Dir, job.ID+".xlsx")
+	assert.FileExists(t, path)
+
+	// Files of earlier runs are removed once they are older than the retention
+	orphan := filepath.Join(svc.cfg.Dir, "0123.csv")
+	require.NoError(t, os.WriteFile(orphan, []byte("x"), 0o644))
+
+	svc.removeExpired(ctx)
+	_, err = svc.Get(ctx, job.ID)
+	assert.NoError(t, err)
+
+	svc.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	svc.removeExpired(ctx)
+	_, err = svc.Get(ctx, job.ID)
+	assert.Equal(t, ErrExportJobNotFound, err)
+	assert.NoFileExists(t, path)
+	assert.NoFileExists(t, orphan)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
+)
+
+// reportExport is a registry report export run outside of a request
+type reportExport struct {
+	Report         string
+	Params         url.Values
+	Role           string // Empty: the restricted policy (see excel.RestrictedPolicy)
+	Lang           string
+	AcceptLanguage string
+	RequestID      string // Written to the report metadata
+}
+
+// newReportExporter creates the exporter of a registry report with its data, locale and redaction role
+func newReportExporter(ctx context.Context, registry *excel.ReportRegistry, locales *excel.Locales, req reportExport) (*excel.ExcelDataExporter, error) {
+	exporter, err := registry.NewExporter(ctx, req.Report, req.Params)
+	if err != nil {
+		return nil, err
+	}
+	if locales != nil {
+		exporter.SetLocale(locales.Negotiate(req.Lang, req.AcceptLanguage))
+	}
+	setRole := exporter.SetRestrictedRole
+	if req.Role != "" {
+		setRole = func() error { return exporter.SetRole(req.Role) }
+	}
+	if err := setRole(); err != nil && !errors.Is(err, excel.ErrNoRedactionRoles) {
+		return nil, err
+	}
+	if err := exporter.ValidateExpressions(); err != nil {
+		return nil, err
+	}
+	exporter.SetRequestID(req.RequestID)
+	return exporter, nil
+}
+
+// writeExportFile writes a file through a temporary file, so readers never see a partial file, and returns its size
+func writeExportFile(path string, write func(w io.Writer) error) (int64, error) {
+	tmp, err := os.Create(path + ".tmp")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	w := &countingWriter{w: tmp}
+	err = write(w)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	return w.n, nil
+}
+
+// countingWriter counts the bytes written
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+func newRandomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
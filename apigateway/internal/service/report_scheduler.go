@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/locvowork/employee_management_sample/apigateway/internal/domain"
+	"github.com/locvowork/employee_management_sample/apigateway/internal/logger"
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("schedule not found")
+	ErrScheduleRunning  = errors.New("previous run of the schedule is still running")
+)
+
+// ReportSchedulerConfig configures the scheduler of recurring reports
+type ReportSchedulerConfig struct {
+	OutboxDir string        // Root of the output directories of the schedules
+	Interval  time.Duration // How often due schedules are checked (default 30s)
+	Locales   *excel.Locales
+}
+
+// ReportScheduler runs the schedules declared by report templates (YAML: schedules) and writes the
+// exports to the outbox. Runs are recorded in the ScheduleRunRepository.
+type ReportScheduler interface {
+	List(ctx context.Context) ([]domain.ReportScheduleStatus, error)
+	// Trigger starts a run now; it fails with ErrScheduleRunning while the previous run is running
+	Trigger(ctx context.Context, report, schedule string) (*domain.ScheduleRun, error)
+	Pause(ctx context.Context, report, schedule string) error
+	Resume(ctx context.Context, report, schedule string) error
+	Runs(ctx context.Context, report, schedule string, limit int) ([]domain.ScheduleRun, error)
+	// Start loads the paused schedules and runs due schedules until ctx is done
+	Start(ctx context.Context) error
+}
+
+// scheduleState is the runtime state of a schedule
+type scheduleState struct {
+	report  string
+	cfg     excel.ReportSchedule
+	next    time.Time
+	running bool
+}
+
+type reportScheduler struct {
+	registry *excel.ReportRegistry
+	repo     domain.ScheduleRunRepository
+	cfg      ReportSchedulerConfig
+	now      func() time.Time
+
+	mu     sync.Mutex
+	ctx    context.Context // Context of the runs
+	states map[string]*scheduleState
+	paused map[string]bool
+	wg     sync.WaitGroup
+}
+
+func NewReportScheduler(registry *excel.ReportRegistry, repo domain.ScheduleRunRepository, cfg ReportSchedulerConfig) ReportScheduler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	return &reportScheduler{
+		registry: registry,
+		repo:     repo,
+		cfg:      cfg,
+		now:      time.Now,
+		ctx:      context.Background(),
+		states:   make(map[string]*scheduleState),
+		paused:   make(map[string]bool),
+	}
+}
+
+func scheduleKey(report, schedule string) string {
+	return report + "/" + schedule
+}
+
+func (s *reportScheduler) Start(ctx context.Context) error {
+	paused, err := s.repo.PausedSchedules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load paused schedules: %w", err)
+	}
+	s.mu.Lock()
+	s.ctx = ctx
+	s.paused = paused
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			s.tick(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// sync updates the schedules from the registry, keeping the next run time of unchanged schedules. Call with s.mu held.
+func (s *reportScheduler) sync(now time.Time) {
+	seen := make(map[string]bool)
+	for _, info := range s.registry.Reports() {
+		for _, cfg := range info.Schedules {
+			key := scheduleKey(info.Name, cfg.Name)
+			seen[key] = true
+			st, ok := s.states[key]
+			if !ok {
+				st = &scheduleState{report: info.Name}
+				s.states[key] = st
+			}
+			if !ok || st.cfg.Cron != cfg.Cron || st.cfg.Timezone != cfg.Timezone {
+				st.next = cfg.Next(now)
+			}
+			st.cfg = cfg
+		}
+	}
+	for key := range s.states {
+		if !seen[key] {
+			delete(s.states, key)
+		}
+	}
+}
+
+// tick starts the runs of the due schedules
+func (s *reportScheduler) tick(ctx context.Context) {
+	now := s.now()
+	s.mu.Lock()
+	s.sync(now)
+	var due []*scheduleState
+	var scheduledAt []time.Time
+	for key, st := range s.states {
+		if s.paused[key] || st.next.IsZero() || now.Before(st.next) {
+			continue
+		}
+		due = append(due, st)
+		scheduledAt = append(scheduledAt, st.next)
+		st.next = st.cfg.Next(now)
+	}
+	s.mu.Unlock()
+
+	for i, st := range due {
+		if _, err := s.start(ctx, st, "cron", scheduledAt[i]); err != nil && !errors.Is(err, ErrScheduleRunning) {
+			logger.ErrorLog(ctx, fmt.Sprintf("failed to start schedule %s/%s: %v", st.report, st.cfg.Name, err))
+		}
+	}
+}
+
+// start records a run and renders it in the background. If the previous run is still running, the run is
+// recorded as skipped and ErrScheduleRunning is returned.
+func (s *reportScheduler) start(ctx context.Context, st *scheduleState, trigger string, scheduledAt time.Time) (*domain.ScheduleRun, error) {
+	id, err := newRandomID()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	run := &domain.ScheduleRun{
+		ID:          id,
+		Report:      st.report,
+		Schedule:    st.cfg.Name,
+		Trigger:     trigger,
+		Status:      domain.ScheduleRunRunning,
+		ScheduledAt: scheduledAt,
+		StartedAt:   now,
+	}
+
+	s.mu.Lock()
+	running := st.running
+	st.running = true
+	cfg := st.cfg
+	runCtx := s.ctx
+	s.mu.Unlock()
+
+	if running {
+		run.Status = domain.ScheduleRunSkipped
+		run.FinishedAt = &now
+		run.Error = ErrScheduleRunning.Error()
+		if err := s.repo.CreateRun(ctx, run); err != nil {
+			logger.ErrorLog(ctx, fmt.Sprintf("failed to record run %s: %v", run.ID, err))
+		}
+		logger.InfoLog(ctx, "schedule %s/%s skipped: previous run is still running", run.Report, run.Schedule)
+		return run, ErrScheduleRunning
+	}
+
+	if err := s.repo.CreateRun(ctx, run); err != nil {
+		s.mu.Lock()
+		st.running = false
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to record run: %w", err)
+	}
+	logger.InfoLog(ctx, "schedule %s/%s started (%s)", run.Report, run.Schedule, trigger)
+
+	started := *run
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.execute(runCtx, cfg, run)
+		s.mu.Lock()
+		st.running = false
+		s.mu.Unlock()
+	}()
+	return &started, nil
+}
+
+// execute renders a run into the outbox and records its outcome
+func (s *reportScheduler) execute(ctx context.Context, cfg excel.ReportSchedule, run *domain.ScheduleRun) {
+	file, size, err := s.render(ctx, cfg, run)
+	finished := s.now()
+	run.FinishedAt = &finished
+	if err != nil {
+		run.Status = domain.ScheduleRunFailed
+		run.Error = err.Error()
+		logger.ErrorLog(ctx, fmt.Sprintf("schedule %s/%s failed: %v", run.Report, run.Schedule, err))
+	} else {
+		run.Status = domain.ScheduleRunSucceeded
+		run.File = file
+		run.Size = size
+		logger.InfoLog(ctx, "schedule %s/%s wrote %s (%d bytes)", run.Report, run.Schedule, file, size)
+	}
+
+	// Record the outcome even if the scheduler is shutting down
+	if err := s.repo.FinishRun(context.Background(), run); err != nil {
+		logger.ErrorLog(ctx, fmt.Sprintf("failed to record run %s: %v", run.ID, err))
+	}
+}
+
+// render writes the export of a run and returns its path relative to the outbox
+func (s *reportScheduler) render(ctx context.Context, cfg excel.ReportSchedule, run *domain.ScheduleRun) (string, int64, error) {
+	params, name, err := cfg.Render(run.Report, run.ScheduledAt)
+	if err != nil {
+		return "", 0, err
+	}
+	exporter, err := newReportExporter(ctx, s.registry, s.cfg.Locales, reportExport{
+		Report:    run.Report,
+		Params:    params,
+		Role:      cfg.Role,
+		Lang:      cfg.Lang,
+		RequestID: run.ID,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	file := filepath.Join(filepath.FromSlash(cfg.OutputDir), name)
+	path := filepath.Join(s.cfg.OutboxDir, file)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", 0, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	size, err := writeExportFile(path, func(w io.Writer) error {
+		return exporter.ToFormat(w, excel.ExportFormat(cfg.Format))
+	})
+	return filepath.ToSlash(file), size, err
+}
+
+// state returns the state of a schedule. Call with s.mu held.
+func (s *reportScheduler) state(report, schedule string) (*scheduleState, error) {
+	s.sync(s.now())
+	st, ok := s.states[scheduleKey(report, schedule)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s/%s", ErrScheduleNotFound, report, schedule)
+	}
+	return st, nil
+}
+
+func (s *reportScheduler) List(ctx context.Context) ([]domain.ReportScheduleStatus, error) {
+	s.mu.Lock()
+	s.sync(s.now())
+	list := make([]domain.ReportScheduleStatus, 0, len(s.states))
+	for key, st := range s.states {
+		status := domain.ReportScheduleStatus{
+			Report:    st.report,
+			Schedule:  st.cfg.Name,
+			Cron:      st.cfg.Cron,
+			Timezone:  st.cfg.Timezone,
+			Format:    st.cfg.Format,
+			OutputDir: st.cfg.OutputDir,
+			Paused:    s.paused[key],
+			Running:   st.running,
+		}
+		if !status.Paused && !st.next.IsZero() {
+			next := st.next
+			status.NextRun = &next
+		}
+		list = append(list, status)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return scheduleKey(list[i].Report, list[i].Schedule) < scheduleKey(list[j].Report, list[j].Schedule)
+	})
+	for i := range list {
+		runs, err := s.repo.ListRuns(ctx, list[i].Report, list[i].Schedule, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(runs) > 0 {
+			list[i].LastRun = &runs[0]
+		}
+	}
+	return list, nil
+}
+
+func (s *reportScheduler) Trigger(ctx context.Context, report, schedule string) (*domain.ScheduleRun, error) {
+	s.mu.Lock()
+	st, err := s.state(report, schedule)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return s.start(ctx, st, "manual", s.now())
+}
+
+func (s *reportScheduler) Pause(ctx context.Context, report, schedule string) error {
+	return s.setPaused(ctx, report, schedule, true)
+}
+
+func (s *reportScheduler) Resume(ctx context.Context, report, schedule string) error {
+	return s.setPaused(ctx, report, schedule, false)
+}
+
+func (s *reportScheduler) setPaused(ctx context.Context, report, schedule string, paused bool) error {
+	s.mu.Lock()
+	_, err := s.state(report, schedule)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SetPaused(ctx, report, schedule, paused); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := scheduleKey(report, schedule)
+	s.paused[key] = paused
+	if st, ok := s.states[key]; ok && !paused {
+		// Runs missed while paused are not caught up
+		st.next = st.cfg.Next(s.now())
+	}
+	return nil
+}
+
+func (s *reportScheduler) Runs(ctx context.Context, report, schedule string, limit int) ([]domain.ScheduleRun, error) {
+	s.mu.Lock()
+	_, err := s.state(report, schedule)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListRuns(ctx, report, schedule, limit)
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/locvowork/employee_management_sample/apigateway/internal/domain"
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const scheduledTemplate = `
+name: "Salaries"
+schedules:
+  - name: "weekly"
+    cron: "0 7 * * MON"
+    timezone: "UTC"
+    format: "csv"
+    output_dir: "finance"
+    file_name: 'salaries_{{date "2006-01-02" .Time}}.{{.Ext}}'
+    params:
+      as_of: '{{.Time | addDays -1 | date "2006-01-02"}}'
+sheets:
+  - name: "Salaries"
+    sections:
+      - id: "salaries"
+        show_header: true
+        columns:
+          - field_name: "AsOf"
+            header: "As Of"
+`
+
+// memoryScheduleRunRepository is an in-memory domain.ScheduleRunRepository
+type memoryScheduleRunRepository struct {
+	mu     sync.Mutex
+	runs   map[string]domain.ScheduleRun
+	paused map[string]bool
+}
+
+func newMemoryScheduleRunRepository() *memoryScheduleRunRepository {
+	return &memoryScheduleRunRepository{runs: make(map[string]domain.ScheduleRun), paused: make(map[string]bool)}
+}
+
+func (r *memoryScheduleRunRepository) EnsureSchema(ctx context.Context) error { return nil }
+
+func (r *memoryScheduleRunRepository) CreateRun(ctx context.Context, run *domain.ScheduleRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[run.ID] = *run
+	return nil
+}
+
+func (r *memoryScheduleRunRepository) FinishRun(ctx context.Context, run *domain.ScheduleRun) error {
+	return r.CreateRun(ctx, run)
+}
+
+func (r *memoryScheduleRunRepository) ListRuns(ctx context.Context, report, schedule string, limit int) ([]domain.ScheduleRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var runs []domain.ScheduleRun
+	for _, run := range r.runs {
+		if run.Report == report && run.Schedule == schedule {
+			runs = append(runs, run)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.After(runs[j].StartedAt) })
+	if limit > 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
+
+func (r *memoryScheduleRunRepository) SetPaused(ctx context.Context, report, schedule string, paused bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paused[scheduleKey(report, schedule)] = paused
+	return nil
+}
+
+func (r *memoryScheduleRunRepository) PausedSchedules(ctx context.Context) (map[string]bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	paused := make(map[string]bool)
+	for k, v := range r.paused {
+		if v {
+			paused[k] = true
+		}
+	}
+	return paused, nil
+}
+
+type salaryRow struct {
+	AsOf string
+}
+
+func newTestReportScheduler(t *testing.T, provider excel.SectionProvider) (*reportScheduler, *memoryScheduleRunRepository) {
+	reports := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(reports, "salaries.yaml"), []byte(scheduledTemplate), 0o644))
+	registry := excel.NewReportRegistry(reports)
+	registry.RegisterProvider("salaries", provider)
+	require.NoError(t, registry.Reload())
+
+	repo := newMemoryScheduleRunRepository()
+	s := NewReportScheduler(registry, repo, ReportSchedulerConfig{OutboxDir: t.TempDir()}).(*reportScheduler)
+	return s, repo
+}
+
+func asOfProvider(ctx context.Context, params url.Values) (interface{}, error) {
+	return []salaryRow{{AsOf: params.Get("as_of")}}, nil
+}
+
+func TestReportSchedulerRunsDueSchedules(t *testing.T) {
+	s, _ := newTestReportScheduler(t, asOfProvider)
+	ctx := context.Background()
+	now := time.Date(2024, 3, 18, 6, 59, 0, 0, time.UTC) // Monday
+	s.now = func() time.Time { return now }
+
+	s.tick(ctx)
+	list, err := s.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, time.Date(2024, 3, 18, 7, 0, 0, 0, time.UTC), *list[0].NextRun)
+
+	now = now.Add(90 * time.Second)
+	s.tick(ctx)
+	s.wg.Wait()
+	s.tick(ctx) // Runs once per activation
+
+	runs, err := s.Runs(ctx, "salaries", "weekly", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, domain.ScheduleRunSucceeded, runs[0].Status)
+	assert.Equal(t, "cron", runs[0].Trigger)
+	assert.Equal(t, "finance/salaries_2024-03-18.zip", runs[0].File)
+
+	data, err := ioutil.ReadFile(filepath.Join(s.cfg.OutboxDir, "finance", "salaries_2024-03-18.zip"))
+	require.NoError(t, err)
+	assert.Equal(t, "As Of\n2024-03-17\n", readBundleFile(t, data, "Salaries/salaries.csv"))
+
+	list, err = s.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 25, 7, 0, 0, 0, time.UTC), *list[0].NextRun)
+	assert.Equal(t, runs[0].ID, list[0].LastRun.ID)
+}
+
+func TestReportSchedulerSkipsOverlappingRuns(t *testing.T) {
+	release := make(chan struct{})
+	s, _ := newTestReportScheduler(t, func(ctx context.Context, params url.Values) (interface{}, error) {
+		<-release
+		return nil, errors.New("database is down")
+	})
+	ctx := context.Background()
+
+	first, err := s.Trigger(ctx, "salaries", "weekly")
+	require.NoError(t, err)
+	assert.Equal(t, domain.ScheduleRunRunning, first.Status)
+
+	skipped, err := s.Trigger(ctx, "salaries", "weekly")
+	assert.Equal(t, ErrScheduleRunning, err)
+	assert.Equal(t, domain.ScheduleRunSkipped, skipped.Status)
+
+	close(release)
+	s.wg.Wait()
+
+	runs, err := s.Runs(ctx, "salaries", "weekly", 0)
+	require.NoError(t, err)
+	statuses := map[string]domain.ScheduleRun{}
+	for _, run := range runs {
+		statuses[run.ID] = run
+	}
+	assert.Equal(t, domain.ScheduleRunFailed, statuses[first.ID].Status)
+	assert.Equal(t, "report salaries, section salaries: database is down", statuses[first.ID].Error)
+	assert.Equal(t, domain.ScheduleRunSkipped, statuses[skipped.ID].Status)
+
+	_, err = s.Trigger(ctx, "salaries", "daily")
+	assert.True(t, errors.Is(err, ErrScheduleNotFound))
+}
+
+func TestReportSchedulerPause(t *testing.T) {
+	s, repo := newTestReportScheduler(t, asOfProvider)
+	ctx := context.Background()
+	now := time.Date(2024, 3, 18, 6, 59, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	s.tick(ctx)
+
+	require.NoError(t, s.Pause(ctx, "salaries", "weekly"))
+	assert.Equal(t, map[string]bool{"salaries/weekly": true}, repo.paused)
+	now = now.Add(time.Minute)
+	s.tick(ctx)
+	s.wg.Wait()
+	runs, _ := s.Runs(ctx, "salaries", "weekly", 0)
+	assert.Empty(t, runs)
+
+	list, err := s.List(ctx)
+	require.NoError(t, err)
+	assert.True(t, list[0].Paused)
+	assert.Nil(t, list[0].NextRun)
+
+	// Missed runs are not caught up
+	require.NoError(t, s.Resume(ctx, "salaries", "weekly"))
+	list, err = s.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 25, 7, 0, 0, 0, time.UTC), *list[0].NextRun)
+
+	assert.True(t, errors.Is(s.Pause(ctx, "salaries", "hourly"), ErrScheduleNotFound))
+}
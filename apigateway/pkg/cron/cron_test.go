@@ -0,0 +1,64 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2024, 3, 13, 10, 30, 45, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 3, 13, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 3, 13, 10, 45, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2024, 3, 14, 10, 30, 0, 0, time.UTC)},
+		{"0 7 * * MON", time.Date(2024, 3, 18, 7, 0, 0, 0, time.UTC)},
+		{"0 7 * * 1-5", time.Date(2024, 3, 14, 7, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9 29 feb *", time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC).AddDate(4, 0, 0)},
+		{"0 12 1,15 * *", time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2024, 3, 13, 10, 45, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		// Day of month or day of week when both are restricted
+		{"0 0 20 * MON", time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if assert.NoError(t, err, tt.expr) {
+			assert.Equal(t, tt.want, s.Next(from), tt.expr)
+		}
+	}
+}
+
+func TestNextInLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+7", 7*3600)
+	s := MustParse("0 7 * * MON")
+	// Sunday 23:00 UTC is Monday 06:00 in UTC+7
+	from := time.Date(2024, 3, 17, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 18, 7, 0, 0, 0, time.UTC), s.Next(from))
+	assert.Equal(t, time.Date(2024, 3, 18, 7, 0, 0, 0, loc), s.Next(from.In(loc)))
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"* * * *":      `cron "* * * *": expected 5 fields, got 4`,
+		"60 * * * *":   `cron "60 * * * *": minute: 60 is out of range 0-59`,
+		"* * * foo *":  `cron "* * * foo *": month: invalid value "foo"`,
+		"*/0 * * * *":  `cron "*/0 * * * *": minute: invalid step in "*/0"`,
+		"* 5-1 * * *":  `cron "* 5-1 * * *": hour: invalid range "5-1"`,
+		"* * 0 * *":    `cron "* * 0 * *": day of month: 0 is out of range 1-31`,
+		"* * * * MON-": `cron "* * * * MON-": day of week: invalid value ""`,
+	}
+	for expr, want := range tests {
+		_, err := Parse(expr)
+		assert.EqualError(t, err, want, expr)
+	}
+}
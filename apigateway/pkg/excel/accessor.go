@@ -0,0 +1,133 @@
+package excel
+
+import (
+	"reflect"
+)
+
+// rowAccessor reads the formatted column values of a section's data items. It is compiled once per section
+// for the item type of the data: field index paths, value kinds, redaction actions and formatter chains are
+// resolved up front, so rows are read without lookups by field name or formatter spec. Items of another type
+// (e.g. in []interface{} data) and expression columns fall back to cellValue.
+type rowAccessor struct {
+	e        *ExcelDataExporter
+	itemType reflect.Type
+	cols     []columnAccessor
+}
+
+// columnAccessor is the compiled plan of one column.
+type columnAccessor struct {
+	col ColumnConfig
+	// index is the field index path in struct items, nil if the struct has no such field
+	index []int
+	// key is the map key of map items
+	key reflect.Value
+	// kind is the kind of the item type: reflect.Struct, reflect.Map or reflect.Invalid (no field access)
+	kind reflect.Kind
+	// action is the redaction action of the column
+	action string
+	// format is the formatter chain of the column, nil without formatter
+	format func(interface{}) interface{}
+}
+
+// compileRowAccessor compiles the accessor of columns for the items of data (a slice or array).
+func (e *ExcelDataExporter) compileRowAccessor(cols []ColumnConfig, data interface{}) *rowAccessor {
+	a := &rowAccessor{e: e, itemType: sliceItemType(data), cols: make([]columnAccessor, len(cols))}
+	kind := reflect.Invalid
+	if a.itemType != nil {
+		kind = a.itemType.Kind()
+	}
+	for j, col := range cols {
+		c := columnAccessor{col: col, kind: kind, action: e.redaction.Action(col.Sensitivity)}
+		switch kind {
+		case reflect.Struct:
+			c.index = e.fieldIndex(a.itemType, col.FieldName)
+		case reflect.Map:
+			if key := reflect.ValueOf(col.FieldName); key.Type().ConvertibleTo(a.itemType.Key()) {
+				c.key = key.Convert(a.itemType.Key())
+			} else {
+				c.kind = reflect.Invalid
+			}
+		}
+		if col.Formatter != nil {
+			c.format = col.Formatter
+		} else if col.FormatterName != "" {
+			if fn, err := e.resolveFormatter(col.FormatterName); err == nil {
+				c.format = fn
+			}
+		}
+		a.cols[j] = c
+	}
+	return a
+}
+
+// sliceItemType returns the element type of a slice or array, without pointers, or nil.
+func sliceItemType(data interface{}) reflect.Type {
+	t := reflect.TypeOf(data)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || (t.Kind() != reflect.Slice && t.Kind() != reflect.Array) {
+		return nil
+	}
+	t = t.Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// value returns the formatted value of column j for one data item, like cellValue.
+func (a *rowAccessor) value(j int, item reflect.Value) interface{} {
+	c := &a.cols[j]
+	v, ok := a.compiled(item)
+	if c.col.Expr != "" || !ok {
+		return a.e.cellValue(c.col, item)
+	}
+	val := c.field(v)
+	if c.action != RedactFull {
+		return a.e.redactValue(c.action, val)
+	}
+	if c.format != nil {
+		return c.format(val)
+	}
+	return val
+}
+
+// compiled returns item without pointers and whether it has the item type the accessor was compiled for.
+// Nil pointers do not.
+func (a *rowAccessor) compiled(item reflect.Value) (reflect.Value, bool) {
+	for item.Kind() == reflect.Ptr {
+		if item.IsNil() {
+			return item, false
+		}
+		item = item.Elem()
+	}
+	return item, item.Type() == a.itemType
+}
+
+// field returns the raw value of the column's field in item, or "" if the item has no such field.
+func (c *columnAccessor) field(item reflect.Value) interface{} {
+	switch c.kind {
+	case reflect.Struct:
+		if c.index == nil {
+			return ""
+		}
+		v := item.Field(c.index[0])
+		for _, i := range c.index[1:] {
+			// Fields promoted through embedded pointers
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return nil
+				}
+				v = v.Elem()
+			}
+			v = v.Field(i)
+		}
+		return v.Interface()
+	case reflect.Map:
+		if v := item.MapIndex(c.key); v.IsValid() {
+			return v.Interface()
+		}
+	}
+	return ""
+}
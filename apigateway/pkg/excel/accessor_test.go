@@ -0,0 +1,126 @@
+package excel
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type accessorAudit struct {
+	Creator string
+}
+
+type accessorRow struct {
+	*accessorAudit
+	ID     int
+	Name   string
+	Salary float64
+	Email  string
+}
+
+func accessorColumns() []ColumnConfig {
+	return []ColumnConfig{
+		{FieldName: "ID"},
+		{FieldName: "Name", FormatterName: "trim|upper"},
+		{FieldName: "Salary", FormatterName: "thousands"},
+		{FieldName: "Email", Sensitivity: "pii"},
+		{FieldName: "Creator"},
+		{FieldName: "Bonus", Expr: "Salary * 0.1"},
+		{FieldName: "Missing"},
+	}
+}
+
+func TestRowAccessorMatchesCellValue(t *testing.T) {
+	e := NewExcelDataExporter()
+	e.SetRedactionPolicy(&RedactionPolicy{Rules: map[string]string{"pii": RedactMask}})
+	cols := accessorColumns()
+
+	structs := []accessorRow{
+		{accessorAudit: &accessorAudit{Creator: "ann"}, ID: 1, Name: " alice ", Salary: 1200, Email: "alice@example.com"},
+		{ID: 2, Name: "bob", Salary: 900.5, Email: "bob@example.com"}, // nil embedded pointer
+	}
+	maps := []map[string]interface{}{{"ID": 3, "Name": "carol", "Salary": 5000.0, "Email": "carol@example.com"}}
+	mixed := []interface{}{structs[0], maps[0]}
+
+	for _, data := range []interface{}{structs, &structs, maps, mixed} {
+		a := e.compileRowAccessor(cols, data)
+		rows := reflect.Indirect(reflect.ValueOf(data))
+		for i := 0; i < rows.Len(); i++ {
+			item := rows.Index(i)
+			for j, col := range cols {
+				assert.Equal(t, e.cellValue(col, item), a.value(j, item), "%T row %d column %s", data, i, col.FieldName)
+			}
+		}
+	}
+
+	a := e.compileRowAccessor(cols, structs)
+	item := reflect.ValueOf(structs[0])
+	assert.Equal(t, "ALICE", a.value(1, item))
+	assert.Equal(t, "1,200", a.value(2, item))
+	assert.NotEqual(t, "alice@example.com", a.value(3, item))
+	assert.Equal(t, "ann", a.value(4, item))
+	assert.Equal(t, "", a.value(6, item))
+	assert.Nil(t, a.value(4, reflect.ValueOf(structs[1])))
+}
+
+func TestRowAccessorPointerItems(t *testing.T) {
+	e := NewExcelDataExporter()
+	rows := []*accessorRow{{ID: 1, Name: "alice"}, nil}
+	a := e.compileRowAccessor(accessorColumns(), rows)
+	assert.Equal(t, reflect.TypeOf(accessorRow{}), a.itemType)
+	assert.Equal(t, "ALICE", a.text(1, reflect.ValueOf(rows).Index(0), "NULL"))
+	assert.Equal(t, "NULL", a.text(1, reflect.ValueOf(rows).Index(1), "NULL"))
+
+	// Pointer items use the compiled plan, nil items fall back to cellValue
+	_, ok := a.compiled(reflect.ValueOf(rows).Index(0))
+	assert.True(t, ok)
+	_, ok = a.compiled(reflect.ValueOf(rows).Index(1))
+	assert.False(t, ok)
+}
+
+func TestConcurrentExportsShareExporter(t *testing.T) {
+	e := NewExcelDataExporter()
+	rows := make([]accessorRow, 200)
+	for i := range rows {
+		rows[i] = accessorRow{ID: i, Name: fmt.Sprintf("name %d", i), Salary: float64(i) * 10}
+	}
+	e.AddSheet("People").AddSection(&SectionConfig{ID: "people", Title: "People", ShowHeader: true, Data: rows, Columns: accessorColumns()})
+
+	want, err := e.ToBytes()
+	require.NoError(t, err)
+	var wantCSV bytes.Buffer
+	require.NoError(t, e.ToCSV(&wantCSV))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			switch i % 4 {
+			case 0:
+				data, err := e.ToBytes()
+				assert.NoError(t, err)
+				assert.Equal(t, len(want), len(data))
+			case 1:
+				var buf bytes.Buffer
+				assert.NoError(t, e.ToCSV(&buf))
+				assert.Equal(t, wantCSV.String(), buf.String())
+			case 2:
+				assert.NoError(t, e.ToJSON(&bytes.Buffer{}))
+			case 3:
+				assert.NoError(t, e.ToHTML(&bytes.Buffer{}, HTMLOptions{}))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// The style IDs of finished workbooks are not kept
+	e.cacheMu.RLock()
+	defer e.cacheMu.RUnlock()
+	assert.Empty(t, e.styleCache)
+}
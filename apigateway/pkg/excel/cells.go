@@ -0,0 +1,189 @@
+package excel
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// CellRenderer renders the titles, headers and data rows of sections as cells, for stream writers that lay
+// out sheets themselves (e.g. sections side by side). The cells get the styles, translations, formatters,
+// redaction and formulas of the other exports. Place a section before rendering its rows, so that formulas
+// of later sections can refer to it. A renderer belongs to one file and one export.
+type CellRenderer struct {
+	exporter *ExcelDataExporter
+	file     *excelize.File
+	run      *exportRun
+	// placed holds the placement, data styles and row accessor of each placed section
+	placed map[*SectionConfig]*placedSection
+}
+
+// placedSection is the state of a section placed on a CellRenderer.
+type placedSection struct {
+	placement SectionPlacement
+	styles    []int // Data styles, resolved on the first row
+	accessor  *rowAccessor
+}
+
+// NewCellRenderer returns a renderer for cells written to f. The styles it creates are cached until Release.
+func (e *ExcelDataExporter) NewCellRenderer(f *excelize.File) *CellRenderer {
+	return e.newCellRenderer(f, &exportRun{placements: make(map[string]SectionPlacement)})
+}
+
+func (e *ExcelDataExporter) newCellRenderer(f *excelize.File, run *exportRun) *CellRenderer {
+	return &CellRenderer{exporter: e, file: f, run: run, placed: make(map[*SectionConfig]*placedSection)}
+}
+
+// Release drops the styles cached for the file of the renderer.
+func (r *CellRenderer) Release() {
+	r.exporter.releaseStyles(r.file)
+}
+
+// Title returns the title cell of a section.
+func (r *CellRenderer) Title(sec *SectionConfig) (excelize.Cell, error) {
+	defaultTitle := &StyleTemplate{
+		Font:      &FontTemplate{Bold: true},
+		Alignment: &AlignmentTemplate{Horizontal: "center", Vertical: "top"},
+	}
+	sid, err := r.exporter.createStyle(r.file, resolveStyle(sec.TitleStyle, defaultTitle, sec.Locked))
+	if err != nil {
+		return excelize.Cell{}, err
+	}
+	return excelize.Cell{Value: r.exporter.localizedTitle(sec.Title), StyleID: sid}, nil
+}
+
+// Headers returns the header cells of a section.
+func (r *CellRenderer) Headers(sec *SectionConfig) ([]excelize.Cell, error) {
+	defaultHeader := &StyleTemplate{
+		Font:      &FontTemplate{Bold: true},
+		Alignment: &AlignmentTemplate{Horizontal: "center", Vertical: "top"},
+	}
+	cells := make([]excelize.Cell, len(sec.Columns))
+	for i, col := range sec.Columns {
+		sid, err := r.exporter.createStyle(r.file, resolveStyle(sec.HeaderStyle, defaultHeader, col.IsLocked(sec.Locked)))
+		if err != nil {
+			return nil, err
+		}
+		cells[i] = excelize.Cell{Value: r.exporter.localizedHeader(col), StyleID: sid}
+	}
+	return cells, nil
+}
+
+// Place records that the data rows of a section start at row and col of sheet, and returns the placement.
+// Formulas of the section and comparison columns referring to it resolve against this placement; update
+// DataLen with SetDataLen once the rows are written for sections that others derive from.
+func (r *CellRenderer) Place(sheet string, sec *SectionConfig, row, col int) SectionPlacement {
+	fieldOffsets := make(map[string]int)
+	for j, c := range sec.Columns {
+		fieldOffsets[c.FieldName] = j
+	}
+	p := SectionPlacement{SectionID: sec.ID, Sheet: sheet, StartRow: row, StartCol: col, FieldOffsets: fieldOffsets}
+	r.placed[sec] = &placedSection{placement: p}
+	if sec.ID != "" {
+		r.run.placements[sec.ID] = p
+	}
+	return p
+}
+
+// SetDataLen records the number of data rows written for a placed section.
+func (r *CellRenderer) SetDataLen(sec *SectionConfig, n int) {
+	if ps, ok := r.placed[sec]; ok {
+		ps.placement.DataLen = n
+		if sec.ID != "" {
+			r.run.placements[sec.ID] = ps.placement
+		}
+	}
+}
+
+// Placement returns the placement of a section ID.
+func (r *CellRenderer) Placement(id string) (SectionPlacement, bool) {
+	return r.run.placement(id)
+}
+
+// Row returns the cells of data row rowIndex of a placed section, starting at the first column of the
+// section. A nil item renders only the formula and comparison columns, e.g. for sections without data that
+// compare other sections.
+func (r *CellRenderer) Row(sec *SectionConfig, item interface{}, rowIndex int) ([]interface{}, error) {
+	ps, ok := r.placed[sec]
+	if !ok {
+		return nil, fmt.Errorf("section %s was not placed", sec.ID)
+	}
+	itemVal := reflect.ValueOf(item)
+	var sample reflect.Value
+	if item != nil && (ps.styles == nil || ps.accessor == nil) {
+		sample = reflect.MakeSlice(reflect.SliceOf(itemVal.Type()), 1, 1)
+		sample.Index(0).Set(itemVal)
+	}
+	if ps.styles == nil {
+		styles, err := r.dataStyles(sec, sample)
+		if err != nil {
+			return nil, err
+		}
+		ps.styles = styles
+	}
+	if ps.accessor == nil && item != nil {
+		ps.accessor = r.exporter.compileRowAccessor(sec.Columns, sample.Interface())
+	}
+	return r.rowCells(sec, ps.placement, ps.accessor, ps.styles, itemVal, rowIndex, rowIndex)
+}
+
+// defaultDataStyle returns the default data style of a section: yellow for hidden sections.
+func defaultDataStyle(sec *SectionConfig) *StyleTemplate {
+	if sec.Type == SectionTypeHidden {
+		return &StyleTemplate{Fill: &FillTemplate{Color: "FFFF00"}}
+	}
+	return nil
+}
+
+// dataStyles returns the data style ID of every column of a section, localized for the first row of dataVal.
+func (r *CellRenderer) dataStyles(sec *SectionConfig, dataVal reflect.Value) ([]int, error) {
+	styles := make([]int, len(sec.Columns))
+	for j, col := range sec.Columns {
+		style := resolveStyle(sec.DataStyle, defaultDataStyle(sec), col.IsLocked(sec.Locked))
+		style = r.exporter.localizedCellStyle(style, r.exporter.columnSample(col, dataVal))
+		sid, err := r.exporter.createStyle(r.file, style)
+		if err != nil {
+			return nil, err
+		}
+		styles[j] = sid
+	}
+	return styles, nil
+}
+
+// rowCells returns the cells of one data row. rowIndex is the index of the row within the section, for
+// banding and row stylers; rowOffset is the offset from the placement, for formulas. An invalid item renders
+// only the formula and comparison columns.
+func (r *CellRenderer) rowCells(sec *SectionConfig, placement SectionPlacement, accessor *rowAccessor, styles []int, item reflect.Value, rowIndex, rowOffset int) ([]interface{}, error) {
+	if hasRowStyles(sec) {
+		ids, err := r.exporter.rowStyleIDs(r.file, sec, defaultDataStyle(sec), rowIndex, item)
+		if err != nil {
+			return nil, err
+		}
+		styles = ids
+	}
+
+	cells := make([]interface{}, len(sec.Columns))
+	for j, col := range sec.Columns {
+		var formula string
+		var err error
+		switch {
+		case col.CompareWith != nil:
+			formula, err = r.exporter.generateDiffFormula(r.run, col, placement, rowOffset)
+		case col.Formula != "":
+			formula, err = r.exporter.generateColumnFormula(r.run, col, placement, rowOffset)
+		case item.IsValid():
+			cells[j] = excelize.Cell{Value: accessor.value(j, item), StyleID: styles[j]}
+			continue
+		default:
+			cells[j] = excelize.Cell{StyleID: styles[j]}
+			continue
+		}
+		if err != nil {
+			cells[j] = excelize.Cell{Value: fmt.Sprintf("Error: %v", err), StyleID: styles[j]}
+		} else {
+			cells[j] = excelize.Cell{Formula: formula, StyleID: styles[j]}
+		}
+	}
+	return cells, nil
+}
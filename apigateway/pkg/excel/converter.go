@@ -1,4 +1,4 @@
-package simpleexcelv2
+package excel
 
 import (
 	"fmt"
@@ -0,0 +1,220 @@
+package excel
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConvertToDynamicData_ShouldValidDynamicObject(t *testing.T) {
+	type Product struct {
+		ID       int
+		Category string
+		MetaData map[string]interface{}
+	}
+	testCases := map[string]struct {
+		input  interface{}
+		output interface{}
+	}{
+		"struct with map": {
+			input: Product{
+				ID:       101,
+				Category: "Electronics",
+				MetaData: map[string]interface{}{"Brand": "GoLang", "Status": "Available"},
+			},
+			output: map[string]interface{}{
+				"ID":              101,
+				"Category":        "Electronics",
+				"MetaData_Brand":  "GoLang",
+				"MetaData_Status": "Available",
+			},
+		},
+		"slice of structs": {
+			input: []Product{
+				{
+					ID:       101,
+					Category: "Electronics",
+					MetaData: map[string]interface{}{"Brand": "GoLang", "Status": "Available"},
+				},
+				{
+					ID:       102,
+					Category: "Electronics",
+					MetaData: map[string]interface{}{"Brand": "GoLang", "Status": "NotAvailable"},
+				},
+			},
+			output: []map[string]interface{}{
+				{
+					"ID":              101,
+					"Category":        "Electronics",
+					"MetaData_Brand":  "GoLang",
+					"MetaData_Status": "Available",
+				},
+				{
+					"ID":              102,
+					"Category":        "Electronics",
+					"MetaData_Brand":  "GoLang",
+					"MetaData_Status": "NotAvailable",
+				},
+			},
+		},
+		"slice of structs having different fields": {
+			input: []Product{
+				{
+					ID:       101,
+					Category: "Phone",
+					MetaData: map[string]interface{}{"Feature01": "10GB RAM", "Feature03": "Ip68", "Feature05": "Screen 1080p"},
+				},
+				{
+					ID:       102,
+					Category: "Phone",
+					MetaData: map[string]interface{}{"Feature02": "20GB RAM", "Feature04": "Ip68", "Feature06": "On-board card"},
+				},
+			},
+			output: []map[string]interface{}{
+				{
+					"ID":                 101,
+					"Category":           "Phone",
+					"MetaData_Feature01": "10GB RAM",
+					"MetaData_Feature02": "",
+					"MetaData_Feature03": "Ip68",
+					"MetaData_Feature04": "",
+					"MetaData_Feature05": "Screen 1080p",
+					"MetaData_Feature06": "",
+				},
+				{
+					"ID":                 102,
+					"Category":           "Phone",
+					"MetaData_Feature01": "",
+					"MetaData_Feature02": "20GB RAM",
+					"MetaData_Feature03": "",
+					"MetaData_Feature04": "Ip68",
+					"MetaData_Feature05": "",
+					"MetaData_Feature06": "On-board card",
+				},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			flattenedObject, err := ConvertToFlattenedData(tc.input)
+			if err != nil {
+				t.Fatalf("ConvertToDynamicData failed: %v", err)
+			}
+			if flattenedObject == nil {
+				t.Fatalf("ConvertToDynamicData failed: flattenedObject is nil")
+			}
+			if !reflect.DeepEqual(flattenedObject, tc.output) {
+				t.Errorf("Expected %v, got %v", tc.output, flattenedObject)
+			}
+		})
+	}
+
+}
+
+func TestConvertToDynamicData_DynamicObjectShouldValidValue(t *testing.T) {
+	type Product struct {
+		ID       int
+		Category string
+		MetaData map[string]interface{}
+	}
+	testCases := map[string]struct {
+		input        interface{}
+		outputFields []map[string]interface{}
+	}{
+		"struct with map": {
+			input: Product{
+				ID:       101,
+				Category: "Electronics",
+				MetaData: map[string]interface{}{"Brand": "GoLang", "Status": "Available"},
+			},
+			outputFields: []map[string]interface{}{
+				{
+					"ID":              101,
+					"Category":        "Electronics",
+					"MetaData_Brand":  "GoLang",
+					"MetaData_Status": "Available",
+				},
+			},
+		},
+		"slice of structs": {
+			input: []Product{
+				{
+					ID:       101,
+					Category: "Electronics",
+					MetaData: map[string]interface{}{"Brand": "GoLang", "Status": "Available"},
+				},
+				{
+					ID:       102,
+					Category: "Electronics",
+					MetaData: map[string]interface{}{"Brand": ".NET", "Status": "NotAvailable"},
+				},
+			},
+			outputFields: []map[string]interface{}{
+				{
+					"ID":              101,
+					"Category":        "Electronics",
+					"MetaData_Brand":  "GoLang",
+					"MetaData_Status": "Available",
+				},
+				{
+					"ID":              102,
+					"Category":        "Electronics",
+					"MetaData_Brand":  ".NET",
+					"MetaData_Status": "NotAvailable",
+				},
+			},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			inputValue := reflect.ValueOf(tc.input)
+			if inputValue.Kind() == reflect.Struct {
+				// Handle single struct case
+				flattenedObject, err := ConvertToFlattenedData(tc.input)
+				if err != nil {
+					t.Fatalf("ConvertToDynamicData failed: %v", err)
+				}
+				if flattenedObject == nil {
+					t.Fatalf("ConvertToDynamicData failed: flattenedObject is nil")
+				}
+				for _, expected := range tc.outputFields {
+					for expectedFieldName, expectedFieldValue := range expected {
+						flattenedMap, ok := flattenedObject.(map[string]interface{})
+						if ok {
+							if !reflect.DeepEqual(flattenedMap[expectedFieldName], expectedFieldValue) {
+								t.Errorf("Expected %v, got %v", expectedFieldValue, flattenedMap[expectedFieldName])
+							}
+						}
+					}
+				}
+
+			} else if inputValue.Kind() == reflect.Slice {
+				// Handle slice of structs case
+				flattenedObjects, err := ConvertToFlattenedData(tc.input)
+				if err != nil {
+					t.Fatalf("ConvertToDynamicData failed: %v", err)
+				}
+				if flattenedObjects == nil {
+					t.Fatalf("ConvertToDynamicData failed: flattenedObjects is nil")
+				}
+				flattenedMapSlice, ok := flattenedObjects.([]map[string]interface{})
+				if ok {
+					for i, expected := range tc.outputFields {
+						for expectedFieldName, expectedFieldValue := range expected {
+							if !reflect.DeepEqual(flattenedMapSlice[i][expectedFieldName], expectedFieldValue) {
+								t.Errorf("Expected %v, got %v", expectedFieldValue, flattenedMapSlice[i][expectedFieldName])
+							}
+						}
+					}
+				} else {
+					t.Fatalf("ConvertToDynamicData returned unexpected type: %T", flattenedObjects)
+				}
+
+			} else {
+				t.Fatalf("Unsupported input type: %T", tc.input)
+			}
+
+		})
+	}
+
+}
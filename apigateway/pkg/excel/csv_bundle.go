@@ -0,0 +1,366 @@
+package excel
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CSVQuoteMode controls when CSV fields are quoted.
+type CSVQuoteMode string
+
+const (
+	CSVQuoteMinimal CSVQuoteMode = "minimal" // Quote only fields containing the delimiter, quotes or line breaks
+	CSVQuoteAll     CSVQuoteMode = "all"     // Quote every field
+)
+
+const csvManifestName = "manifest.json"
+
+// CSVOptions configures CSV output.
+type CSVOptions struct {
+	Delimiter  rune         // Field delimiter, default ','. Use '\t' for TSV.
+	Quote      CSVQuoteMode // Default CSVQuoteMinimal
+	BOM        bool         // Prepend a UTF-8 BOM so Excel on Windows detects the encoding
+	SkipHeader bool         // Omit the header row
+	NullValue  string       // Representation of nil values, default ""
+	UseCRLF    bool         // Terminate rows with \r\n
+	PerSheet   bool         // Bundle only: one file per sheet instead of one per section
+}
+
+func (o CSVOptions) delimiter() rune {
+	if o.Delimiter == 0 {
+		return ','
+	}
+	return o.Delimiter
+}
+
+// extension returns the file extension matching the delimiter.
+func (o CSVOptions) extension() string {
+	if o.delimiter() == '\t' {
+		return ".tsv"
+	}
+	return ".csv"
+}
+
+// csvRowWriter writes delimited rows with configurable quoting.
+type csvRowWriter struct {
+	w    io.Writer
+	opts CSVOptions
+	sb   strings.Builder
+}
+
+func newCSVRowWriter(w io.Writer, opts CSVOptions) (*csvRowWriter, error) {
+	if d := opts.delimiter(); d == '"' || d == '\r' || d == '\n' {
+		return nil, fmt.Errorf("invalid csv delimiter %q", d)
+	}
+	if opts.BOM {
+		if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+			return nil, err
+		}
+	}
+	return &csvRowWriter{w: w, opts: opts}, nil
+}
+
+func (cw *csvRowWriter) needsQuotes(field string) bool {
+	if cw.opts.Quote == CSVQuoteAll {
+		return true
+	}
+	if field == "" {
+		return false
+	}
+	return strings.ContainsRune(field, cw.opts.delimiter()) || strings.ContainsAny(field, "\"\r\n") || field[0] == ' '
+}
+
+// WriteRow writes one row.
+func (cw *csvRowWriter) WriteRow(fields []string) error {
+	cw.sb.Reset()
+	for i, field := range fields {
+		if i > 0 {
+			cw.sb.WriteRune(cw.opts.delimiter())
+		}
+		if cw.needsQuotes(field) {
+			cw.sb.WriteByte('"')
+			cw.sb.WriteString(strings.ReplaceAll(field, `"`, `""`))
+			cw.sb.WriteByte('"')
+		} else {
+			cw.sb.WriteString(field)
+		}
+	}
+	if cw.opts.UseCRLF {
+		cw.sb.WriteString("\r\n")
+	} else {
+		cw.sb.WriteByte('\n')
+	}
+	_, err := io.WriteString(cw.w, cw.sb.String())
+	return err
+}
+
+// textValue extracts and formats a column value as text (CSV, HTML).
+func (e *ExcelDataExporter) textValue(item reflect.Value, col ColumnConfig, nullValue string) string {
+	for item.Kind() == reflect.Interface || item.Kind() == reflect.Ptr {
+		if item.IsNil() {
+			return nullValue
+		}
+		item = item.Elem()
+	}
+	return valueText(e.cellValue(col, item), nullValue)
+}
+
+// text is textValue with the compiled accessor.
+func (a *rowAccessor) text(j int, item reflect.Value, nullValue string) string {
+	for item.Kind() == reflect.Interface || item.Kind() == reflect.Ptr {
+		if item.IsNil() {
+			return nullValue
+		}
+		item = item.Elem()
+	}
+	return valueText(a.value(j, item), nullValue)
+}
+
+// valueText formats a cell value as text.
+func valueText(val interface{}, nullValue string) string {
+	if val == nil {
+		return nullValue
+	}
+	if rv := reflect.ValueOf(val); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nullValue
+		}
+		val = rv.Elem().Interface()
+	}
+	if t, ok := val.(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// writeCSVRows writes the rows of a section's data slice using the given output columns.
+// addRow, if set, is called before each row and stops the export when it fails (see streamCounter.addRow).
+func (e *ExcelDataExporter) writeCSVRows(cw *csvRowWriter, sec *SectionConfig, data interface{}, cols []ColumnConfig, opts CSVOptions, addRow func() error) (int, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return 0, nil
+	}
+	dataLen := v.Len()
+
+	// Columns the section does not have are written as null
+	present := make(map[string]bool, len(sec.Columns))
+	for _, col := range sec.Columns {
+		present[col.FieldName] = true
+	}
+
+	accessor := e.compileRowAccessor(cols, data)
+	row := make([]string, len(cols))
+	for i := 0; i < dataLen; i++ {
+		if addRow != nil {
+			if err := addRow(); err != nil {
+				return i, err
+			}
+		}
+		item := v.Index(i)
+		for j, col := range cols {
+			if !present[col.FieldName] {
+				row[j] = opts.NullValue
+				continue
+			}
+			row[j] = accessor.text(j, item, opts.NullValue)
+		}
+		if err := cw.WriteRow(row); err != nil {
+			return i, err
+		}
+	}
+	return dataLen, nil
+}
+
+func (e *ExcelDataExporter) csvHeaders(cols []ColumnConfig) []string {
+	headers := make([]string, len(cols))
+	for i, col := range cols {
+		headers[i] = e.localizedHeader(col)
+		if headers[i] == "" {
+			headers[i] = col.FieldName
+		}
+	}
+	return headers
+}
+
+// =============================================================================
+// ZIP bundle
+// =============================================================================
+
+// CSVManifest describes the files of a CSV bundle.
+type CSVManifest struct {
+	ReportName    string            `json:"report_name,omitempty"`
+	ConfigVersion string            `json:"config_version,omitempty"`
+	ConfigHash    string            `json:"config_hash,omitempty"`
+	RequestID     string            `json:"request_id,omitempty"`
+	GeneratedAt   time.Time         `json:"generated_at"`
+	Delimiter     string            `json:"delimiter"`
+	Header        bool              `json:"header"`
+	NullValue     string            `json:"null_value"`
+	BOM           bool              `json:"bom"`
+	Files         []CSVManifestFile `json:"files"`
+}
+
+// CSVManifestFile describes one CSV file of a bundle.
+type CSVManifestFile struct {
+	Name     string              `json:"name"`
+	Sheet    string              `json:"sheet"`
+	Sections []string            `json:"sections"`
+	Columns  []CSVManifestColumn `json:"columns"`
+	Rows     int                 `json:"rows"`
+}
+
+// CSVManifestColumn describes one column of a CSV file.
+type CSVManifestColumn struct {
+	Field  string `json:"field"`
+	Header string `json:"header"`
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// bundleFileName returns a safe, unique file name inside the bundle.
+func bundleFileName(used map[string]bool, ext string, parts ...string) string {
+	for i, p := range parts {
+		parts[i] = strings.Trim(unsafeFileNameChars.ReplaceAllString(p, "_"), "_")
+		if parts[i] == "" {
+			parts[i] = "unnamed"
+		}
+	}
+	base := strings.Join(parts, "/")
+	name := base + ext
+	for n := 2; used[name]; n++ {
+		name = fmt.Sprintf("%s_%d%s", base, n, ext)
+	}
+	used[name] = true
+	return name
+}
+
+// csvSections returns the sections of a sheet of a run that produce CSV rows, with merged columns.
+func (e *ExcelDataExporter) csvSections(sb *SheetBuilder) []*SectionConfig {
+	var sections []*SectionConfig
+	for _, sec := range sb.sections {
+		if sec.Type == SectionTypeTitleOnly {
+			continue
+		}
+		sec.Columns = mergeSectionColumns(sec, sec.Data)
+		if len(sec.Columns) == 0 {
+			continue
+		}
+		sections = append(sections, sec)
+	}
+	return sections
+}
+
+// ToCSVBundle writes a ZIP archive with one CSV per section (or per sheet with opts.PerSheet)
+// across all sheets, plus a manifest.json describing the files and their columns.
+// Titles and blank separator lines are not written, so every file can be parsed as-is.
+func (e *ExcelDataExporter) ToCSVBundle(w io.Writer, opts CSVOptions) error {
+	if len(e.sheets) == 0 {
+		return fmt.Errorf("no sheets to export")
+	}
+
+	zw := zip.NewWriter(w)
+	manifest := CSVManifest{
+		ReportName:    e.reportName,
+		ConfigVersion: e.configVersion,
+		ConfigHash:    e.configHash,
+		RequestID:     e.requestID,
+		GeneratedAt:   time.Now().UTC(),
+		Delimiter:     string(opts.delimiter()),
+		Header:        !opts.SkipHeader,
+		NullValue:     opts.NullValue,
+		BOM:           opts.BOM,
+	}
+	used := map[string]bool{csvManifestName: true}
+
+	for _, sb := range e.newRun().sheets {
+		sections := e.csvSections(sb)
+		if len(sections) == 0 {
+			continue
+		}
+
+		if opts.PerSheet {
+			file, err := e.writeBundleFile(zw, bundleFileName(used, opts.extension(), sb.name), sb.name, sections, opts)
+			if err != nil {
+				return err
+			}
+			manifest.Files = append(manifest.Files, file)
+			continue
+		}
+
+		for i, sec := range sections {
+			secName := sec.ID
+			if secName == "" {
+				secName = fmt.Sprintf("section_%d", i+1)
+			}
+			file, err := e.writeBundleFile(zw, bundleFileName(used, opts.extension(), sb.name, secName), sb.name, []*SectionConfig{sec}, opts)
+			if err != nil {
+				return err
+			}
+			manifest.Files = append(manifest.Files, file)
+		}
+	}
+
+	mw, err := zw.Create(csvManifestName)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return zw.Close()
+}
+
+// writeBundleFile writes the given sections into a single CSV file of the bundle.
+// The columns are the union of the sections' columns, in order of first appearance.
+func (e *ExcelDataExporter) writeBundleFile(zw *zip.Writer, name, sheet string, sections []*SectionConfig, opts CSVOptions) (CSVManifestFile, error) {
+	file := CSVManifestFile{Name: name, Sheet: sheet}
+
+	var cols []ColumnConfig
+	seen := make(map[string]bool)
+	for _, sec := range sections {
+		file.Sections = append(file.Sections, sec.ID)
+		for _, col := range sec.Columns {
+			if !seen[col.FieldName] {
+				seen[col.FieldName] = true
+				cols = append(cols, col)
+			}
+		}
+	}
+	headers := e.csvHeaders(cols)
+	for i, col := range cols {
+		file.Columns = append(file.Columns, CSVManifestColumn{Field: col.FieldName, Header: headers[i]})
+	}
+
+	fw, err := zw.Create(name)
+	if err != nil {
+		return file, err
+	}
+	cw, err := newCSVRowWriter(fw, opts)
+	if err != nil {
+		return file, err
+	}
+	if !opts.SkipHeader {
+		if err := cw.WriteRow(headers); err != nil {
+			return file, err
+		}
+	}
+	for _, sec := range sections {
+		n, err := e.writeCSVRows(cw, sec, sec.Data, cols, opts, nil)
+		if err != nil {
+			return file, fmt.Errorf("write %s: %w", name, err)
+		}
+		file.Rows += n
+	}
+	return file, nil
+}
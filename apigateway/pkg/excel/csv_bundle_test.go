@@ -0,0 +1,161 @@
+package excel
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readBundle(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open bundle: %v", err)
+	}
+	files := make(map[string]string)
+	for _, zf := range zr.File {
+		rc, err := zf.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", zf.Name, err)
+		}
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+		files[zf.Name] = string(b)
+	}
+	return files
+}
+
+func bundleExporter(t *testing.T) *ExcelDataExporter {
+	yamlConfig := `
+name: "Inventory"
+version: "2"
+sheets:
+  - name: "Products"
+    sections:
+      - type: "title"
+        title: "Inventory Report"
+      - id: "products"
+        title: "Products"
+        show_header: true
+        columns:
+          - field_name: "Name"
+            header: "Product Name"
+          - field_name: "Price"
+            header: "Price"
+      - id: "discontinued"
+        show_header: true
+        columns:
+          - field_name: "Name"
+            header: "Product Name"
+          - field_name: "Reason"
+            header: "Reason"
+  - name: "Summary / Totals"
+    sections:
+      - id: "totals"
+        columns:
+          - field_name: "Total"
+`
+	exporter, err := NewExcelDataExporterFromYamlConfig(yamlConfig)
+	assert.NoError(t, err)
+
+	exporter.
+		BindSectionData("products", []map[string]interface{}{
+			{"Name": "Laptop, 15\"", "Price": 1299.5},
+			{"Name": "Phone", "Price": nil},
+		}).
+		BindSectionData("discontinued", []map[string]interface{}{
+			{"Name": "Pager", "Reason": "Obsolete"},
+		}).
+		BindSectionData("totals", []map[string]interface{}{{"Total": 1299.5}})
+	return exporter
+}
+
+func TestToCSVBundlePerSection(t *testing.T) {
+	exporter := bundleExporter(t)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, exporter.ToCSVBundle(buf, CSVOptions{NullValue: "NULL"}))
+	files := readBundle(t, buf.Bytes())
+
+	// Every file parses as a clean CSV: no titles, no blank separators
+	records, err := csv.NewReader(strings.NewReader(files["Products/products.csv"])).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Product Name", "Price"},
+		{"Laptop, 15\"", "1299.5"},
+		{"Phone", "NULL"},
+	}, records)
+
+	assert.Equal(t, "Product Name,Reason\nPager,Obsolete\n", files["Products/discontinued.csv"])
+	assert.Equal(t, "Total\n1299.5\n", files["Summary_Totals/totals.csv"])
+
+	var manifest CSVManifest
+	assert.NoError(t, json.Unmarshal([]byte(files["manifest.json"]), &manifest))
+	assert.Equal(t, "Inventory", manifest.ReportName)
+	assert.Equal(t, "2", manifest.ConfigVersion)
+	assert.Equal(t, ",", manifest.Delimiter)
+	if assert.Len(t, manifest.Files, 3) {
+		assert.Equal(t, "Products/products.csv", manifest.Files[0].Name)
+		assert.Equal(t, 2, manifest.Files[0].Rows)
+		assert.Equal(t, []CSVManifestColumn{{"Name", "Product Name"}, {"Price", "Price"}}, manifest.Files[0].Columns)
+	}
+}
+
+func TestToCSVBundlePerSheetTSV(t *testing.T) {
+	exporter := bundleExporter(t)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, exporter.ToCSVBundle(buf, CSVOptions{
+		Delimiter: '\t',
+		Quote:     CSVQuoteAll,
+		BOM:       true,
+		PerSheet:  true,
+		UseCRLF:   true,
+	}))
+	files := readBundle(t, buf.Bytes())
+
+	// Sections of a sheet share one header built from the union of their columns
+	assert.Equal(t, "\uFEFF"+
+		"\"Product Name\"\t\"Price\"\t\"Reason\"\r\n"+
+		"\"Laptop, 15\"\"\"\t\"1299.5\"\t\"\"\r\n"+
+		"\"Phone\"\t\"\"\t\"\"\r\n"+
+		"\"Pager\"\t\"\"\t\"Obsolete\"\r\n",
+		files["Products.tsv"])
+	assert.Contains(t, files, "Summary_Totals.tsv")
+}
+
+func TestToCSVBundleWithoutHeader(t *testing.T) {
+	exporter := bundleExporter(t)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, exporter.ToCSVBundle(buf, CSVOptions{Delimiter: ';', SkipHeader: true}))
+	files := readBundle(t, buf.Bytes())
+	assert.Equal(t, "Pager;Obsolete\n", files["Products/discontinued.csv"])
+
+	assert.Error(t, exporter.ToCSVBundle(new(bytes.Buffer), CSVOptions{Delimiter: '"'}))
+}
+
+func TestCSVExportsFollowRebinding(t *testing.T) {
+	exporter := NewExcelDataExporter()
+	exporter.AddSheet("People").AddSection(&SectionConfig{ID: "people", ShowHeader: true})
+
+	for _, name := range []string{"first", "second"} {
+		exporter.BindSectionData("people", []map[string]interface{}{{"Name": name}})
+		buf := new(bytes.Buffer)
+		assert.NoError(t, exporter.ToCSVBundle(buf, CSVOptions{}))
+		assert.Equal(t, "Name\n"+name+"\n", readBundle(t, buf.Bytes())["People/people.csv"])
+		buf.Reset()
+		assert.NoError(t, exporter.ToCSV(buf))
+		assert.Equal(t, "Name\n"+name+"\n\n", buf.String()) // ToCSV separates sections with a blank line
+	}
+	// The exports work on copies of the sections
+	section := exporter.GetSection("people")
+	assert.Nil(t, section.Data)
+	assert.Empty(t, section.Columns)
+}
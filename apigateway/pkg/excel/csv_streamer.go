@@ -0,0 +1,256 @@
+package excel
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+)
+
+// ExportFormat selects the output format of a stream.
+type ExportFormat string
+
+const (
+	FormatXLSX ExportFormat = "xlsx"
+	FormatCSV  ExportFormat = "csv"
+	FormatJSON ExportFormat = "json" // Whole report only (ToJSON), not a streaming format
+)
+
+// SectionStreamer is the streaming contract shared by the xlsx Streamer and the CSVStreamer.
+type SectionStreamer interface {
+	Write(sectionID string, data interface{}) error
+	Close() error
+}
+
+var (
+	_ SectionStreamer = (*Streamer)(nil)
+	_ SectionStreamer = (*CSVStreamer)(nil)
+)
+
+// ParseExportFormat parses a format name such as a "?format=" query value. Empty means xlsx.
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch ExportFormat(s) {
+	case "", FormatXLSX:
+		return FormatXLSX, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("unsupported export format %q", s)
+}
+
+// ContentType returns the MIME type of the format.
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSON:
+		return "application/json"
+	}
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+// StartStreamFormat starts a streaming export in the given format.
+// csvOpts is only used for FormatCSV.
+func (e *ExcelDataExporter) StartStreamFormat(w io.Writer, format ExportFormat, csvOpts CSVOptions) (SectionStreamer, error) {
+	switch format {
+	case FormatXLSX, "":
+		return e.StartStream(w)
+	case FormatCSV:
+		return e.StartCSVStream(w, csvOpts)
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+// CSVStreamer writes sections to a single CSV as data arrives.
+// It follows the same strict section order as Streamer. Titles are not written; the header
+// is written once and covers the union of the configured columns of all sections
+// (or the fields of the first batch when no columns are configured).
+type CSVStreamer struct {
+	exporter *ExcelDataExporter
+	counter  *streamCounter
+	buf      *bufio.Writer
+	cw       *csvRowWriter
+	opts     CSVOptions
+
+	// sections are the data sections of all sheets of the run, in order, and sheets the sheet of each
+	sections            []*SectionConfig
+	sheets              []string
+	columns             []ColumnConfig
+	currentSectionIndex int
+	headerWritten       bool
+	closed              bool
+	// err is set when the stream was aborted; later calls return it
+	err error
+}
+
+// StartCSVStream initializes a streaming CSV export session.
+// Use StartCSVStreamContext for cancellation, progress reporting and a row limit.
+func (e *ExcelDataExporter) StartCSVStream(w io.Writer, opts CSVOptions) (*CSVStreamer, error) {
+	return e.StartCSVStreamContext(context.Background(), w, opts, StreamOptions{})
+}
+
+// StartCSVStreamContext initializes a streaming CSV export session bound to ctx, like StartStreamContext.
+// Canceling ctx aborts the stream: Write and Close return a *StreamCanceledError. Rows already flushed
+// stay in the output.
+func (e *ExcelDataExporter) StartCSVStreamContext(ctx context.Context, w io.Writer, opts CSVOptions, streamOpts StreamOptions) (*CSVStreamer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &StreamCanceledError{Cause: err}
+	}
+	counter := newStreamCounter(ctx, w, streamOpts)
+	buf := bufio.NewWriter(counter.out)
+	cw, err := newCSVRowWriter(buf, opts)
+	if err != nil {
+		return nil, err
+	}
+	s := &CSVStreamer{exporter: e, counter: counter, buf: buf, cw: cw, opts: opts}
+
+	seen := make(map[string]bool)
+	for _, sb := range e.newRun().sheets {
+		for _, sec := range sb.sections {
+			if sec.Type == SectionTypeTitleOnly {
+				continue
+			}
+			s.sections = append(s.sections, sec)
+			s.sheets = append(s.sheets, sb.name)
+			for _, col := range sec.Columns {
+				if col.FieldName != "" && !seen[col.FieldName] {
+					seen[col.FieldName] = true
+					s.columns = append(s.columns, col)
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+// Write appends a batch of data to the specified section.
+// Sections skipped over are written from bound data (BindSectionData), if any.
+func (s *CSVStreamer) Write(sectionID string, data interface{}) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	targetIndex := -1
+	for i := s.currentSectionIndex; i < len(s.sections); i++ {
+		if s.sections[i].ID == sectionID {
+			targetIndex = i
+			break
+		}
+	}
+	if targetIndex == -1 {
+		return fmt.Errorf("section '%s' not found in remaining sections (already passed or does not exist)", sectionID)
+	}
+
+	for ; s.currentSectionIndex < targetIndex; s.currentSectionIndex++ {
+		if err := s.writeStaticSection(s.sections[s.currentSectionIndex]); err != nil {
+			return err
+		}
+	}
+
+	if err := s.writeRows(s.sections[targetIndex], data); err != nil {
+		return err
+	}
+	if err := s.flush(); err != nil {
+		return err
+	}
+	s.counter.progress(s.sheets[targetIndex], sectionID, false)
+	return nil
+}
+
+// Close writes the remaining sections with bound data and flushes the output.
+func (s *CSVStreamer) Close() error {
+	if s.closed && s.err == nil {
+		return nil
+	}
+	if err := s.check(); err != nil {
+		return err
+	}
+	s.closed = true
+	for ; s.currentSectionIndex < len(s.sections); s.currentSectionIndex++ {
+		if err := s.writeStaticSection(s.sections[s.currentSectionIndex]); err != nil {
+			return err
+		}
+	}
+	if !s.headerWritten {
+		if err := s.writeHeader(nil, nil); err != nil {
+			return s.fail(err)
+		}
+	}
+	if err := s.flush(); err != nil {
+		return err
+	}
+	sheet, lastSection := "", ""
+	if n := len(s.sections); n > 0 {
+		sheet, lastSection = s.sheets[n-1], s.sections[n-1].ID
+	}
+	s.counter.progress(sheet, lastSection, true)
+	return nil
+}
+
+// check returns the error that aborted the stream, an error if the stream is closed, or a
+// *StreamCanceledError (aborting the stream) if the context is done.
+func (s *CSVStreamer) check() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.closed {
+		return fmt.Errorf("stream is closed or not initialized")
+	}
+	if err := s.counter.checkContext(); err != nil {
+		return s.fail(err)
+	}
+	return nil
+}
+
+// flush flushes the buffered rows, aborting the stream if the context is done.
+func (s *CSVStreamer) flush() error {
+	if err := s.buf.Flush(); err != nil {
+		if ctxErr := s.counter.checkContext(); ctxErr != nil {
+			err = ctxErr
+		}
+		return s.fail(err)
+	}
+	return nil
+}
+
+// fail aborts the stream. Later calls return err.
+func (s *CSVStreamer) fail(err error) error {
+	s.err = err
+	return err
+}
+
+// writeStaticSection writes a section that is passed over, using its static or bound data.
+// The section currently being streamed has no static data and is left as is.
+func (s *CSVStreamer) writeStaticSection(sec *SectionConfig) error {
+	if sec.Data == nil {
+		return nil
+	}
+	return s.writeRows(sec, sec.Data)
+}
+
+func (s *CSVStreamer) writeHeader(sec *SectionConfig, data interface{}) error {
+	s.headerWritten = true
+	if len(s.columns) == 0 && data != nil {
+		s.columns = mergeSectionColumns(sec, data)
+	}
+	if s.opts.SkipHeader || len(s.columns) == 0 {
+		return nil
+	}
+	return s.cw.WriteRow(s.exporter.csvHeaders(s.columns))
+}
+
+func (s *CSVStreamer) writeRows(sec *SectionConfig, data interface{}) error {
+	if !s.headerWritten {
+		if err := s.writeHeader(sec, data); err != nil {
+			return s.fail(err)
+		}
+	}
+	if len(sec.Columns) == 0 {
+		sec.Columns = mergeSectionColumns(sec, data)
+	}
+	addRow := func() error { return s.counter.addRow(sec.ID) }
+	if _, err := s.exporter.writeCSVRows(s.cw, sec, data, s.columns, s.opts, addRow); err != nil {
+		return s.fail(err)
+	}
+	return nil
+}
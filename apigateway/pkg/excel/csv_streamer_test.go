@@ -0,0 +1,154 @@
+package excel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func wikiExporter() *ExcelDataExporter {
+	exporter := NewExcelDataExporter()
+	exporter.AddSheet("Wikipedia People").
+		AddSection(&SectionConfig{
+			Type:  SectionTypeTitleOnly,
+			Title: "Wikipedia People Export",
+		}).
+		AddSection(&SectionConfig{
+			ID:         "wiki-data",
+			ShowHeader: true,
+			Columns: []ColumnConfig{
+				{FieldName: "Name", Header: "Person Name"},
+				{FieldName: "URL", Header: "Wiki URL"},
+			},
+		})
+	return exporter
+}
+
+type wikiPerson struct{ Name, URL string }
+
+func TestCSVStreamer(t *testing.T) {
+	buf := new(bytes.Buffer)
+	streamer, err := wikiExporter().StartStreamFormat(buf, FormatCSV, CSVOptions{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, streamer.Write("wiki-data", []wikiPerson{{"Ada Lovelace", "https://a"}}))
+	// Batches are flushed as they are written
+	assert.Equal(t, "Person Name,Wiki URL\nAda Lovelace,https://a\n", buf.String())
+
+	assert.NoError(t, streamer.Write("wiki-data", []wikiPerson{{"Turing, Alan", "https://b"}}))
+	assert.Error(t, streamer.Write("missing", []wikiPerson{}))
+	assert.NoError(t, streamer.Close())
+
+	assert.Equal(t, "Person Name,Wiki URL\nAda Lovelace,https://a\n\"Turing, Alan\",https://b\n", buf.String())
+	assert.Error(t, streamer.Write("wiki-data", []wikiPerson{}))
+}
+
+func TestCSVStreamerSectionOrderAndBoundData(t *testing.T) {
+	exporter := NewExcelDataExporter()
+	exporter.AddSheet("A").
+		AddSection(&SectionConfig{ID: "golang", Columns: []ColumnConfig{{FieldName: "Name"}, {FieldName: "ProfileURL", Header: "Profile"}}}).
+		AddSection(&SectionConfig{ID: "static", Columns: []ColumnConfig{{FieldName: "Name"}}})
+	exporter.AddSheet("B").
+		AddSection(&SectionConfig{ID: "python", Columns: []ColumnConfig{{FieldName: "Name"}, {FieldName: "Lang"}}})
+	exporter.BindSectionData("static", []map[string]interface{}{{"Name": "Bound"}})
+
+	buf := new(bytes.Buffer)
+	streamer, err := exporter.StartCSVStream(buf, CSVOptions{Delimiter: ';', NullValue: "\\N"})
+	assert.NoError(t, err)
+	assert.NoError(t, streamer.Write("golang", []map[string]interface{}{{"Name": "Rob", "ProfileURL": "r"}}))
+	assert.NoError(t, streamer.Write("python", []map[string]interface{}{{"Name": "Guido", "Lang": "py"}}))
+	assert.Error(t, streamer.Write("golang", nil), "sections must be written in order")
+	assert.NoError(t, streamer.Close())
+
+	assert.Equal(t, "Name;Profile;Lang\n"+
+		"Rob;r;\\N\n"+
+		"Bound;\\N;\\N\n"+
+		"Guido;\\N;py\n", buf.String())
+}
+
+func TestCSVStreamerWorksOnCopies(t *testing.T) {
+	exporter := NewExcelDataExporter()
+	exporter.AddSheet("Detected").AddSection(&SectionConfig{ID: "detected"})
+
+	for _, row := range []map[string]interface{}{{"ID": 1}, {"Name": "second"}} {
+		buf := new(bytes.Buffer)
+		streamer, err := exporter.StartCSVStream(buf, CSVOptions{})
+		assert.NoError(t, err)
+		assert.NoError(t, streamer.Write("detected", []map[string]interface{}{row}))
+		assert.NoError(t, streamer.Close())
+		for field, val := range row {
+			assert.Equal(t, fmt.Sprintf("%s\n%v\n", field, val), buf.String())
+		}
+	}
+	// Columns are detected on the copy of each stream, not on the configured section
+	assert.Empty(t, exporter.GetSection("detected").Columns)
+}
+
+func TestCSVStreamerOptions(t *testing.T) {
+	var progress []StreamProgress
+	e := streamOptionsExporter()
+	e.AddSheet("Detected").AddSection(&SectionConfig{ID: "detected"})
+	buf := new(bytes.Buffer)
+	streamer, err := e.StartCSVStreamContext(context.Background(), buf, CSVOptions{}, StreamOptions{
+		OnProgress: func(p StreamProgress) { progress = append(progress, p) },
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, streamer.Write("items", rolloverItems(2)))
+	assert.NoError(t, streamer.Write("detected", []map[string]interface{}{{"ID": 9}}))
+	assert.NoError(t, streamer.Close())
+	if assert.Len(t, progress, 3) {
+		// Every Write is flushed: the header and two rows, then the last row
+		assert.Equal(t, StreamProgress{Sheet: "Data", SectionID: "items", SectionRows: 2, Sections: map[string]int{"items": 2},
+			TotalRows: 2, BytesFlushed: int64(len("ID,Name\n1,Item 1\n2,Item 2\n")), Elapsed: progress[0].Elapsed}, progress[0])
+		assert.Equal(t, StreamProgress{Sheet: "Detected", SectionID: "detected", SectionRows: 1, Sections: map[string]int{"items": 2, "detected": 1},
+			TotalRows: 3, BytesFlushed: int64(buf.Len()), Elapsed: progress[2].Elapsed, Done: true}, progress[2])
+	}
+	// Columns are resolved on the copy of the stream, not on the configured section
+	assert.Empty(t, e.sheets[1].sections[0].Columns)
+
+	streamer, err = streamOptionsExporter().StartCSVStreamContext(context.Background(), new(bytes.Buffer), CSVOptions{}, StreamOptions{MaxRows: 3})
+	assert.NoError(t, err)
+	err = streamer.Write("items", rolloverItems(4))
+	assert.True(t, errors.Is(err, ErrMaxRowsExceeded))
+	assert.Equal(t, err, streamer.Close())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	streamer, err = streamOptionsExporter().StartCSVStreamContext(ctx, new(bytes.Buffer), CSVOptions{}, StreamOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, streamer.Write("items", rolloverItems(2)))
+	cancel()
+	err = streamer.Write("items", rolloverItems(2))
+	var canceled *StreamCanceledError
+	if assert.True(t, errors.As(err, &canceled)) {
+		assert.Equal(t, 2, canceled.Rows)
+	}
+	assert.Equal(t, err, streamer.Close())
+
+	_, err = streamOptionsExporter().StartCSVStreamContext(ctx, new(bytes.Buffer), CSVOptions{}, StreamOptions{})
+	assert.True(t, errors.Is(err, ErrStreamCanceled))
+}
+
+func TestStartStreamFormatXLSX(t *testing.T) {
+	format, err := ParseExportFormat("")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatXLSX, format)
+	_, err = ParseExportFormat("pdf")
+	assert.Error(t, err)
+
+	buf := new(bytes.Buffer)
+	streamer, err := wikiExporter().StartStreamFormat(buf, format, CSVOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, streamer.Write("wiki-data", []wikiPerson{{"Ada", "a"}}))
+	assert.NoError(t, streamer.Close())
+
+	f, err := excelize.OpenReader(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	defer f.Close()
+	v, _ := f.GetCellValue("Wikipedia People", "A3")
+	assert.Equal(t, "Ada", v)
+}
@@ -0,0 +1,629 @@
+package excel
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Fill colors of HighlightDiff.
+const (
+	DiffChangedColor = "FFEB9C" // Light orange for changed cells
+	DiffLockedColor  = "FFC7CE" // Light red for changed locked cells
+	DiffAddedColor   = "C6EFCE" // Light green for added rows
+)
+
+// ErrNoSectionLayout is returned by DiffWorkbooks for workbooks without an embedded section layout,
+// e.g. workbooks written by the streamer.
+var ErrNoSectionLayout = errors.New("workbook has no section layout")
+
+// SectionLayout records where a section was rendered. Rows and columns are 1-based; FieldRow and HeaderRow
+// are 0 when the section has no hidden field name row or header.
+type SectionLayout struct {
+	ID        string   `json:"id"`
+	Sheet     string   `json:"sheet"`
+	Top       int      `json:"top"` // First row of the section (its title, if any)
+	FieldRow  int      `json:"field_row,omitempty"`
+	HeaderRow int      `json:"header_row,omitempty"`
+	Row       int      `json:"row"` // First data row
+	Col       int      `json:"col"`
+	Rows      int      `json:"rows"`
+	Fields    []string `json:"fields"`
+}
+
+// anchorRow returns the row used to find the section and its columns in a changed workbook.
+func (l SectionLayout) anchorRow() int {
+	if l.FieldRow > 0 {
+		return l.FieldRow
+	}
+	return l.HeaderRow
+}
+
+// WorkbookDiff is the change report of a returned workbook against the workbook originally sent.
+type WorkbookDiff struct {
+	Report   string          `json:"report"`
+	Original *ReportMetadata `json:"original"`
+	Modified *ReportMetadata `json:"modified,omitempty"` // Nil if the returned workbook lost its metadata
+	Warnings []string        `json:"warnings,omitempty"`
+	Sections []SectionDiff   `json:"sections"`
+	Summary  DiffSummary     `json:"summary"`
+}
+
+// DiffSummary counts the changes of a WorkbookDiff.
+type DiffSummary struct {
+	CellsChanged       int `json:"cells_changed"`
+	LockedCellsChanged int `json:"locked_cells_changed"` // Changes that bypassed the sheet protection
+	RowsAdded          int `json:"rows_added"`
+	RowsRemoved        int `json:"rows_removed"`
+	ColumnsAdded       int `json:"columns_added"`
+	ColumnsRemoved     int `json:"columns_removed"`
+	SectionsMissing    int `json:"sections_missing"`
+}
+
+// HasChanges reports whether the returned workbook differs from the original.
+func (d *WorkbookDiff) HasChanges() bool {
+	return d.Summary != DiffSummary{} || d.hasReorderedColumns()
+}
+
+func (d *WorkbookDiff) hasReorderedColumns() bool {
+	for _, sec := range d.Sections {
+		if sec.ColumnsReordered {
+			return true
+		}
+	}
+	return false
+}
+
+// SectionDiff holds the changes of one rendered section (one per sheet when the section rolled over).
+type SectionDiff struct {
+	SectionID        string     `json:"section_id"`
+	Sheet            string     `json:"sheet"`
+	Key              []string   `json:"key,omitempty"`     // Fields matching the rows; rows are matched by position without one
+	Missing          bool       `json:"missing,omitempty"` // The sheet or the section's field/header row was not found
+	ColumnsAdded     []string   `json:"columns_added,omitempty"`
+	ColumnsRemoved   []string   `json:"columns_removed,omitempty"`
+	ColumnsReordered bool       `json:"columns_reordered,omitempty"`
+	RowsAdded        []RowDiff  `json:"rows_added,omitempty"`
+	RowsRemoved      []RowDiff  `json:"rows_removed,omitempty"`
+	Changes          []CellDiff `json:"changes,omitempty"`
+}
+
+// RowDiff is a row added to (Row of the returned workbook) or removed from (Row of the original) a section.
+type RowDiff struct {
+	Key    string            `json:"key,omitempty"`
+	Row    int               `json:"row"`
+	Range  string            `json:"range"` // Cells of the row, e.g. "A5:F5"
+	Values map[string]string `json:"values"`
+}
+
+// CellDiff is a changed cell of a row present in both workbooks.
+type CellDiff struct {
+	Key          string `json:"key,omitempty"`
+	Field        string `json:"field"`
+	Cell         string `json:"cell"` // Address in the returned workbook
+	OriginalCell string `json:"original_cell"`
+	Old          string `json:"old"`
+	New          string `json:"new"`
+	Locked       bool   `json:"locked,omitempty"` // The column is locked: the change bypassed the sheet protection
+}
+
+// DiffWorkbooks compares a workbook that came back (e.g. after review) with the workbook originally exported
+// from this template. Sections are read at the layout embedded in the original; in the returned workbook they
+// are found by their hidden field name or header row, so moved sections, added or removed columns and reordered
+// columns are detected. Rows are matched by the section key (YAML: key), or by position for sections without
+// one. Raw cell values are compared; formula columns are skipped. Changes to locked columns are reported as
+// locked changes.
+//
+// The original must pass VerifyTemplate and carry a section layout (ErrNoSectionLayout), so it must have been
+// built with BuildExcel, ToBytes or ToWriter rather than streamed.
+func (e *ExcelDataExporter) DiffWorkbooks(original, modified *excelize.File) (*WorkbookDiff, error) {
+	meta, err := e.VerifyTemplate(original)
+	if err != nil {
+		return nil, err
+	}
+	if len(meta.Layout) == 0 {
+		return nil, ErrNoSectionLayout
+	}
+
+	diff := &WorkbookDiff{Report: meta.ReportName, Original: meta, Sections: make([]SectionDiff, 0, len(meta.Layout))}
+	switch mod, err := ReadReportMetadata(modified); {
+	case errors.Is(err, ErrNoReportMetadata):
+		diff.Warnings = append(diff.Warnings, "returned workbook has no report metadata")
+	case err != nil:
+		return nil, err
+	default:
+		diff.Modified = mod
+		if mod.RequestID != meta.RequestID || mod.ConfigHash != meta.ConfigHash || !mod.GeneratedAt.Equal(meta.GeneratedAt) {
+			diff.Warnings = append(diff.Warnings, "returned workbook comes from a different export than the original")
+		}
+	}
+
+	orig := newWorkbookRows(original)
+	mod := newWorkbookRows(modified)
+
+	// Locate every section first: the end of a section is the start of the next one below it
+	locs := make([]sectionLocation, len(meta.Layout))
+	for i, l := range meta.Layout {
+		origRows, _, err := orig.get(l.Sheet)
+		if err != nil {
+			return nil, err
+		}
+		modRows, ok, err := mod.get(l.Sheet)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			locs[i] = locateSection(l, origRows, modRows)
+		}
+	}
+
+	for i, l := range meta.Layout {
+		origRows, _, _ := orig.get(l.Sheet)
+		modRows, _, _ := mod.get(l.Sheet)
+		sd := SectionDiff{SectionID: l.ID, Sheet: l.Sheet}
+		if !locs[i].found {
+			sd.Missing = true
+			diff.Sections = append(diff.Sections, sd)
+			continue
+		}
+		end := len(modRows)
+		for k, other := range meta.Layout {
+			if k == i || !locs[k].found || other.Sheet != l.Sheet || other.Top <= l.Top || !columnsOverlap(l, other) {
+				continue
+			}
+			if top := other.Top + locs[k].delta; top-1 < end {
+				end = top - 1
+			}
+		}
+		e.diffSection(diff, &sd, l, locs[i], origRows, modRows, end)
+		diff.Sections = append(diff.Sections, sd)
+	}
+
+	for _, sd := range diff.Sections {
+		diff.Summary.RowsAdded += len(sd.RowsAdded)
+		diff.Summary.RowsRemoved += len(sd.RowsRemoved)
+		diff.Summary.ColumnsAdded += len(sd.ColumnsAdded)
+		diff.Summary.ColumnsRemoved += len(sd.ColumnsRemoved)
+		diff.Summary.CellsChanged += len(sd.Changes)
+		for _, c := range sd.Changes {
+			if c.Locked {
+				diff.Summary.LockedCellsChanged++
+			}
+		}
+		if sd.Missing {
+			diff.Summary.SectionsMissing++
+		}
+	}
+	return diff, nil
+}
+
+// diffSection compares the rows of a located section; end is the last row the section may use in modRows.
+func (e *ExcelDataExporter) diffSection(diff *WorkbookDiff, sd *SectionDiff, l SectionLayout, loc sectionLocation, origRows, modRows [][]string, end int) {
+	sec := e.GetSection(l.ID)
+	locked := make([]bool, len(l.Fields))
+	skip := make([]bool, len(l.Fields))
+	for j, field := range l.Fields {
+		if sec == nil {
+			continue
+		}
+		locked[j] = sec.Locked
+		if col := sec.GetColumn(field); col != nil {
+			locked[j] = col.IsLocked(sec.Locked)
+			skip[j] = col.Formula != "" || col.CompareWith != nil
+		}
+		if loc.cols[j] == 0 {
+			sd.ColumnsRemoved = append(sd.ColumnsRemoved, field)
+		}
+	}
+	sd.ColumnsAdded = loc.added
+	sd.ColumnsReordered = loc.reordered
+
+	// Key columns must be present in both workbooks
+	var key []int
+	if sec != nil && len(sec.Key) > 0 {
+		for _, field := range sec.Key {
+			j := indexOf(l.Fields, field)
+			if j < 0 || loc.cols[j] == 0 {
+				diff.Warnings = append(diff.Warnings, fmt.Sprintf("section %s: key field %s is missing, rows are matched by position", l.ID, field))
+				key = nil
+				break
+			}
+			key = append(key, j)
+		}
+		if key != nil {
+			sd.Key = sec.Key
+		}
+	}
+
+	origData := make([][]string, l.Rows)
+	for i := range origData {
+		origData[i] = make([]string, len(l.Fields))
+		for j := range l.Fields {
+			origData[i][j] = cellAt(origRows, l.Row+i, l.Col+j)
+		}
+	}
+	start := l.Row + loc.delta
+	var modData [][]string
+	var modRowNums []int
+	for r := start; r <= end; r++ {
+		values := make([]string, len(l.Fields))
+		for j, c := range loc.cols {
+			if c > 0 {
+				values[j] = cellAt(modRows, r, c)
+			}
+		}
+		modData = append(modData, values)
+		modRowNums = append(modRowNums, r)
+	}
+	// Trailing empty rows are not part of the section
+	for len(modData) > 0 && isEmptyRow(modData[len(modData)-1], modRows, modRowNums[len(modRowNums)-1], loc.addedCols) {
+		modData = modData[:len(modData)-1]
+		modRowNums = modRowNums[:len(modRowNums)-1]
+	}
+
+	rowKey := func(values []string) string {
+		parts := make([]string, len(key))
+		for i, j := range key {
+			parts[i] = values[j]
+		}
+		return strings.Join(parts, "|")
+	}
+	origRow := func(i int) RowDiff {
+		rd := RowDiff{Row: l.Row + i, Range: rowRange(l.Row+i, l.Col, l.Col+len(l.Fields)-1), Values: make(map[string]string, len(l.Fields))}
+		for j, field := range l.Fields {
+			rd.Values[field] = origData[i][j]
+		}
+		return rd
+	}
+	modRow := func(m int) RowDiff {
+		first, last := loc.span()
+		rd := RowDiff{Row: modRowNums[m], Range: rowRange(modRowNums[m], first, last), Values: make(map[string]string, len(l.Fields))}
+		for j, field := range l.Fields {
+			if loc.cols[j] > 0 {
+				rd.Values[field] = modData[m][j]
+			}
+		}
+		return rd
+	}
+	compare := func(i, m int, k string) {
+		for j, field := range l.Fields {
+			if skip[j] || loc.cols[j] == 0 || origData[i][j] == modData[m][j] {
+				continue
+			}
+			sd.Changes = append(sd.Changes, CellDiff{
+				Key:          k,
+				Field:        field,
+				Cell:         cellName(loc.cols[j], modRowNums[m]),
+				OriginalCell: cellName(l.Col+j, l.Row+i),
+				Old:          origData[i][j],
+				New:          modData[m][j],
+				Locked:       locked[j],
+			})
+		}
+	}
+
+	if key == nil {
+		for i := 0; i < len(origData) || i < len(modData); i++ {
+			switch {
+			case i >= len(modData):
+				sd.RowsRemoved = append(sd.RowsRemoved, origRow(i))
+			case i >= len(origData):
+				sd.RowsAdded = append(sd.RowsAdded, modRow(i))
+			default:
+				compare(i, i, "")
+			}
+		}
+		return
+	}
+
+	origIndex := make(map[string]int, len(origData))
+	for i := len(origData) - 1; i >= 0; i-- { // First occurrence wins
+		origIndex[rowKey(origData[i])] = i
+	}
+	matched := make([]bool, len(origData))
+	for m, values := range modData {
+		if isEmptyRow(values, modRows, modRowNums[m], loc.addedCols) {
+			continue // A cleared row; its original shows up as removed
+		}
+		k := rowKey(values)
+		i, ok := origIndex[k]
+		if !ok || matched[i] {
+			rd := modRow(m)
+			rd.Key = k
+			sd.RowsAdded = append(sd.RowsAdded, rd)
+			continue
+		}
+		matched[i] = true
+		compare(i, m, k)
+	}
+	for i := range origData {
+		if !matched[i] {
+			rd := origRow(i)
+			rd.Key = rowKey(origData[i])
+			sd.RowsRemoved = append(sd.RowsRemoved, rd)
+		}
+	}
+}
+
+// sectionLocation is where a section was found in the returned workbook.
+type sectionLocation struct {
+	found     bool
+	delta     int   // Row offset from the original position
+	cols      []int // Column of each layout field, 0 if it was removed
+	addedCols []int
+	added     []string // Names of the columns inserted within the section
+	reordered bool
+}
+
+// span returns the first and last column of the section.
+func (loc sectionLocation) span() (int, int) {
+	first, last := 0, 0
+	for _, c := range append(append([]int(nil), loc.cols...), loc.addedCols...) {
+		if c == 0 {
+			continue
+		}
+		if first == 0 || c < first {
+			first = c
+		}
+		if c > last {
+			last = c
+		}
+	}
+	return first, last
+}
+
+// locateSection finds a section in the returned sheet by the labels of its field name or header row: the row
+// holding most of them, nearest to the original position, and for each label the nearest column holding it.
+// Fields with an empty or duplicate label keep their position next to the previous field.
+func locateSection(l SectionLayout, origRows, modRows [][]string) sectionLocation {
+	loc := sectionLocation{cols: make([]int, len(l.Fields))}
+	anchor := l.anchorRow()
+	labels := make([]string, len(l.Fields))
+	counts := make(map[string]int)
+	if anchor > 0 {
+		for j := range l.Fields {
+			labels[j] = cellAt(origRows, anchor, l.Col+j)
+			counts[labels[j]]++
+		}
+	}
+	unique := func(j int) bool { return labels[j] != "" && counts[labels[j]] == 1 }
+
+	best, bestScore := anchor, 0
+	for r := 1; r <= len(modRows) && anchor > 0; r++ {
+		present := make(map[string]bool, len(modRows[r-1]))
+		for _, v := range modRows[r-1] {
+			present[v] = true
+		}
+		score := 0
+		for j := range labels {
+			if unique(j) && present[labels[j]] {
+				score++
+			}
+		}
+		if score > bestScore || (score == bestScore && score > 0 && abs(r-anchor) < abs(best-anchor)) {
+			best, bestScore = r, score
+		}
+	}
+	hasLabels := false
+	for j := range labels {
+		hasLabels = hasLabels || unique(j)
+	}
+	if hasLabels && bestScore == 0 {
+		return loc
+	}
+	loc.found, loc.delta = true, best-anchor
+
+	used := make(map[int]bool)
+	if hasLabels {
+		row := modRows[best-1]
+		for j := range labels {
+			if !unique(j) {
+				continue
+			}
+			want := l.Col + j
+			for c := 1; c <= len(row); c++ {
+				if row[c-1] == labels[j] && !used[c] && (loc.cols[j] == 0 || abs(c-want) < abs(loc.cols[j]-want)) {
+					loc.cols[j] = c
+				}
+			}
+			used[loc.cols[j]] = true
+		}
+	}
+	shift := 0
+	for j, c := range loc.cols {
+		if c > 0 {
+			shift = c - (l.Col + j)
+			break
+		}
+	}
+	for j := range labels {
+		if unique(j) {
+			continue
+		}
+		c := l.Col + j + shift
+		if j > 0 && loc.cols[j-1] > 0 {
+			c = loc.cols[j-1] + 1
+		}
+		if !used[c] {
+			loc.cols[j] = c
+			used[c] = true
+		}
+	}
+
+	last := 0
+	for _, c := range loc.cols {
+		if c == 0 {
+			continue
+		}
+		if c < last {
+			loc.reordered = true
+		}
+		last = c
+	}
+	first, last := loc.span()
+	headerRow := best
+	if l.HeaderRow > 0 {
+		headerRow = l.HeaderRow + loc.delta
+	}
+	for c := first; c <= last && hasLabels; c++ {
+		if used[c] {
+			continue
+		}
+		loc.addedCols = append(loc.addedCols, c)
+		name := cellAt(modRows, headerRow, c)
+		if name == "" {
+			name, _ = excelize.ColumnNumberToName(c)
+		}
+		loc.added = append(loc.added, name)
+	}
+	return loc
+}
+
+// workbookRows caches the raw cell values of the sheets of a workbook.
+type workbookRows struct {
+	f      *excelize.File
+	sheets map[string][][]string
+}
+
+func newWorkbookRows(f *excelize.File) *workbookRows {
+	return &workbookRows{f: f, sheets: make(map[string][][]string)}
+}
+
+// get returns the rows of a sheet; ok is false if the workbook has no such sheet.
+func (w *workbookRows) get(sheet string) ([][]string, bool, error) {
+	if rows, ok := w.sheets[sheet]; ok {
+		return rows, rows != nil, nil
+	}
+	if idx, err := w.f.GetSheetIndex(sheet); err != nil || idx < 0 {
+		w.sheets[sheet] = nil
+		return nil, false, nil
+	}
+	rows, err := w.f.GetRows(sheet, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, false, fmt.Errorf("read sheet %s: %w", sheet, err)
+	}
+	if rows == nil {
+		rows = [][]string{}
+	}
+	w.sheets[sheet] = rows
+	return rows, true, nil
+}
+
+// cellAt returns the value of a cell (1-based), or "" outside the rows.
+func cellAt(rows [][]string, row, col int) string {
+	if row < 1 || row > len(rows) || col < 1 || col > len(rows[row-1]) {
+		return ""
+	}
+	return rows[row-1][col-1]
+}
+
+func isEmptyRow(values []string, rows [][]string, row int, extraCols []int) bool {
+	for _, v := range values {
+		if v != "" {
+			return false
+		}
+	}
+	for _, c := range extraCols {
+		if cellAt(rows, row, c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func columnsOverlap(a, b SectionLayout) bool {
+	return a.Col <= b.Col+len(b.Fields)-1 && b.Col <= a.Col+len(a.Fields)-1
+}
+
+func cellName(col, row int) string {
+	name, _ := excelize.CoordinatesToCellName(col, row)
+	return name
+}
+
+func rowRange(row, first, last int) string {
+	return cellName(first, row) + ":" + cellName(last, row)
+}
+
+func indexOf(values []string, v string) int {
+	for i, s := range values {
+		if s == v {
+			return i
+		}
+	}
+	return -1
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// HighlightDiff marks the changes of a diff in the returned workbook it was computed from: changed cells are
+// filled with DiffChangedColor (DiffLockedColor for locked cells) and get a comment with the original value, and
+// added rows are filled with DiffAddedColor. Existing cell styles are kept apart from their fill.
+func HighlightDiff(f *excelize.File, diff *WorkbookDiff) error {
+	styles := make(map[string]int)
+	fill := func(sheet, cell, color string) error {
+		id, err := f.GetCellStyle(sheet, cell)
+		if err != nil {
+			return err
+		}
+		key := fmt.Sprintf("%d/%s", id, color)
+		styleID, ok := styles[key]
+		if !ok {
+			style, err := f.GetStyle(id)
+			if err != nil {
+				return err
+			}
+			style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{color}}
+			if styleID, err = f.NewStyle(style); err != nil {
+				return err
+			}
+			styles[key] = styleID
+		}
+		return f.SetCellStyle(sheet, cell, cell, styleID)
+	}
+
+	for _, sec := range diff.Sections {
+		for _, row := range sec.RowsAdded {
+			cells := strings.SplitN(row.Range, ":", 2)
+			if len(cells) != 2 {
+				continue
+			}
+			first, _, err := excelize.CellNameToCoordinates(cells[0])
+			if err != nil {
+				return err
+			}
+			last, _, err := excelize.CellNameToCoordinates(cells[1])
+			if err != nil {
+				return err
+			}
+			for c := first; c <= last; c++ {
+				if err := fill(sec.Sheet, cellName(c, row.Row), DiffAddedColor); err != nil {
+					return err
+				}
+			}
+		}
+		for _, change := range sec.Changes {
+			color := DiffChangedColor
+			if change.Locked {
+				color = DiffLockedColor
+			}
+			if err := fill(sec.Sheet, change.Cell, color); err != nil {
+				return err
+			}
+			if err := f.AddComment(sec.Sheet, excelize.Comment{
+				Author: "Diff",
+				Cell:   change.Cell,
+				Text:   fmt.Sprintf("Original (%s): %s", change.OriginalCell, change.Old),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
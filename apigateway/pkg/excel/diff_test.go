@@ -0,0 +1,241 @@
+package excel
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+const diffYAML = `
+name: "Stock Review"
+version: "1"
+sheets:
+  - name: "Review"
+    protection:
+      password_key: "review"
+    sections:
+      - id: "items"
+        title: "Items"
+        show_header: true
+        locked: true
+        key: ["SKU"]
+        columns:
+          - field_name: "SKU"
+            header: "SKU"
+            hidden_field_name: "sku"
+          - field_name: "Description"
+            header: "Description"
+            hidden_field_name: "description"
+          - field_name: "Quantity"
+            header: "Quantity"
+            hidden_field_name: "quantity"
+            locked: false
+      - id: "notes"
+        show_header: true
+        columns:
+          - field_name: "Note"
+            header: "Note"
+          - field_name: "Author"
+            header: "Author"
+`
+
+type diffItem struct {
+	SKU         string
+	Description string
+	Quantity    int
+}
+
+type diffNote struct {
+	Note   string
+	Author string
+}
+
+// newDiffWorkbook exports diffYAML and returns the exporter and the workbook bytes.
+func newDiffWorkbook(t *testing.T) (*ExcelDataExporter, []byte) {
+	e, err := NewExcelDataExporterFromYamlConfig(diffYAML)
+	require.NoError(t, err)
+	e.SetPassword("review", "secret")
+	e.BindSectionData("items", []diffItem{
+		{"A-1", "Bolts", 10},
+		{"A-2", "Nuts", 20},
+		{"A-3", "Washers", 30},
+	})
+	e.BindSectionData("notes", []diffNote{{"Check counts", "Ann"}})
+	data, err := e.ToBytes()
+	require.NoError(t, err)
+	return e, data
+}
+
+func openWorkbook(t *testing.T, data []byte) *excelize.File {
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestSectionLayoutMetadata(t *testing.T) {
+	_, data := newDiffWorkbook(t)
+	meta, err := ReadReportMetadata(openWorkbook(t, data))
+	require.NoError(t, err)
+	assert.Equal(t, []SectionLayout{
+		{ID: "items", Sheet: "Review", Top: 1, FieldRow: 2, HeaderRow: 3, Row: 4, Col: 1, Rows: 3, Fields: []string{"SKU", "Description", "Quantity"}},
+		{ID: "notes", Sheet: "Review", Top: 7, HeaderRow: 7, Row: 8, Col: 1, Rows: 1, Fields: []string{"Note", "Author"}},
+	}, meta.Layout)
+	assert.Empty(t, meta.Custom)
+}
+
+func TestSectionLayoutSplitAcrossProperties(t *testing.T) {
+	row := make(map[string]interface{})
+	var fields []string
+	for i := 0; i < 30; i++ {
+		field := fmt.Sprintf("Größe_%02d", i)
+		row[field] = i
+		fields = append(fields, field)
+	}
+	e := NewExcelDataExporter().SetReportInfo("Sizes", "1")
+	e.AddSheet("Größen").AddSection(&SectionConfig{ID: "sizes", ShowHeader: true, Data: []map[string]interface{}{row}})
+	data, err := e.ToBytes()
+	require.NoError(t, err)
+
+	f := openWorkbook(t, data)
+	props, err := getCustomProps(f)
+	require.NoError(t, err)
+	assert.NotEmpty(t, props[PropSectionLayout+"2"])
+	for name, value := range props {
+		assert.LessOrEqual(t, len(value), maxPropertyLength, name)
+		assert.True(t, utf8.ValidString(value), name)
+	}
+
+	meta, err := ReadReportMetadata(f)
+	require.NoError(t, err)
+	require.Len(t, meta.Layout, 1)
+	assert.Equal(t, "Größen", meta.Layout[0].Sheet)
+	assert.ElementsMatch(t, fields, meta.Layout[0].Fields)
+}
+
+func TestDiffWorkbooksUnchanged(t *testing.T) {
+	e, data := newDiffWorkbook(t)
+	diff, err := e.DiffWorkbooks(openWorkbook(t, data), openWorkbook(t, data))
+	require.NoError(t, err)
+	assert.False(t, diff.HasChanges())
+	assert.Empty(t, diff.Warnings)
+	assert.Len(t, diff.Sections, 2)
+}
+
+func TestDiffWorkbooksRows(t *testing.T) {
+	e, data := newDiffWorkbook(t)
+	modified := openWorkbook(t, data)
+	require.NoError(t, modified.SetCellValue("Review", "C4", 12))         // Editable quantity of A-1
+	require.NoError(t, modified.SetCellValue("Review", "B5", "Hex nuts")) // Locked description of A-2
+	require.NoError(t, modified.RemoveRow("Review", 6))                   // A-3
+	require.NoError(t, modified.InsertRows("Review", 5, 2))               // Shifts the notes section down
+	require.NoError(t, modified.SetSheetRow("Review", "A5", &[]interface{}{"B-1", "Screws", 5}))
+	require.NoError(t, modified.SetCellValue("Review", "B9", "Bob"))
+
+	diff, err := e.DiffWorkbooks(openWorkbook(t, data), modified)
+	require.NoError(t, err)
+	assert.Equal(t, DiffSummary{CellsChanged: 3, LockedCellsChanged: 1, RowsAdded: 1, RowsRemoved: 1}, diff.Summary)
+
+	items := diff.Sections[0]
+	assert.Equal(t, []string{"SKU"}, items.Key)
+	assert.Equal(t, []CellDiff{
+		{Key: "A-1", Field: "Quantity", Cell: "C4", OriginalCell: "C4", Old: "10", New: "12"},
+		{Key: "A-2", Field: "Description", Cell: "B7", OriginalCell: "B5", Old: "Nuts", New: "Hex nuts", Locked: true},
+	}, items.Changes)
+	assert.Equal(t, []RowDiff{{Key: "B-1", Row: 5, Range: "A5:C5", Values: map[string]string{"SKU": "B-1", "Description": "Screws", "Quantity": "5"}}}, items.RowsAdded)
+	assert.Equal(t, []RowDiff{{Key: "A-3", Row: 6, Range: "A6:C6", Values: map[string]string{"SKU": "A-3", "Description": "Washers", "Quantity": "30"}}}, items.RowsRemoved)
+
+	notes := diff.Sections[1]
+	assert.Empty(t, notes.Key)
+	assert.Equal(t, []CellDiff{{Field: "Author", Cell: "B9", OriginalCell: "B8", Old: "Ann", New: "Bob"}}, notes.Changes)
+}
+
+func TestDiffWorkbooksColumns(t *testing.T) {
+	e, data := newDiffWorkbook(t)
+	modified := openWorkbook(t, data)
+	// Swap Description and Quantity, then insert a column between SKU and Quantity
+	for row := 2; row <= 6; row++ {
+		b, _ := modified.GetCellValue("Review", cellName(2, row))
+		c, _ := modified.GetCellValue("Review", cellName(3, row))
+		require.NoError(t, modified.SetCellValue("Review", cellName(2, row), c))
+		require.NoError(t, modified.SetCellValue("Review", cellName(3, row), b))
+	}
+	require.NoError(t, modified.InsertCols("Review", "B", 1))
+	require.NoError(t, modified.SetCellValue("Review", "B3", "Comment"))
+	require.NoError(t, modified.SetCellValue("Review", "B4", "recount"))
+	// Remove the Author column of the notes, shifted to C by the inserted column
+	require.NoError(t, modified.SetCellValue("Review", "C7", ""))
+	require.NoError(t, modified.SetCellValue("Review", "C8", ""))
+
+	diff, err := e.DiffWorkbooks(openWorkbook(t, data), modified)
+	require.NoError(t, err)
+
+	items := diff.Sections[0]
+	assert.True(t, items.ColumnsReordered)
+	assert.Equal(t, []string{"Comment"}, items.ColumnsAdded)
+	assert.Empty(t, items.ColumnsRemoved)
+	assert.Empty(t, items.Changes)
+
+	notes := diff.Sections[1]
+	assert.Equal(t, []string{"Author"}, notes.ColumnsRemoved)
+	assert.Empty(t, notes.Changes)
+	assert.True(t, diff.HasChanges())
+}
+
+func TestDiffWorkbooksMissingSection(t *testing.T) {
+	e, data := newDiffWorkbook(t)
+	modified := openWorkbook(t, data)
+	require.NoError(t, modified.SetSheetName("Review", "Reviewed"))
+
+	diff, err := e.DiffWorkbooks(openWorkbook(t, data), modified)
+	require.NoError(t, err)
+	assert.Equal(t, 2, diff.Summary.SectionsMissing)
+	assert.True(t, diff.Sections[0].Missing)
+}
+
+func TestDiffWorkbooksStaleTemplate(t *testing.T) {
+	_, data := newDiffWorkbook(t)
+	other, err := NewExcelDataExporterFromYamlConfig(propertiesYAML)
+	require.NoError(t, err)
+	_, err = other.DiffWorkbooks(openWorkbook(t, data), openWorkbook(t, data))
+	assert.ErrorIs(t, err, ErrStaleTemplate)
+}
+
+func TestHighlightDiff(t *testing.T) {
+	e, data := newDiffWorkbook(t)
+	modified := openWorkbook(t, data)
+	require.NoError(t, modified.SetCellValue("Review", "C4", 12))
+	require.NoError(t, modified.SetCellValue("Review", "B5", "Hex nuts"))
+	require.NoError(t, modified.InsertRows("Review", 7, 1))
+	require.NoError(t, modified.SetSheetRow("Review", "A7", &[]interface{}{"B-1", "Screws", 5}))
+
+	diff, err := e.DiffWorkbooks(openWorkbook(t, data), modified)
+	require.NoError(t, err)
+	require.NoError(t, HighlightDiff(modified, diff))
+
+	fillOf := func(cell string) string {
+		id, err := modified.GetCellStyle("Review", cell)
+		require.NoError(t, err)
+		style, err := modified.GetStyle(id)
+		require.NoError(t, err)
+		if len(style.Fill.Color) == 0 {
+			return ""
+		}
+		return style.Fill.Color[0]
+	}
+	assert.Equal(t, DiffChangedColor, fillOf("C4"))
+	assert.Equal(t, DiffLockedColor, fillOf("B5"))
+	assert.Equal(t, DiffAddedColor, fillOf("A7"))
+	assert.Equal(t, DiffAddedColor, fillOf("C7"))
+	assert.Equal(t, DefaultLockedColor, fillOf("A4"))
+
+	comments, err := modified.GetComments("Review")
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+	assert.Equal(t, "Original (B5): Nuts", comments[1].Text)
+}
@@ -1,4 +1,4 @@
-package simpleexcelv2
+package excel
 
 import (
 	"bytes"
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/xuri/excelize/v2"
@@ -36,15 +38,39 @@ type ExcelDataExporter struct {
 	sheets []*SheetBuilder
 	// formatters holds registered formatter functions by name
 	formatters map[string]func(interface{}) interface{}
-
-	// Metadata for coordinate mapping
-	sectionMetadata map[string]SectionPlacement
-
-	// Performance Caches
-	styleCache   map[string]int
+	// formatterFactories holds registered parameterized formatters; resolvedFormatters caches resolved specs (cacheMu)
+	formatterFactories map[string]FormatterFactory
+	resolvedFormatters map[string]func(interface{}) interface{}
+	// locale is the export locale (see SetLocale)
+	locale *LocaleBundle
+	// compiledExprs caches column expressions compiled per data item type (cacheMu)
+	compiledExprs map[exprCacheKey]*compiledExpr
+	// redaction is the redaction policy of the export (see SetRedactionPolicy); redactionConfig maps roles to policies
+	redaction       *RedactionPolicy
+	redactionConfig *RedactionConfig
+
+	// Performance Caches, guarded by cacheMu so concurrent exports can share the exporter.
+	// Style IDs are per workbook; the styles of a workbook are dropped when its export ends.
+	cacheMu      sync.RWMutex
+	styleCache   map[*excelize.File]map[string]int
 	colNameCache map[int]string
-	fieldCache   map[fieldCacheKey]int
+	fieldCache   map[fieldCacheKey][]int
 	logger       Logger
+
+	// Protection & encryption (passwords are supplied at runtime, keyed by password_key)
+	workbookProtection *WorkbookProtectionConfig
+	encryption         *EncryptionConfig
+	passwords          map[string]string
+
+	// Document properties & report metadata
+	properties    *DocumentProperties
+	reportName    string
+	configVersion string
+	configHash    string
+	requestID     string
+
+	// Sheet rollover (see SetMaxRowsPerSheet)
+	maxRowsPerSheet int
 }
 
 // Logger interface for internal logging
@@ -64,7 +90,7 @@ func (e *ExcelDataExporter) log(format string, args ...interface{}) {
 	}
 }
 
-// fieldCacheKey is a unique key for caching field indices.
+// fieldCacheKey is a unique key for caching field index paths.
 type fieldCacheKey struct {
 	Type      reflect.Type
 	FieldName string
@@ -73,6 +99,7 @@ type fieldCacheKey struct {
 // SectionPlacement stores the starting coordinates and metadata of a rendered section.
 type SectionPlacement struct {
 	SectionID    string
+	Sheet        string
 	StartRow     int
 	StartCol     int
 	FieldOffsets map[string]int // Map of FieldName to ColumnOffset (relative to startCol)
@@ -81,13 +108,23 @@ type SectionPlacement struct {
 
 // ReportTemplate represents the YAML structure.
 type ReportTemplate struct {
-	Sheets []SheetTemplate `yaml:"sheets"`
+	Name               string                    `yaml:"name"`
+	Version            string                    `yaml:"version"`
+	Description        string                    `yaml:"description"`
+	Properties         *DocumentProperties       `yaml:"properties"`
+	WorkbookProtection *WorkbookProtectionConfig `yaml:"workbook_protection"`
+	Encryption         *EncryptionConfig         `yaml:"encryption"`
+	MaxRowsPerSheet    int                       `yaml:"max_rows_per_sheet"` // Continue on a new sheet after this many rows
+	Redaction          *RedactionConfig          `yaml:"redaction"`          // Redaction policies and roles
+	Schedules          []ReportSchedule          `yaml:"schedules"`          // Recurring exports of the report
+	Sheets             []SheetTemplate           `yaml:"sheets"`
 }
 
 // SheetTemplate represents a sheet in the YAML.
 type SheetTemplate struct {
-	Name     string          `yaml:"name"`
-	Sections []SectionConfig `yaml:"sections"`
+	Name       string            `yaml:"name"`
+	Protection *ProtectionConfig `yaml:"protection"`
+	Sections   []SectionConfig   `yaml:"sections"`
 }
 
 // SectionConfig defines a section of data in a sheet.
@@ -109,7 +146,16 @@ type SectionConfig struct {
 	HeaderHeight   float64        `yaml:"header_height"`
 	DataHeight     float64        `yaml:"data_height"`
 	HasFilter      bool           `yaml:"has_filter"`
+	AsTable        bool           `yaml:"as_table"`     // Render header and data as a native Excel table
+	TableStyle     string         `yaml:"table_style"`  // Built-in table style, e.g. "TableStyleMedium2"
+	NameColumns    bool           `yaml:"name_columns"` // Also register each column's data range as a defined name
+	Banding        *BandingConfig `yaml:"banding"`      // Alternating odd/even data row styles
+	Key            []string       `yaml:"key"`          // Fields identifying a data row when comparing workbooks (see DiffWorkbooks)
+	RowStyler      RowStyler      `yaml:"-"`            // Optional per-row style callback (Programmatic)
 	Columns        []ColumnConfig `yaml:"columns"`
+
+	// dropped holds the fields of columns dropped by the redaction policy
+	dropped map[string]bool
 }
 
 // CompareConfig defines how to compare a column with another section.
@@ -125,8 +171,11 @@ type ColumnConfig struct {
 	Width           float64                       `yaml:"width"`
 	Height          float64                       `yaml:"height"`
 	Locked          *bool                         `yaml:"locked"`            // Column-level lock override (overrides section Locked)
+	Formula         string                        `yaml:"formula"`           // Per-row formula template, e.g. "={Price}*{Quantity}"
 	Formatter       func(interface{}) interface{} `yaml:"-"`                 // Optional custom formatter function (Programmatic)
-	FormatterName   string                        `yaml:"formatter"`         // Name of registered formatter (YAML)
+	FormatterName   string                        `yaml:"formatter"`         // Formatter spec (YAML), e.g. "currency(EUR,2)" or "trim|upper"
+	Expr            string                        `yaml:"expr"`              // Value computed in Go per row, e.g. "Price * 1.1" (see expr.go)
+	Sensitivity     string                        `yaml:"sensitivity"`       // Sensitivity tag for redaction policies, e.g. "pii" or "salary"
 	HiddenFieldName string                        `yaml:"hidden_field_name"` // Hidden field name for backend use
 	CompareWith     *CompareConfig                `yaml:"compare_with"`      // For injecting comparison formulas
 	CompareAgainst  *CompareConfig                `yaml:"compare_against"`   // For injecting comparison formulas
@@ -152,12 +201,20 @@ func (s *SectionConfig) GetColumn(fieldName string) *ColumnConfig {
 	return nil
 }
 
+// ResolvedColumns returns the columns an export renders for the section and its data: the configured columns
+// followed by the fields detected in the data, without the columns dropped by redaction on the copies made
+// for an export. Exports resolve the columns on a copy of the section, so Columns keeps the configured columns.
+func (s *SectionConfig) ResolvedColumns() []ColumnConfig {
+	return mergeSectionColumns(s, s.Data)
+}
+
 // StyleTemplate defines basic styling.
 type StyleTemplate struct {
 	Font      *FontTemplate      `yaml:"font"`
 	Fill      *FillTemplate      `yaml:"fill"`
 	Alignment *AlignmentTemplate `yaml:"alignment"`
 	Locked    *bool              `yaml:"locked"`
+	NumFmt    string             `yaml:"num_fmt"` // Excel number format, e.g. "#,##0.00" or "dd/mm/yyyy"
 }
 
 type AlignmentTemplate struct {
@@ -180,13 +237,13 @@ type FillTemplate struct {
 
 func NewExcelDataExporter() *ExcelDataExporter {
 	return &ExcelDataExporter{
-		data:            make(map[string]interface{}),
-		sheets:          []*SheetBuilder{},
-		formatters:      make(map[string]func(interface{}) interface{}),
-		sectionMetadata: make(map[string]SectionPlacement),
-		styleCache:      make(map[string]int),
-		colNameCache:    make(map[int]string),
-		fieldCache:      make(map[fieldCacheKey]int),
+		data:         make(map[string]interface{}),
+		sheets:       []*SheetBuilder{},
+		formatters:   make(map[string]func(interface{}) interface{}),
+		styleCache:   make(map[*excelize.File]map[string]int),
+		colNameCache: make(map[int]string),
+		fieldCache:   make(map[fieldCacheKey][]int),
+		passwords:    make(map[string]string),
 	}
 }
 
@@ -198,25 +255,56 @@ func NewExcelDataExporterFromYamlConfig(yamlConfig string) (*ExcelDataExporter,
 	if err := yaml.Unmarshal([]byte(yamlConfig), &tmpl); err != nil {
 		return nil, fmt.Errorf("decode yaml: %w", err)
 	}
+	if err := validateTemplateExpressions(&tmpl); err != nil {
+		return nil, err
+	}
+	if tmpl.Redaction != nil {
+		if err := tmpl.Redaction.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateSchedules(&tmpl); err != nil {
+		return nil, err
+	}
 
 	exporter := &ExcelDataExporter{
-		template:        &tmpl,
-		data:            make(map[string]interface{}),
-		formatters:      make(map[string]func(interface{}) interface{}),
-		sheets:          make([]*SheetBuilder, 0),
-		sectionMetadata: make(map[string]SectionPlacement),
-		styleCache:      make(map[string]int),
-		colNameCache:    make(map[int]string),
-		fieldCache:      make(map[fieldCacheKey]int),
+		template:     &tmpl,
+		data:         make(map[string]interface{}),
+		formatters:   make(map[string]func(interface{}) interface{}),
+		sheets:       make([]*SheetBuilder, 0),
+		styleCache:   make(map[*excelize.File]map[string]int),
+		colNameCache: make(map[int]string),
+		fieldCache:   make(map[fieldCacheKey][]int),
+		passwords:    make(map[string]string),
+
+		workbookProtection: tmpl.WorkbookProtection,
+		encryption:         tmpl.Encryption,
+
+		properties:    tmpl.Properties,
+		reportName:    tmpl.Name,
+		configVersion: tmpl.Version,
+		configHash:    configHash(yamlConfig),
+
+		maxRowsPerSheet: tmpl.MaxRowsPerSheet,
+		redactionConfig: tmpl.Redaction,
+	}
+	if tmpl.Description != "" {
+		if exporter.properties == nil {
+			exporter.properties = &DocumentProperties{}
+		}
+		if exporter.properties.Description == "" {
+			exporter.properties.Description = tmpl.Description
+		}
 	}
 
 	// Initialize sheets from template
 	for i := range tmpl.Sheets {
 		sheetTmpl := &tmpl.Sheets[i]
 		sb := &SheetBuilder{
-			exporter: exporter,
-			name:     sheetTmpl.Name,
-			sections: make([]*SectionConfig, len(sheetTmpl.Sections)),
+			exporter:   exporter,
+			name:       sheetTmpl.Name,
+			sections:   make([]*SectionConfig, len(sheetTmpl.Sections)),
+			protection: sheetTmpl.Protection,
 		}
 		for j := range sheetTmpl.Sections {
 			sb.sections[j] = &sheetTmpl.Sections[j]
@@ -252,6 +340,7 @@ func (e *ExcelDataExporter) BindSectionData(id string, data interface{}) *ExcelD
 // This allows referencing formatters by name in YAML configurations.
 func (e *ExcelDataExporter) RegisterFormatter(name string, f func(interface{}) interface{}) *ExcelDataExporter {
 	e.formatters[name] = f
+	e.resetFormatters()
 	return e
 }
 
@@ -291,36 +380,60 @@ func (e *ExcelDataExporter) GetSection(id string) *SectionConfig {
 // It processes both programmatically added sheets and sheets defined in a YAML template,
 // returning the generated excelize.File instance or an error// BuildExcel generates the excel file
 func (e *ExcelDataExporter) BuildExcel() (*excelize.File, error) {
+	res, err := e.BuildExcelResult()
+	if err != nil {
+		return nil, err
+	}
+	return res.File, nil
+}
+
+// BuildExcelResult builds the Excel file like BuildExcel and returns it with the sheets that were continued
+// on a new sheet because they reached the row limit.
+func (e *ExcelDataExporter) BuildExcelResult() (*ExcelResult, error) {
 	f := excelize.NewFile()
+	defer e.releaseStyles(f)
+
+	// The run holds copies of the sheets with the data bound to the sections by ID
+	run := e.newRun()
+	used := make(map[string]bool, len(run.sheets))
+	for _, sb := range run.sheets {
+		used[sb.name] = true
+	}
 
 	// Process All Sheets (both fluent and YAML-initialized are now in e.sheets)
-	for i, sb := range e.sheets {
-		sheetName := sb.name
-		if i == 0 {
-			f.SetSheetName("Sheet1", sheetName)
-		} else {
-			// Check if sheet exists to avoid error if duplicates (though logic shouldn't produce duplicates easily)
-			idx, _ := f.GetSheetIndex(sheetName)
-			if idx == -1 {
-				f.NewSheet(sheetName)
-			}
+	first := true
+	for _, sb := range run.sheets {
+		// Sheets beyond the row limit continue on "<name> (2)", "<name> (3)", ...
+		pages, err := e.paginate(run, sb, used)
+		if err != nil {
+			return nil, err
 		}
 
-		// Perform Late Binding for any section that has an ID and matching data in e.data
-		for _, sec := range sb.sections {
-			if sec.ID != "" {
-				if data, ok := e.data[sec.ID]; ok {
-					sec.Data = data
+		for _, page := range pages {
+			if first {
+				f.SetSheetName("Sheet1", page.name)
+				first = false
+			} else {
+				// Check if sheet exists to avoid error if duplicates (though logic shouldn't produce duplicates easily)
+				idx, _ := f.GetSheetIndex(page.name)
+				if idx == -1 {
+					f.NewSheet(page.name)
 				}
 			}
-		}
 
-		if err := e.renderSections(f, sheetName, sb.sections); err != nil {
-			return nil, err
+			if err := e.renderSections(run, f, page.name, page.sections, sb.protection); err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	return f, nil
+	if err := e.protectWorkbook(f); err != nil {
+		return nil, err
+	}
+	if err := e.applyDocumentProperties(f, run.layout); err != nil {
+		return nil, err
+	}
+	return &ExcelResult{File: f, Splits: run.splits}, nil
 }
 
 // ExportToExcel generates the Excel file on disk.
@@ -330,7 +443,12 @@ func (e *ExcelDataExporter) ExportToExcel(ctx context.Context, path string) erro
 		return err
 	}
 	defer f.Close()
-	return f.SaveAs(path)
+
+	opts, err := e.saveOptions()
+	if err != nil {
+		return err
+	}
+	return f.SaveAs(path, opts...)
 }
 
 // ToBytes exports the Excel file to an in-memory byte slice.
@@ -341,9 +459,14 @@ func (e *ExcelDataExporter) ToBytes() ([]byte, error) {
 	}
 	defer f.Close()
 
+	opts, err := e.saveOptions()
+	if err != nil {
+		return nil, err
+	}
+
 	// Create a buffer and write the Excel file to it
 	buf := new(bytes.Buffer)
-	if _, err := f.WriteTo(buf); err != nil {
+	if _, err := f.WriteTo(buf, opts...); err != nil {
 		return nil, err
 	}
 	return buf.Bytes(), nil
@@ -351,40 +474,37 @@ func (e *ExcelDataExporter) ToBytes() ([]byte, error) {
 
 // StartStream initializes a streaming export session.
 // It returns a Streamer which can be used to write data incrementally.
+// Use StartStreamContext for cancellation, progress reporting and a row limit.
 func (e *ExcelDataExporter) StartStream(w io.Writer) (*Streamer, error) {
+	return e.startStream(context.Background(), w, StreamOptions{})
+}
+
+func (e *ExcelDataExporter) startStream(ctx context.Context, w io.Writer, opts StreamOptions) (*Streamer, error) {
 	// 1. Initialize File
-	f := excelize.NewFile()
+	run := e.newRun()
+	session := e.newStreamSession(ctx, w, opts, run)
+	f := session.file
 	streamer := &Streamer{
+		session:       session,
 		exporter:      e,
+		run:           run,
 		file:          f,
-		writer:        w,
 		streamWriters: make(map[string]*excelize.StreamWriter),
+		placements:    make(map[*SectionConfig]*SectionPlacement),
+		continuations: make(map[string][]string),
+		accessors:     make(map[*SectionConfig]*rowAccessor),
 	}
 
-	// 2. Prepare Sheets
-	for i, sb := range e.sheets {
-		sheetName := sb.name
-		if i == 0 {
-			f.SetSheetName("Sheet1", sheetName)
-		} else {
-			f.NewSheet(sheetName)
-		}
-
-		// Initialize StreamWriter for this sheet
-		sw, err := f.NewStreamWriter(sheetName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create stream writer for sheet %s: %w", sheetName, err)
-		}
-		streamer.streamWriters[sheetName] = sw
+	// 2. Prepare state. Sheets are created when they are reached, so that continuation sheets
+	// follow their template sheet.
+	if err := streamer.startSheet(0); err != nil {
+		session.release()
+		return nil, err
 	}
 
-	// Prepare state
-	streamer.currentSheetIndex = 0
-	streamer.currentSectionIndex = 0
-	streamer.currentRow = 1
-
 	// Initial processing (render static sections of first sheet)
 	if err := streamer.advanceToNextStreamingSection(); err != nil {
+		session.release()
 		return nil, err
 	}
 
@@ -393,13 +513,23 @@ func (e *ExcelDataExporter) StartStream(w io.Writer) (*Streamer, error) {
 
 // ToWriter exports the Excel file directly to a writer.
 func (e *ExcelDataExporter) ToWriter(w io.Writer) error {
-	f, err := e.BuildExcel()
+	_, err := e.ToWriterSplits(w)
+	return err
+}
+
+// ToWriterSplits exports the Excel file to a writer like ToWriter and returns the splits of its sheets.
+func (e *ExcelDataExporter) ToWriterSplits(w io.Writer) ([]SheetSplit, error) {
+	res, err := e.BuildExcelResult()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer f.Close()
+	defer res.File.Close()
 
-	return f.Write(w)
+	opts, err := e.saveOptions()
+	if err != nil {
+		return nil, err
+	}
+	return res.Splits, res.File.Write(w, opts...)
 }
 
 // ToCSV exports the first sheet of data to CSV format.
@@ -412,34 +542,27 @@ func (e *ExcelDataExporter) ToCSV(w io.Writer) error {
 	csvWriter := csv.NewWriter(w)
 	defer csvWriter.Flush()
 
-	sheet := e.sheets[0]
+	sheet := e.newRun().sheets[0]
 	for _, sec := range sheet.sections {
-		// Perform Late Binding if needed
-		if sec.ID != "" && sec.Data == nil {
-			if data, ok := e.data[sec.ID]; ok {
-				sec.Data = data
-			}
-		}
-
 		// Get data length
-		dataLen := e.getDataLength(sec)
+		dataLen := e.getDataLength(nil, sec)
 		if dataLen == 0 && !sec.ShowHeader {
 			continue
 		}
 
 		// Resolve columns
-		cols := mergeColumns(sec.Data, sec.Columns)
+		cols := mergeSectionColumns(sec, sec.Data)
 
 		// Title (if single title only)
 		if sec.Title != nil {
-			_ = csvWriter.Write([]string{fmt.Sprintf("%v", sec.Title)})
+			_ = csvWriter.Write([]string{fmt.Sprintf("%v", e.localizedTitle(sec.Title))})
 		}
 
 		// Header
 		if sec.ShowHeader && len(cols) > 0 {
 			headerArr := make([]string, len(cols))
 			for i, col := range cols {
-				headerArr[i] = col.Header
+				headerArr[i] = e.localizedHeader(col)
 			}
 			if err := csvWriter.Write(headerArr); err != nil {
 				return err
@@ -453,19 +576,13 @@ func (e *ExcelDataExporter) ToCSV(w io.Writer) error {
 				v = v.Elem()
 			}
 
+			accessor := e.compileRowAccessor(cols, sec.Data)
 			for i := 0; i < dataLen; i++ {
 				item := v.Index(i)
 				rowArr := make([]string, len(cols))
-				for j, col := range cols {
-					val := e.extractValue(item, col.FieldName)
+				for j := range cols {
 					// Apply formatter if any
-					if col.Formatter != nil {
-						val = col.Formatter(val)
-					} else if col.FormatterName != "" && e.formatters != nil {
-						if fn, ok := e.formatters[col.FormatterName]; ok {
-							val = fn(val)
-						}
-					}
+					val := accessor.value(j, item)
 					rowArr[j] = fmt.Sprintf("%v", val)
 				}
 				if err := csvWriter.Write(rowArr); err != nil {
@@ -486,9 +603,10 @@ func (e *ExcelDataExporter) ToCSV(w io.Writer) error {
 // =============================================================================
 
 type SheetBuilder struct {
-	exporter *ExcelDataExporter
-	name     string
-	sections []*SectionConfig
+	exporter   *ExcelDataExporter
+	name       string
+	sections   []*SectionConfig
+	protection *ProtectionConfig
 }
 
 func (sb *SheetBuilder) AddSection(config *SectionConfig) *SheetBuilder {
@@ -530,21 +648,20 @@ func calculatePosition(sec *SectionConfig, nextColHorizontal, maxRow int) (int,
 	return 1, maxRow
 }
 
-// getDataLength returns the expected number of data rows for a section.
-func (e *ExcelDataExporter) getDataLength(sec *SectionConfig) int {
+// getDataLength returns the expected number of data rows for a section. Sections without data take the
+// length of their first source section placed by the run.
+func (e *ExcelDataExporter) getDataLength(run *exportRun, sec *SectionConfig) int {
 	dataVal := reflect.ValueOf(sec.Data)
 	if dataVal.Kind() == reflect.Slice {
 		return dataVal.Len()
 	}
 	if len(sec.SourceSections) > 0 {
-		if sourcePlacement, ok := e.sectionMetadata[sec.SourceSections[0]]; ok {
-			return sourcePlacement.DataLen
-		}
+		return run.dataLen(sec.SourceSections[0])
 	}
 	return 0
 }
 
-func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, sections []*SectionConfig) error {
+func (e *ExcelDataExporter) renderSections(run *exportRun, f *excelize.File, sheet string, sections []*SectionConfig, protection *ProtectionConfig) error {
 	t0 := time.Now()
 	// --- PASS 1: Layout Calculation ---
 	tempRow, tempCol := 1, 1
@@ -560,7 +677,7 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 		}
 
 		// Determine effective columns merging user config and data fields
-		sec.Columns = mergeColumns(sec.Data, sec.Columns)
+		sec.Columns = mergeSectionColumns(sec, sec.Data)
 
 		// Determine start coordinates
 		sCol, sRow := calculatePosition(sec, tempCol, tempRow)
@@ -589,10 +706,11 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 		}
 
 		// We need to know DataLen for Pass 1 to update tempRow/tempCol trackers accurately
-		dataLen := e.getDataLength(sec)
+		dataLen := e.getDataLength(run, sec)
 
 		placements[i] = SectionPlacement{
 			SectionID:    sec.ID,
+			Sheet:        sheet,
 			StartRow:     dataStartRow,
 			StartCol:     sCol,
 			FieldOffsets: fieldOffsets,
@@ -600,7 +718,7 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 		}
 
 		if sec.ID != "" {
-			e.sectionMetadata[sec.ID] = placements[i]
+			run.placements[sec.ID] = placements[i]
 		}
 
 		// Update global trackers for Pass 1 layout
@@ -624,31 +742,21 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 	}
 	e.log("Pass 1 (Layout) took %v", time.Since(t0))
 
+	// Only flow layouts are split across sheets (see paginate)
+	if last := maxRowForPass1 - 1; last > e.maxRows() {
+		return fmt.Errorf("sheet %s needs %d rows, more than the %d rows allowed per sheet; sheets with horizontal or positioned sections are not split", sheet, last, e.maxRows())
+	}
+
 	t1 := time.Now()
 	// --- PASS 2: Actual Rendering ---
 	maxRow := 1
 	nextColHorizontal := 1
-	hasLockedCells := false
 	hiddenRows := []int{}
 
 	// Check for locked cells first (to decide if we need to unlock sheet)
-	for _, sec := range sections {
-		if sec.Locked {
-			hasLockedCells = true
-		} else {
-			for _, col := range sec.Columns {
-				if col.Locked != nil && *col.Locked {
-					hasLockedCells = true
-					break
-				}
-			}
-		}
-		if hasLockedCells {
-			break
-		}
-	}
+	hasLockedCells := sectionsHaveLockedCells(sections)
 
-	if hasLockedCells {
+	if hasLockedCells || protection != nil {
 		unlocked := false
 		defaultStyle := &StyleTemplate{Locked: &unlocked}
 		styleID, _ := e.createStyle(f, defaultStyle)
@@ -671,7 +779,7 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 		if sectionType == SectionTypeTitleOnly {
 			if sec.Title != nil {
 				cell := e.getCellAddress(sCol, currentRow)
-				f.SetCellValue(sheet, cell, sec.Title)
+				f.SetCellValue(sheet, cell, e.localizedTitle(sec.Title))
 				defaultTitleOnly := &StyleTemplate{
 					Font:      &FontTemplate{Bold: true},
 					Alignment: &AlignmentTemplate{Horizontal: "center", Vertical: "top"},
@@ -708,10 +816,12 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 			continue
 		}
 
+		layout := SectionLayout{ID: sec.ID, Sheet: sheet, Top: sRow, Col: sCol, Rows: placement.DataLen}
+
 		// Render Title
 		if sec.Title != nil {
 			cell := e.getCellAddress(sCol, currentRow)
-			f.SetCellValue(sheet, cell, sec.Title)
+			f.SetCellValue(sheet, cell, e.localizedTitle(sec.Title))
 			defaultTitle := &StyleTemplate{
 				Font:      &FontTemplate{Bold: true},
 				Alignment: &AlignmentTemplate{Horizontal: "center", Vertical: "top"},
@@ -736,6 +846,7 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 			locked := true
 			hiddenStyle := &StyleTemplate{Fill: &FillTemplate{Color: "FFFF00"}, Locked: &locked}
 			styleID, _ := e.createStyle(f, hiddenStyle)
+			layout.FieldRow = currentRow
 			for i, col := range sec.Columns {
 				cell := e.getCellAddress(sCol+i, currentRow)
 				f.SetCellValue(sheet, cell, col.HiddenFieldName)
@@ -747,9 +858,10 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 
 		// Render Header
 		if sec.ShowHeader {
+			layout.HeaderRow = currentRow
 			for i, col := range sec.Columns {
 				cell := e.getCellAddress(sCol+i, currentRow)
-				f.SetCellValue(sheet, cell, col.Header)
+				f.SetCellValue(sheet, cell, e.localizedHeader(col))
 				locked := col.IsLocked(sec.Locked)
 				defaultHeader := &StyleTemplate{
 					Font:      &FontTemplate{Bold: true},
@@ -771,20 +883,24 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 		}
 
 		// --- Batch Data Rendering ---
+		layout.Row = currentRow
 		dataLen := placement.DataLen
 		dataVal := reflect.ValueOf(sec.Data)
 
 		if dataLen > 0 {
+			var defaultDataStyle *StyleTemplate
+			if sectionType == SectionTypeHidden {
+				defaultDataStyle = &StyleTemplate{Fill: &FillTemplate{Color: "FFFF00"}}
+			}
+			rowStyled := hasRowStyles(sec)
+
 			// Pre-calculate data styles for columns so we can apply them in bulk at the end
 			dataStyleIDs := make([]int, len(sec.Columns))
 			maxColHeight := sec.DataHeight
 			for j, col := range sec.Columns {
 				locked := col.IsLocked(sec.Locked)
-				var defaultDataStyle *StyleTemplate
-				if sectionType == SectionTypeHidden {
-					defaultDataStyle = &StyleTemplate{Fill: &FillTemplate{Color: "FFFF00"}}
-				}
 				style := resolveStyle(sec.DataStyle, defaultDataStyle, locked)
+				style = e.localizedCellStyle(style, e.columnSample(col, dataVal))
 				styleID, _ := e.createStyle(f, style)
 				dataStyleIDs[j] = styleID
 				if col.Height > maxColHeight {
@@ -792,8 +908,9 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 				}
 			}
 
-			// Pre-calculate column names to avoid repeated calls in row loop (though SetSheetRow handles finding cells)
-			// Actually SetSheetRow takes "A1", we just need the start cell for each row.
+			// Field index paths, redaction and formatters are resolved once for the section
+			accessor := e.compileRowAccessor(sec.Columns, sec.Data)
+			startColName := e.getColName(sCol)
 
 			for i := 0; i < dataLen; i++ {
 				var item reflect.Value
@@ -816,27 +933,26 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 				for j, col := range sec.Columns {
 					if col.CompareWith != nil {
 						// Formula
-						formula, err := e.generateDiffFormula(col, i)
+						formula, err := e.generateDiffFormula(run, col, placement, i)
 						if err == nil {
 							rowFormulas = append(rowFormulas, docFormula{j, formula})
 						} else {
 							rowValues[j] = fmt.Sprintf("Error: %v", err)
 						}
-					} else if item.IsValid() {
-						val := e.extractValue(item, col.FieldName)
-						if col.Formatter != nil {
-							val = col.Formatter(val)
-						} else if col.FormatterName != "" {
-							if fmtFunc, ok := e.formatters[col.FormatterName]; ok {
-								val = fmtFunc(val)
-							}
+					} else if col.Formula != "" {
+						formula, err := e.generateColumnFormula(run, col, placement, i)
+						if err == nil {
+							rowFormulas = append(rowFormulas, docFormula{j, formula})
+						} else {
+							rowValues[j] = fmt.Sprintf("Error: %v", err)
 						}
-						rowValues[j] = val
+					} else if item.IsValid() {
+						rowValues[j] = accessor.value(j, item)
 					}
 				}
 
 				// Write ROW
-				startCell := e.getCellAddress(sCol, currentRow)
+				startCell := startColName + strconv.Itoa(currentRow)
 				f.SetSheetRow(sheet, startCell, &rowValues)
 
 				// Apply Formulas
@@ -845,6 +961,19 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 					f.SetCellFormula(sheet, cell, form.Formula)
 				}
 
+				// Banding and row stylers need per-row styles instead of the bulk column ranges. Rows are
+				// counted across the parts of a split section, so banding continues on continuation sheets.
+				if rowStyled {
+					rowStyleIDs, err := e.rowStyleIDs(f, sec, defaultDataStyle, run.sectionRow(placement, i), item)
+					if err != nil {
+						return err
+					}
+					for j, styleID := range rowStyleIDs {
+						cell := e.getCellAddress(sCol+j, currentRow)
+						f.SetCellStyle(sheet, cell, cell, styleID)
+					}
+				}
+
 				if maxColHeight > 0 {
 					f.SetRowHeight(sheet, currentRow, maxColHeight)
 				}
@@ -854,7 +983,7 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 			// Apply Styles via Ranges (Bulk Style Application)
 			// Range is from sRow (or where data started) to currentRow-1
 			dataStartRow := placement.StartRow
-			if dataLen > 0 {
+			if dataLen > 0 && !rowStyled {
 				dataEndRow := dataStartRow + dataLen - 1
 
 				for j := 0; j < len(sec.Columns); j++ {
@@ -868,8 +997,8 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 			}
 		}
 
-		// Apply AutoFilter if requested
-		if sec.HasFilter && sec.ShowHeader && len(sec.Columns) > 0 {
+		// Apply AutoFilter if requested (tables carry their own filter)
+		if sec.HasFilter && !sec.AsTable && sec.ShowHeader && len(sec.Columns) > 0 {
 			headerRow := sRow
 			if sec.Title != nil {
 				headerRow++
@@ -885,11 +1014,30 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 			f.AutoFilter(sheet, filterRange, nil)
 		}
 
+		if sec.AsTable {
+			table, err := e.sectionTable(sec, placement)
+			if err != nil {
+				return err
+			}
+			if err := f.AddTable(sheet, table); err != nil {
+				return fmt.Errorf("add table for section %s: %w", sec.ID, err)
+			}
+		}
+		if err := registerSectionNames(f, sec, placement); err != nil {
+			return err
+		}
+
 		if sectionType == SectionTypeHidden {
 			for r := sRow; r < currentRow; r++ {
 				hiddenRows = append(hiddenRows, r)
 			}
 		}
+		if sec.ID != "" {
+			for _, col := range sec.Columns {
+				layout.Fields = append(layout.Fields, col.FieldName)
+			}
+			run.layout = append(run.layout, layout)
+		}
 
 		if currentRow > maxRow {
 			maxRow = currentRow
@@ -902,31 +1050,15 @@ func (e *ExcelDataExporter) renderSections(f *excelize.File, sheet string, secti
 		f.SetRowVisible(sheet, r, false)
 	}
 
-	if hasLockedCells {
-		f.ProtectSheet(sheet, &excelize.SheetProtectionOptions{
-			Password:            "",
-			FormatCells:         false,
-			FormatColumns:       true,
-			FormatRows:          true,
-			InsertColumns:       false,
-			InsertRows:          false,
-			InsertHyperlinks:    false,
-			DeleteColumns:       false,
-			DeleteRows:          false,
-			Sort:                false,
-			AutoFilter:          true,
-			PivotTables:         false,
-			SelectLockedCells:   true,
-			SelectUnlockedCells: true,
-		})
-	}
-	return nil
+	return e.protectSheet(f, sheet, protection, hasLockedCells)
 }
 
-func (e *ExcelDataExporter) resolveCellAddress(sectionID, fieldName string, rowOffset int) (string, error) {
-	placement, ok := e.sectionMetadata[sectionID]
-	if !ok {
-		return "", fmt.Errorf("section %s not found", sectionID)
+// resolveCellAddress returns the address of data row row of a section (counted across the parts of a split
+// section) for a formula of a section placed at from, qualified with the sheet name on other sheets.
+func (e *ExcelDataExporter) resolveCellAddress(run *exportRun, from SectionPlacement, sectionID, fieldName string, row int) (string, error) {
+	placement, rowOffset, err := run.locate(sectionID, row)
+	if err != nil {
+		return "", err
 	}
 
 	colOffset, ok := placement.FieldOffsets[fieldName]
@@ -935,21 +1067,28 @@ func (e *ExcelDataExporter) resolveCellAddress(sectionID, fieldName string, rowO
 	}
 
 	// StartRow in metadata should point to the first row of DATA
-	return excelize.CoordinatesToCellName(placement.StartCol+colOffset, placement.StartRow+rowOffset)
+	cell, err := excelize.CoordinatesToCellName(placement.StartCol+colOffset, placement.StartRow+rowOffset)
+	if err != nil || placement.Sheet == "" || placement.Sheet == from.Sheet {
+		return cell, err
+	}
+	return quoteSheetName(placement.Sheet) + "!" + cell, nil
 }
 
-func (e *ExcelDataExporter) generateDiffFormula(col ColumnConfig, rowOffset int) (string, error) {
+// generateDiffFormula returns the comparison formula of data row rowOffset of a section placed at placement.
+// The compared cells are on the same row of their sections.
+func (e *ExcelDataExporter) generateDiffFormula(run *exportRun, col ColumnConfig, placement SectionPlacement, rowOffset int) (string, error) {
 	if col.CompareWith == nil {
 		return "", nil
 	}
 
-	cellA, err := e.resolveCellAddress(col.CompareWith.SectionID, col.CompareWith.FieldName, rowOffset)
+	row := run.sectionRow(placement, rowOffset)
+	cellA, err := e.resolveCellAddress(run, placement, col.CompareWith.SectionID, col.CompareWith.FieldName, row)
 	if err != nil {
 		return "", err
 	}
 
 	if col.CompareAgainst != nil {
-		cellB, err := e.resolveCellAddress(col.CompareAgainst.SectionID, col.CompareAgainst.FieldName, rowOffset)
+		cellB, err := e.resolveCellAddress(run, placement, col.CompareAgainst.SectionID, col.CompareAgainst.FieldName, row)
 		if err != nil {
 			return "", err
 		}
@@ -1007,11 +1146,16 @@ func resolveStyle(base *StyleTemplate, defaultStyle *StyleTemplate, locked bool)
 
 // getColName returns the column name for a given column number, with caching.
 func (e *ExcelDataExporter) getColName(col int) string {
-	if name, ok := e.colNameCache[col]; ok {
+	e.cacheMu.RLock()
+	name, ok := e.colNameCache[col]
+	e.cacheMu.RUnlock()
+	if ok {
 		return name
 	}
-	name, _ := excelize.ColumnNumberToName(col)
+	name, _ = excelize.ColumnNumberToName(col)
+	e.cacheMu.Lock()
 	e.colNameCache[col] = name
+	e.cacheMu.Unlock()
 	return name
 }
 
@@ -1040,9 +1184,15 @@ func (e *ExcelDataExporter) createStyle(f *excelize.File, tmpl *StyleTemplate) (
 	if tmpl.Locked != nil {
 		fmt.Fprintf(&sb, "l:%v|", *tmpl.Locked)
 	}
+	if tmpl.NumFmt != "" {
+		fmt.Fprintf(&sb, "n:%s|", tmpl.NumFmt)
+	}
 	key := sb.String()
 
-	if id, ok := e.styleCache[key]; ok {
+	e.cacheMu.RLock()
+	id, ok := e.styleCache[f][key]
+	e.cacheMu.RUnlock()
+	if ok {
 		return id, nil
 	}
 
@@ -1071,32 +1221,47 @@ func (e *ExcelDataExporter) createStyle(f *excelize.File, tmpl *StyleTemplate) (
 			Locked: *tmpl.Locked,
 		}
 	}
+	if tmpl.NumFmt != "" {
+		numFmt := tmpl.NumFmt
+		style.CustomNumFmt = &numFmt
+	}
 	id, err := f.NewStyle(style)
 	if err == nil {
-		e.styleCache[key] = id
+		e.cacheMu.Lock()
+		if e.styleCache[f] == nil {
+			e.styleCache[f] = make(map[string]int)
+		}
+		e.styleCache[f][key] = id
+		e.cacheMu.Unlock()
 	}
 	return id, err
 }
 
+// releaseStyles drops the cached style IDs of a workbook whose export ended.
+func (e *ExcelDataExporter) releaseStyles(f *excelize.File) {
+	e.cacheMu.Lock()
+	delete(e.styleCache, f)
+	e.cacheMu.Unlock()
+}
+
 func (e *ExcelDataExporter) extractValue(item reflect.Value, fieldName string) interface{} {
 	if item.Kind() == reflect.Struct {
-		t := item.Type()
-		key := fieldCacheKey{Type: t, FieldName: fieldName}
-		index, ok := e.fieldCache[key]
-		if !ok {
-			f, found := t.FieldByName(fieldName)
-			if found {
-				index = f.Index[0]
-				e.fieldCache[key] = index
-			} else {
-				e.fieldCache[key] = -1 // Not found
-				return ""
-			}
+		index := e.fieldIndex(item.Type(), fieldName)
+		if index == nil {
+			return ""
 		}
-
-		if index != -1 {
-			return item.Field(index).Interface()
+		v := item.Field(index[0])
+		for _, i := range index[1:] {
+			// Fields promoted through embedded pointers
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return nil
+				}
+				v = v.Elem()
+			}
+			v = v.Field(i)
 		}
+		return v.Interface()
 	} else if item.Kind() == reflect.Map {
 		val := item.MapIndex(reflect.ValueOf(fieldName))
 		if val.IsValid() {
@@ -1106,6 +1271,24 @@ func (e *ExcelDataExporter) extractValue(item reflect.Value, fieldName string) i
 	return ""
 }
 
+// fieldIndex returns the index path of a field of struct type t, with caching, or nil if t has no such field.
+func (e *ExcelDataExporter) fieldIndex(t reflect.Type, fieldName string) []int {
+	key := fieldCacheKey{Type: t, FieldName: fieldName}
+	e.cacheMu.RLock()
+	index, ok := e.fieldCache[key]
+	e.cacheMu.RUnlock()
+	if ok {
+		return index
+	}
+	if f, found := t.FieldByName(fieldName); found {
+		index = f.Index
+	}
+	e.cacheMu.Lock()
+	e.fieldCache[key] = index
+	e.cacheMu.Unlock()
+	return index
+}
+
 // mergeColumns merges user-defined columns with detected fields from data.
 // It prioritizes user-defined columns, then appends remaining detected fields.
 func mergeColumns(data interface{}, userConfigs []ColumnConfig) []ColumnConfig {
@@ -1144,6 +1327,13 @@ func mergeColumns(data interface{}, userConfigs []ColumnConfig) []ColumnConfig {
 	return finalCols
 }
 
+// DetectFields returns the field names detected in data, as used for columns without configuration: the
+// exported fields of a struct (or of the first item of a slice of structs), or the union of the keys of the
+// first 50 maps of a slice of maps.
+func DetectFields(data interface{}) []string {
+	return getFields(data)
+}
+
 func getFields(data interface{}) []string {
 	v := reflect.ValueOf(data)
 	if v.Kind() == reflect.Ptr {
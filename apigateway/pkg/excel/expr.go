@@ -0,0 +1,1203 @@
+package excel
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Column expressions (ColumnConfig.Expr) compute derived values in Go at render time, e.g.
+//
+//	Price * 1.1
+//	FirstName + " " + LastName
+//	years_between(HireDate, now())
+//	if(Available, "Yes", "No")
+//
+// Identifiers refer to fields of the data item (dotted paths for nested fields, {Field Name} for map keys
+// that are not identifiers). Expressions are sandboxed: they can only read the item and call the functions
+// of exprFuncs. nil values propagate: arithmetic and most functions on nil return nil (an empty cell).
+
+// exprType is the static type of an expression.
+type exprType int
+
+const (
+	exprAny exprType = iota // Unknown until render time (map values, interfaces, fields of unbound data)
+	exprNumber
+	exprString
+	exprBool
+	exprTime
+	exprNil
+)
+
+func (t exprType) String() string {
+	switch t {
+	case exprNumber:
+		return "number"
+	case exprString:
+		return "string"
+	case exprBool:
+		return "bool"
+	case exprTime:
+		return "time"
+	case exprNil:
+		return "nil"
+	}
+	return "any"
+}
+
+// accepts reports whether a value of type v can be used where type t is expected.
+func (t exprType) accepts(v exprType) bool {
+	return t == exprAny || v == exprAny || v == exprNil || t == v || (t == exprTime && v == exprString)
+}
+
+// exprTypeOf returns the expression type of a Go type.
+func exprTypeOf(t reflect.Type) exprType {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return exprTime
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return exprNumber
+	case reflect.String:
+		return exprString
+	case reflect.Bool:
+		return exprBool
+	}
+	return exprAny
+}
+
+// compiledExpr is a parsed and type-checked expression, or the error that prevented it.
+type compiledExpr struct {
+	root exprNode
+	typ  exprType
+	err  error
+}
+
+// exprCacheKey identifies an expression compiled for one data item type.
+type exprCacheKey struct {
+	Expr string
+	Type reflect.Type
+}
+
+// compileExpr parses src and type-checks it against the data item type t.
+// With a nil t (data not bound yet) field types are unknown and only checked at render time.
+func compileExpr(src string, t reflect.Type) *compiledExpr {
+	root, err := parseExpr(src)
+	if err != nil {
+		return &compiledExpr{err: fmt.Errorf("expr %q: %w", src, err)}
+	}
+	typ, err := root.check(t)
+	if err != nil {
+		return &compiledExpr{err: fmt.Errorf("expr %q: %w", src, err)}
+	}
+	return &compiledExpr{root: root, typ: typ}
+}
+
+// cellValue returns the formatted value of a column for one data item: the result of the
+// column's expression, or the value of its field. Expression errors become "Error: ..." cells.
+// Values of redacted columns are redacted instead of formatted.
+func (e *ExcelDataExporter) cellValue(col ColumnConfig, item reflect.Value) interface{} {
+	action := e.redaction.Action(col.Sensitivity)
+	val := e.extractValue(item, col.FieldName)
+	if col.Expr != "" {
+		var err error
+		if val, err = e.evalColumnExpr(col, item); err != nil {
+			if action != RedactFull {
+				// Errors may quote the sensitive value
+				return fmt.Sprintf("Error: column %s: expr %q failed", col.FieldName, col.Expr)
+			}
+			return fmt.Sprintf("Error: %v", err)
+		}
+	}
+	if action != RedactFull {
+		return e.redactValue(action, val)
+	}
+	return e.formatValue(col, val)
+}
+
+// evalColumnExpr evaluates the expression of a column for one data item. Expressions are compiled
+// once per item type.
+func (e *ExcelDataExporter) evalColumnExpr(col ColumnConfig, item reflect.Value) (interface{}, error) {
+	for item.Kind() == reflect.Ptr || item.Kind() == reflect.Interface {
+		if item.IsNil() {
+			return nil, nil
+		}
+		item = item.Elem()
+	}
+	if !item.IsValid() {
+		return nil, nil
+	}
+
+	key := exprCacheKey{Expr: col.Expr, Type: item.Type()}
+	e.cacheMu.RLock()
+	ce, ok := e.compiledExprs[key]
+	e.cacheMu.RUnlock()
+	if !ok {
+		ce = compileExpr(col.Expr, item.Type())
+		e.cacheMu.Lock()
+		if e.compiledExprs == nil {
+			e.compiledExprs = make(map[exprCacheKey]*compiledExpr)
+		}
+		e.compiledExprs[key] = ce
+		e.cacheMu.Unlock()
+	}
+	if ce.err != nil {
+		return nil, fmt.Errorf("column %s: %w", col.FieldName, ce.err)
+	}
+	val, err := ce.root.eval(&exprContext{e: e, item: item})
+	if err != nil {
+		return nil, fmt.Errorf("column %s: expr %q: %w", col.FieldName, col.Expr, err)
+	}
+	return val, nil
+}
+
+// ValidateExpressions parses and type-checks all column expressions, against the field types of the bound
+// data where available. It returns an error listing every invalid expression, or nil.
+func (e *ExcelDataExporter) ValidateExpressions() error {
+	var problems []string
+	for _, sb := range e.sheets {
+		for _, sec := range sb.sections {
+			data := sec.Data
+			if bound, ok := e.data[sec.ID]; ok && sec.ID != "" {
+				data = bound
+			}
+			itemType := dataItemType(data)
+			for _, col := range sec.Columns {
+				if col.Expr == "" {
+					continue
+				}
+				if ce := compileExpr(col.Expr, itemType); ce.err != nil {
+					problems = append(problems, fmt.Sprintf("sheet %s, section %s, column %s: %v", sb.name, sec.ID, col.FieldName, ce.err))
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid expressions:\n%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// dataItemType returns the element type of section data, or nil if it is unknown.
+func dataItemType(data interface{}) reflect.Type {
+	if data == nil {
+		return nil
+	}
+	t := reflect.TypeOf(data)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct && t.Kind() != reflect.Map {
+		return nil
+	}
+	return t
+}
+
+// validateTemplateExpressions parses and type-checks the expressions of a YAML template (field types unknown).
+func validateTemplateExpressions(tmpl *ReportTemplate) error {
+	for _, sheet := range tmpl.Sheets {
+		for _, sec := range sheet.Sections {
+			for _, col := range sec.Columns {
+				if col.Expr == "" {
+					continue
+				}
+				if ce := compileExpr(col.Expr, nil); ce.err != nil {
+					return fmt.Errorf("sheet %s, section %s, column %s: %w", sheet.Name, sec.ID, col.FieldName, ce.err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// Lexer
+// =============================================================================
+
+type exprTokenKind int
+
+const (
+	tokEOF exprTokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokField // {Field Name}
+	tokOp
+)
+
+type exprToken struct {
+	kind exprTokenKind
+	text string
+	pos  int
+}
+
+func (t exprToken) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	case tokField:
+		return "{" + t.text + "}"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// lexExpr splits an expression into tokens.
+func lexExpr(src string) ([]exprToken, error) {
+	var toks []exprToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			toks = append(toks, exprToken{tokNumber, src[start:i], start})
+		case c == '"' || c == '\'':
+			start := i
+			var sb strings.Builder
+			for i++; ; i++ {
+				if i >= len(src) {
+					return nil, fmt.Errorf("unterminated string at %d", start)
+				}
+				if src[i] == c {
+					i++
+					break
+				}
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+					switch src[i] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					default:
+						sb.WriteByte(src[i])
+					}
+					continue
+				}
+				sb.WriteByte(src[i])
+			}
+			toks = append(toks, exprToken{tokString, sb.String(), start})
+		case c == '{':
+			end := strings.IndexByte(src[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated field reference at %d", i)
+			}
+			toks = append(toks, exprToken{tokField, strings.TrimSpace(src[i+1 : i+end]), i})
+			i += end + 1
+		case c == '_' || c < utf8.RuneSelf && unicode.IsLetter(rune(c)):
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '.' || src[i] < utf8.RuneSelf && (unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i])))) {
+				i++
+			}
+			toks = append(toks, exprToken{tokIdent, src[start:i], start})
+		default:
+			if i+1 < len(src) {
+				switch op := src[i : i+2]; op {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					toks = append(toks, exprToken{tokOp, op, i})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("+-*/%<>!(),", rune(c)) {
+				r, _ := utf8.DecodeRuneInString(src[i:])
+				return nil, fmt.Errorf("unexpected character %q at %d", r, i)
+			}
+			toks = append(toks, exprToken{tokOp, string(c), i})
+			i++
+		}
+	}
+	return append(toks, exprToken{kind: tokEOF, pos: len(src)}), nil
+}
+
+// =============================================================================
+// Parser
+// =============================================================================
+
+// binaryPrecedence is the precedence of the binary operators (higher binds tighter).
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+type exprParser struct {
+	toks []exprToken
+	pos  int
+}
+
+// parseExpr parses an expression into its syntax tree.
+func parseExpr(src string) (exprNode, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, fmt.Errorf("empty expression")
+	}
+	toks, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks}
+	node, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s at %d", tok, tok.pos)
+	}
+	return node, nil
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.toks[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	tok := p.toks[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) isOp(op string) bool {
+	tok := p.peek()
+	return tok.kind == tokOp && tok.text == op
+}
+
+func (p *exprParser) expectOp(op string) error {
+	if tok := p.next(); tok.kind != tokOp || tok.text != op {
+		return fmt.Errorf("expected %q, got %s at %d", op, tok, tok.pos)
+	}
+	return nil
+}
+
+// parseBinary parses binary operations of at least precedence minPrec (precedence climbing).
+func (p *exprParser) parseBinary(minPrec int) (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		prec, ok := binaryPrecedence[tok.text]
+		if tok.kind != tokOp || !ok || prec < minPrec {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseBinary(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: tok.text, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.isOp("-") || p.isOp("!") {
+		op := p.next().text
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s at %d", tok.text, tok.pos)
+		}
+		return &literalNode{val: f}, nil
+	case tokString:
+		return &literalNode{val: tok.text}, nil
+	case tokField:
+		return &fieldNode{path: []string{tok.text}}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true", "false":
+			return &literalNode{val: tok.text == "true"}, nil
+		case "nil", "null":
+			return &literalNode{}, nil
+		}
+		if p.isOp("(") {
+			return p.parseCall(tok)
+		}
+		return &fieldNode{path: strings.Split(tok.text, ".")}, nil
+	case tokOp:
+		if tok.text == "(" {
+			node, err := p.parseBinary(1)
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expectOp(")")
+		}
+	}
+	return nil, fmt.Errorf("unexpected %s at %d", tok, tok.pos)
+}
+
+func (p *exprParser) parseCall(name exprToken) (exprNode, error) {
+	fn, ok := exprFuncs[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s at %d", name.text, name.pos)
+	}
+	p.next() // (
+	var args []exprNode
+	for !p.isOp(")") {
+		if len(args) > 0 {
+			if err := p.expectOp(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseBinary(1)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next() // )
+
+	if len(args) < fn.minArgs || fn.maxArgs >= 0 && len(args) > fn.maxArgs {
+		return nil, fmt.Errorf("%s takes %s, got %d", name.text, fn.arity(), len(args))
+	}
+	return &callNode{name: name.text, fn: fn, args: args}, nil
+}
+
+// =============================================================================
+// Syntax tree
+// =============================================================================
+
+// exprNode is a node of a parsed expression. check returns its static type against the data item
+// type (nil: unknown), eval evaluates it for one item.
+type exprNode interface {
+	check(itemType reflect.Type) (exprType, error)
+	eval(c *exprContext) (interface{}, error)
+}
+
+// exprContext is the evaluation context of one data item.
+type exprContext struct {
+	e    *ExcelDataExporter
+	item reflect.Value
+}
+
+type literalNode struct {
+	val interface{}
+}
+
+func (n *literalNode) check(reflect.Type) (exprType, error) {
+	switch n.val.(type) {
+	case float64:
+		return exprNumber, nil
+	case string:
+		return exprString, nil
+	case bool:
+		return exprBool, nil
+	}
+	return exprNil, nil
+}
+
+func (n *literalNode) eval(*exprContext) (interface{}, error) {
+	return n.val, nil
+}
+
+type fieldNode struct {
+	path []string
+}
+
+func (n *fieldNode) check(t reflect.Type) (exprType, error) {
+	if t == nil {
+		return exprAny, nil
+	}
+	for i, name := range n.path {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch {
+		case t.Kind() == reflect.Map:
+			t = t.Elem()
+		case t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{}):
+			f, ok := t.FieldByName(name)
+			if !ok {
+				return exprAny, fmt.Errorf("unknown field %s", strings.Join(n.path, "."))
+			}
+			if f.PkgPath != "" {
+				return exprAny, fmt.Errorf("unexported field %s", strings.Join(n.path, "."))
+			}
+			t = f.Type
+		case t.Kind() == reflect.Interface:
+			return exprAny, nil
+		default:
+			return exprAny, fmt.Errorf("%s has no field %s", strings.Join(n.path[:i], "."), name)
+		}
+	}
+	return exprTypeOf(t), nil
+}
+
+func (n *fieldNode) eval(c *exprContext) (interface{}, error) {
+	item := c.item
+	var val interface{}
+	for i, name := range n.path {
+		for item.Kind() == reflect.Ptr || item.Kind() == reflect.Interface {
+			if item.IsNil() {
+				return nil, nil
+			}
+			item = item.Elem()
+		}
+		switch item.Kind() {
+		case reflect.Map:
+			if item.Type().Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("field %s: map keys are not strings", name)
+			}
+			v := item.MapIndex(reflect.ValueOf(name).Convert(item.Type().Key()))
+			if !v.IsValid() {
+				return nil, nil
+			}
+			val = v.Interface()
+		case reflect.Struct:
+			if _, ok := item.Type().FieldByName(name); !ok {
+				return nil, fmt.Errorf("unknown field %s", strings.Join(n.path, "."))
+			}
+			val = c.e.extractValue(item, name)
+		default:
+			return nil, fmt.Errorf("%s has no field %s", strings.Join(n.path[:i], "."), name)
+		}
+		item = reflect.ValueOf(val)
+	}
+	return normalizeExprValue(val), nil
+}
+
+// exprFields returns the field paths an expression reads, e.g. "Salary" and "Address.City". Expressions that
+// do not parse read no fields.
+func exprFields(src string) []string {
+	root, err := parseExpr(src)
+	if err != nil {
+		return nil
+	}
+	var fields []string
+	var walk func(n exprNode)
+	walk = func(n exprNode) {
+		switch n := n.(type) {
+		case *fieldNode:
+			fields = append(fields, strings.Join(n.path, "."))
+		case *unaryNode:
+			walk(n.x)
+		case *binaryNode:
+			walk(n.left)
+			walk(n.right)
+		case *callNode:
+			for _, arg := range n.args {
+				walk(arg)
+			}
+		}
+	}
+	walk(root)
+	return fields
+}
+
+// normalizeExprValue converts a field value to an expression value: float64, string, bool, time.Time or nil.
+// Other values are kept as they are.
+func normalizeExprValue(v interface{}) interface{} {
+	switch v.(type) {
+	case nil, float64, string, bool, time.Time:
+		return v
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return normalizeExprValue(rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	}
+	return v
+}
+
+type unaryNode struct {
+	op string
+	x  exprNode
+}
+
+func (n *unaryNode) check(t reflect.Type) (exprType, error) {
+	xt, err := n.x.check(t)
+	if err != nil {
+		return exprAny, err
+	}
+	want := exprNumber
+	if n.op == "!" {
+		want = exprBool
+	}
+	if !want.accepts(xt) {
+		return exprAny, fmt.Errorf("operator %s needs a %s, got %s", n.op, want, xt)
+	}
+	return want, nil
+}
+
+func (n *unaryNode) eval(c *exprContext) (interface{}, error) {
+	v, err := n.x.eval(c)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		b, err := truthy(v)
+		return !b, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	f, err := numberValue(v)
+	return -f, err
+}
+
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *binaryNode) check(t reflect.Type) (exprType, error) {
+	lt, err := n.left.check(t)
+	if err != nil {
+		return exprAny, err
+	}
+	rt, err := n.right.check(t)
+	if err != nil {
+		return exprAny, err
+	}
+	mismatch := fmt.Errorf("operator %s cannot be applied to %s and %s", n.op, lt, rt)
+
+	switch n.op {
+	case "&&", "||":
+		if !exprBool.accepts(lt) || !exprBool.accepts(rt) {
+			return exprAny, mismatch
+		}
+		return exprBool, nil
+	case "==", "!=":
+		if !lt.accepts(rt) && !rt.accepts(lt) {
+			return exprAny, mismatch
+		}
+		return exprBool, nil
+	case "<", "<=", ">", ">=":
+		if lt == exprBool || rt == exprBool || !lt.accepts(rt) && !rt.accepts(lt) {
+			return exprAny, mismatch
+		}
+		return exprBool, nil
+	case "+":
+		if lt == exprString || rt == exprString {
+			return exprString, nil
+		}
+		if lt == exprAny || rt == exprAny {
+			return exprAny, nil
+		}
+	}
+	if !exprNumber.accepts(lt) || !exprNumber.accepts(rt) {
+		return exprAny, mismatch
+	}
+	return exprNumber, nil
+}
+
+func (n *binaryNode) eval(c *exprContext) (interface{}, error) {
+	l, err := n.left.eval(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// && and || evaluate their right operand only when needed
+	if n.op == "&&" || n.op == "||" {
+		lb, err := truthy(l)
+		if err != nil || lb == (n.op == "||") {
+			return lb, err
+		}
+		r, err := n.right.eval(c)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(r)
+	}
+
+	r, err := n.right.eval(c)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return exprEqual(l, r), nil
+	case "!=":
+		return !exprEqual(l, r), nil
+	case "<", "<=", ">", ">=":
+		if l == nil || r == nil {
+			return false, nil
+		}
+		cmp, err := exprCompare(l, r)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		}
+		return cmp >= 0, nil
+	}
+
+	_, lString := l.(string)
+	_, rString := r.(string)
+	if n.op == "+" && (lString || rString) {
+		return c.text(l) + c.text(r), nil
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+	a, err := numberValue(l)
+	if err != nil {
+		return nil, err
+	}
+	b, err := numberValue(r)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	}
+	if b == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	if n.op == "%" {
+		return math.Mod(a, b), nil
+	}
+	return a / b, nil
+}
+
+type callNode struct {
+	name string
+	fn   *exprFunc
+	args []exprNode
+}
+
+func (n *callNode) check(t reflect.Type) (exprType, error) {
+	types := make([]exprType, len(n.args))
+	for i, arg := range n.args {
+		at, err := arg.check(t)
+		if err != nil {
+			return exprAny, err
+		}
+		types[i] = at
+	}
+	result, err := n.fn.check(types)
+	if err != nil {
+		return exprAny, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return result, nil
+}
+
+func (n *callNode) eval(c *exprContext) (interface{}, error) {
+	// if evaluates only the chosen branch
+	if n.name == "if" {
+		cond, err := n.args[0].eval(c)
+		if err != nil {
+			return nil, err
+		}
+		b, err := truthy(cond)
+		if err != nil {
+			return nil, fmt.Errorf("if: %w", err)
+		}
+		if b {
+			return n.args[1].eval(c)
+		}
+		return n.args[2].eval(c)
+	}
+
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(c)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil && !n.fn.nilArgs {
+			return nil, nil
+		}
+		args[i] = v
+	}
+	v, err := n.fn.eval(c, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+// =============================================================================
+// Values
+// =============================================================================
+
+// truthy returns the value of a condition. nil is false.
+func truthy(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return b, nil
+	}
+	return false, fmt.Errorf("expected a bool, got %s", describeValue(v))
+}
+
+func numberValue(v interface{}) (float64, error) {
+	if f, ok := v.(float64); ok {
+		return f, nil
+	}
+	return 0, fmt.Errorf("expected a number, got %s", describeValue(v))
+}
+
+// timeValue returns a time value; strings are parsed as RFC 3339 timestamps or dates (2006-01-02).
+func timeValue(v interface{}) (time.Time, error) {
+	switch tv := v.(type) {
+	case time.Time:
+		return tv, nil
+	case string:
+		for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, tv); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("expected a time, got %s", describeValue(v))
+}
+
+func describeValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return "string " + strconv.Quote(s)
+	}
+	return fmt.Sprintf("%T %v", v, v)
+}
+
+// text converts a value to text for string operations. Times use the date layouts of the export locale.
+func (c *exprContext) text(v interface{}) string {
+	switch tv := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return tv
+	case float64:
+		return strconv.FormatFloat(tv, 'f', -1, 64)
+	case time.Time:
+		return tv.Format(timeLayout(tv, c.e.formatContext()))
+	}
+	return fmt.Sprint(v)
+}
+
+func exprEqual(a, b interface{}) bool {
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ok && ta.Equal(tb)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// exprCompare orders two numbers, strings or times.
+func exprCompare(a, b interface{}) (int, error) {
+	switch av := a.(type) {
+	case float64:
+		if bv, ok := b.(float64); ok {
+			return compareOrdered(av < bv, av > bv), nil
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv), nil
+		}
+		if bv, ok := b.(time.Time); ok {
+			if at, err := timeValue(av); err == nil {
+				return compareOrdered(at.Before(bv), at.After(bv)), nil
+			}
+		}
+	case time.Time:
+		if bt, err := timeValue(b); err == nil {
+			return compareOrdered(av.Before(bt), av.After(bt)), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %s and %s", describeValue(a), describeValue(b))
+}
+
+func compareOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+// =============================================================================
+// Functions
+// =============================================================================
+
+// exprFunc is a function callable from expressions.
+type exprFunc struct {
+	minArgs, maxArgs int  // maxArgs < 0: variadic
+	nilArgs          bool // Called with nil arguments; other functions return nil for nil arguments
+	check            func(args []exprType) (exprType, error)
+	eval             func(c *exprContext, args []interface{}) (interface{}, error)
+}
+
+func (f *exprFunc) arity() string {
+	switch {
+	case f.maxArgs < 0:
+		return fmt.Sprintf("at least %d arguments", f.minArgs)
+	case f.minArgs == f.maxArgs:
+		return fmt.Sprintf("%d arguments", f.minArgs)
+	}
+	return fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+}
+
+// signature returns a type check for parameters of the given types (the last one repeats for variadic functions).
+func signature(result exprType, params ...exprType) func([]exprType) (exprType, error) {
+	return func(args []exprType) (exprType, error) {
+		for i, at := range args {
+			want := params[len(params)-1]
+			if i < len(params) {
+				want = params[i]
+			}
+			if !want.accepts(at) {
+				return exprAny, fmt.Errorf("argument %d must be a %s, got %s", i+1, want, at)
+			}
+		}
+		return result, nil
+	}
+}
+
+// commonType is the type of values that are one of types (ignoring nil), or exprAny.
+func commonType(types []exprType) exprType {
+	result := exprNil
+	for _, t := range types {
+		switch {
+		case t == exprNil:
+		case result == exprNil:
+			result = t
+		case result != t:
+			return exprAny
+		}
+	}
+	return result
+}
+
+func numberFunc(fn func(float64) float64) *exprFunc {
+	return &exprFunc{minArgs: 1, maxArgs: 1, check: signature(exprNumber, exprNumber),
+		eval: func(c *exprContext, args []interface{}) (interface{}, error) {
+			f, err := numberValue(args[0])
+			return fn(f), err
+		}}
+}
+
+func stringFunc(fn func(string) string) *exprFunc {
+	return &exprFunc{minArgs: 1, maxArgs: 1, check: signature(exprString, exprAny),
+		eval: func(c *exprContext, args []interface{}) (interface{}, error) {
+			return fn(c.text(args[0])), nil
+		}}
+}
+
+func timePartFunc(fn func(time.Time) int) *exprFunc {
+	return &exprFunc{minArgs: 1, maxArgs: 1, check: signature(exprNumber, exprTime),
+		eval: func(c *exprContext, args []interface{}) (interface{}, error) {
+			t, err := timeValue(args[0])
+			return float64(fn(t)), err
+		}}
+}
+
+func betweenFunc(fn func(a, b time.Time) int) *exprFunc {
+	return &exprFunc{minArgs: 2, maxArgs: 2, check: signature(exprNumber, exprTime, exprTime),
+		eval: func(c *exprContext, args []interface{}) (interface{}, error) {
+			a, err := timeValue(args[0])
+			if err != nil {
+				return nil, err
+			}
+			b, err := timeValue(args[1])
+			if err != nil {
+				return nil, err
+			}
+			return float64(fn(a, b)), nil
+		}}
+}
+
+func extremumFunc(better func(a, b float64) bool) *exprFunc {
+	return &exprFunc{minArgs: 1, maxArgs: -1, check: signature(exprNumber, exprNumber),
+		eval: func(c *exprContext, args []interface{}) (interface{}, error) {
+			var result float64
+			for i, arg := range args {
+				f, err := numberValue(arg)
+				if err != nil {
+					return nil, err
+				}
+				if i == 0 || better(f, result) {
+					result = f
+				}
+			}
+			return result, nil
+		}}
+}
+
+// monthsBetween returns the number of whole months from a to b (negative if b is before a).
+func monthsBetween(a, b time.Time) int {
+	if b.Before(a) {
+		return -monthsBetween(b, a)
+	}
+	months := (b.Year()-a.Year())*12 + int(b.Month()-a.Month())
+	if b.Day() < a.Day() {
+		months--
+	}
+	return months
+}
+
+// daysBetween returns the number of calendar days from a to b.
+func daysBetween(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	days := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC).Sub(time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)).Hours() / 24
+	return int(math.Round(days))
+}
+
+// exprFuncs are the functions callable from expressions.
+var exprFuncs = map[string]*exprFunc{
+	// if(cond, then, else) evaluates only the chosen branch (see callNode.eval)
+	"if": {minArgs: 3, maxArgs: 3, nilArgs: true,
+		check: func(args []exprType) (exprType, error) {
+			if !exprBool.accepts(args[0]) {
+				return exprAny, fmt.Errorf("condition must be a bool, got %s", args[0])
+			}
+			return commonType(args[1:]), nil
+		}},
+	"coalesce": {minArgs: 1, maxArgs: -1, nilArgs: true,
+		check: func(args []exprType) (exprType, error) { return commonType(args), nil },
+		eval: func(c *exprContext, args []interface{}) (interface{}, error) {
+			for _, arg := range args {
+				if arg != nil {
+					return arg, nil
+				}
+			}
+			return nil, nil
+		}},
+	"concat": {minArgs: 1, maxArgs: -1, nilArgs: true, check: signature(exprString, exprAny),
+		eval: func(c *exprContext, args []interface{}) (interface{}, error) {
+			var sb strings.Builder
+			for _, arg := range args {
+				sb.WriteString(c.text(arg))
+			}
+			return sb.String(), nil
+		}},
+	"string": stringFunc(func(s string) string { return s }),
+	"number": {minArgs: 1, maxArgs: 1, check: signature(exprNumber, exprAny),
+		eval: func(c *exprContext, args []interface{}) (interface{}, error) {
+			if f, ok := toFloat(args[0]); ok {
+				return f, nil
+			}
+			return nil, fmt.Errorf("cannot convert %s to a number", describeValue(args[0]))
+		}},
+
+	"upper": stringFunc(strings.ToUpper),
+	"lower": stringFunc(strings.ToLower),
+	"trim":  stringFunc(strings.TrimSpace),
+	"len": {minArgs: 1, maxArgs: 1, check: signature(exprNumber, exprString),
+		eval: func(c *exprContext, args []interface{}) (interface{}, error) {
+			return float64(utf8.RuneCountInString(c.text(args[0]))), nil
+		}},
+	"contains": {minArgs: 2, maxArgs: 2, check: signature(exprBool, exprString, exprString),
+		eval: func(c *exprContext, args []interface{}) (interface{}, error) {
+			return strings.Contains(c.text(args[0]), c.text(args[1])), nil
+		}},
+
+	"round": {minArgs: 1, maxArgs: 2, check: signature(exprNumber, exprNumber, exprNumber),
+		eval: func(c *exprContext, args []interface{}) (interface{}, error) {
+			f, err := numberValue(args[0])
+			if err != nil {
+				return nil, err
+			}
+			scale := 1.0
+			if len(args) == 2 {
+				digits, err := numberValue(args[1])
+				if err != nil {
+					return nil, err
+				}
+				scale = math.Pow(10, math.Trunc(digits))
+			}
+			return math.Round(f*scale) / scale, nil
+		}},
+	"floor": numberFunc(math.Floor),
+	"ceil":  numberFunc(math.Ceil),
+	"abs":   numberFunc(math.Abs),
+	"min":   extremumFunc(func(a, b float64) bool { return a < b }),
+	"max":   extremumFunc(func(a, b float64) bool { return a > b }),
+
+	"now": {check: signature(exprTime),
+		eval: func(c *exprContext, args []interface{}) (interface{}, error) { return time.Now(), nil }},
+	"today": {check: signature(exprTime),
+		eval: func(c *exprContext, args []interface{}) (interface{}, error) {
+			y, m, d := time.Now().Date()
+			return time.Date(y, m, d, 0, 0, 0, 0, time.Local), nil
+		}},
+	"year":           timePartFunc(func(t time.Time) int { return t.Year() }),
+	"month":          timePartFunc(func(t time.Time) int { return int(t.Month()) }),
+	"day":            timePartFunc(func(t time.Time) int { return t.Day() }),
+	"years_between":  betweenFunc(func(a, b time.Time) int { return monthsBetween(a, b) / 12 }),
+	"months_between": betweenFunc(monthsBetween),
+	"days_between":   betweenFunc(daysBetween),
+	"format_date": {minArgs: 2, maxArgs: 2, check: signature(exprString, exprTime, exprString),
+		eval: func(c *exprContext, args []interface{}) (interface{}, error) {
+			t, err := timeValue(args[0])
+			return t.Format(c.text(args[1])), err
+		}},
+}
+
+// ExprFunctions returns the names of the functions available in column expressions.
+func ExprFunctions() []string {
+	names := make([]string, 0, len(exprFuncs))
+	for name := range exprFuncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
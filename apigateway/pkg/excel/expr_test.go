@@ -0,0 +1,186 @@
+package excel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type exprEmployee struct {
+	FirstName string
+	LastName  string
+	Price     float64
+	Quantity  int
+	Available bool
+	HireDate  time.Time
+	Manager   *exprEmployee
+	Bonus     *float64
+}
+
+func TestEvalColumnExpr(t *testing.T) {
+	e := NewExcelDataExporter()
+	hired := time.Date(2019, 6, 15, 0, 0, 0, 0, time.UTC)
+	emp := exprEmployee{
+		FirstName: "Ada", LastName: "Lovelace", Price: 10, Quantity: 3, Available: true, HireDate: hired,
+		Manager: &exprEmployee{FirstName: "Charles"},
+	}
+	row := map[string]interface{}{"Unit Price": 2.5, "Qty": 4}
+
+	tests := []struct {
+		expr string
+		item interface{}
+		want interface{}
+	}{
+		{"Price * 1.1 + 1", emp, 12.0},
+		{"Price * (Quantity - 1) % 7", emp, 6.0},
+		{`FirstName + " " + LastName`, emp, "Ada Lovelace"},
+		{`"Qty: " + Quantity`, emp, "Qty: 3"},
+		{`if(Available, "Yes", "No")`, emp, "Yes"},
+		{`if(!Available || Price > 100, "check", "ok")`, emp, "ok"},
+		{"years_between(HireDate, '2024-06-14')", emp, 4.0},
+		{"months_between(HireDate, '2019-08-15')", emp, 2.0},
+		{"days_between('2024-03-01', HireDate)", emp, -1721.0},
+		{"year(HireDate) >= 2019 && month(HireDate) == 6", emp, true},
+		{"format_date(HireDate, 'Jan 2006')", emp, "Jun 2019"},
+		{"upper(Manager.FirstName)", emp, "CHARLES"},
+		{"Manager.Manager.FirstName", emp, nil},
+		{"Bonus * 2", emp, nil},
+		{"coalesce(Bonus, 0) + 1", emp, 1.0},
+		{"round(Price / 3, 2)", emp, 3.33},
+		{"max(Price, Quantity, 4) - min(1, 2)", emp, 9.0},
+		{"len(concat(FirstName, nil, '!'))", emp, 4.0},
+		{"{Unit Price} * Qty", row, 10.0},
+		{"Missing == nil", row, true},
+		{"number('42') + 1", row, 43.0},
+	}
+	for _, tt := range tests {
+		val, err := e.evalColumnExpr(ColumnConfig{FieldName: "Out", Expr: tt.expr}, reflect.ValueOf(tt.item))
+		if assert.NoError(t, err, tt.expr) {
+			if f, ok := tt.want.(float64); ok {
+				assert.InDelta(t, f, val, 1e-9, tt.expr)
+			} else {
+				assert.Equal(t, tt.want, val, tt.expr)
+			}
+		}
+	}
+
+	// Pointers to items are dereferenced; today() has no time of day
+	val, err := e.evalColumnExpr(ColumnConfig{Expr: "days_between(HireDate, today()) > 0"}, reflect.ValueOf(&emp))
+	assert.NoError(t, err)
+	assert.Equal(t, true, val)
+}
+
+func TestCompileExprErrors(t *testing.T) {
+	itemType := reflect.TypeOf(exprEmployee{})
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"Price *", "unexpected end of expression at 7"},
+		{"Price ** 2", `unexpected "*" at 7`},
+		{"(Price + 1", `expected ")"`},
+		{"'open", "unterminated string at 0"},
+		{"Price # 2", "unexpected character '#' at 6"},
+		{"price_with_tax(Price)", "unknown function price_with_tax at 0"},
+		{"if(Available, 1)", "if takes 3 arguments, got 2"},
+		{"Prise * 1.1", "unknown field Prise"},
+		{"Manager.Nickname", "unknown field Manager.Nickname"},
+		{"Price.Amount", "Price has no field Amount"},
+		{`"a" * 2`, "operator * cannot be applied to string and number"},
+		{"Available + 1", "operator + cannot be applied to bool and number"},
+		{"if(Price, 1, 2)", "if: condition must be a bool, got number"},
+		{"upper(Price) > 1", "operator > cannot be applied to string and number"},
+		{"years_between(HireDate, Price)", "years_between: argument 2 must be a time, got number"},
+		{"-FirstName", "operator - needs a number, got string"},
+	}
+	for _, tt := range tests {
+		ce := compileExpr(tt.expr, itemType)
+		if assert.Error(t, ce.err, tt.expr) {
+			assert.Contains(t, ce.err.Error(), tt.err, tt.expr)
+		}
+	}
+
+	// Field types are unknown until data is bound
+	assert.NoError(t, compileExpr("Prise * 1.1", nil).err)
+	assert.Error(t, compileExpr(`"a" * 2`, nil).err)
+}
+
+func TestColumnExprRuntimeErrors(t *testing.T) {
+	e := NewExcelDataExporter()
+	item := reflect.ValueOf(map[string]interface{}{"Qty": 0, "Name": "x"})
+
+	val := e.cellValue(ColumnConfig{FieldName: "Ratio", Expr: "10 / Qty"}, item)
+	assert.Equal(t, `Error: column Ratio: expr "10 / Qty": division by zero`, val)
+
+	val = e.cellValue(ColumnConfig{FieldName: "Double", Expr: "Name * 2"}, item)
+	assert.Equal(t, `Error: column Double: expr "Name * 2": expected a number, got string "x"`, val)
+}
+
+func TestColumnExprInYAML(t *testing.T) {
+	_, err := NewExcelDataExporterFromYamlConfig(`
+sheets:
+  - name: "Staff"
+    sections:
+      - id: "staff"
+        columns:
+          - field_name: "Tenure"
+            expr: "years_between(HireDate, now()"
+`)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `sheet Staff, section staff, column Tenure: expr "years_between(HireDate, now()"`)
+	}
+
+	e, err := NewExcelDataExporterFromYamlConfig(`
+sheets:
+  - name: "Staff"
+    sections:
+      - id: "staff"
+        show_header: true
+        columns:
+          - field_name: "FullName"
+            header: "Name"
+            expr: 'FirstName + " " + LastName'
+            formatter: "upper"
+          - field_name: "Total"
+            header: "Total"
+            expr: "Price * Quantity"
+            formatter: "currency(USD,2)"
+          - field_name: "Status"
+            header: "Status"
+            expr: 'if(Available, "In stock", "Sold out")'
+          - field_name: "Bonus"
+            header: "Bonus"
+            expr: "Bonus * Quantity"
+`)
+	assert.NoError(t, err)
+
+	staff := []exprEmployee{
+		{FirstName: "Ada", LastName: "Lovelace", Price: 1200.5, Quantity: 2, Available: true},
+		{FirstName: "Alan", LastName: "Turing", Price: 10, Quantity: 1},
+	}
+	e.BindSectionData("staff", staff)
+	assert.NoError(t, e.ValidateExpressions())
+
+	f, err := e.BuildExcel()
+	assert.NoError(t, err)
+	defer f.Close()
+	rows, err := f.GetRows("Staff")
+	assert.NoError(t, err)
+	// Unconfigured data fields follow the configured columns
+	assert.Equal(t, []string{"ADA LOVELACE", "$2,401.00", "In stock", ""}, rows[1][:4])
+	assert.Equal(t, []string{"ALAN TURING", "$10.00", "Sold out", ""}, rows[2][:4])
+
+	var csv strings.Builder
+	assert.NoError(t, e.ToCSV(&csv))
+	assert.Contains(t, csv.String(), "ADA LOVELACE,\"$2,401.00\",In stock")
+
+	// Type errors against the bound data are reported before the export
+	e.BindSectionData("staff", []struct{ FirstName, LastName string }{{"Grace", "Hopper"}})
+	err = e.ValidateExpressions()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `sheet Staff, section staff, column Total: expr "Price * Quantity": unknown field Price`)
+	}
+}
@@ -0,0 +1,585 @@
+package excel
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FormatterFactory builds a formatter from the arguments of a formatter spec,
+// e.g. currency(EUR,2) calls the "currency" factory with ["EUR", "2"].
+type FormatterFactory func(args ...string) (func(interface{}) interface{}, error)
+
+// currencySymbols maps ISO currency codes to the symbol written before the amount.
+// Other codes are written after the amount, e.g. "1,200.00 CHF".
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"CNY": "¥",
+	"INR": "₹",
+	"KRW": "₩",
+	"VND": "₫",
+}
+
+// formatContext carries the locale settings used by the built-in formatters (see SetLocale).
+type formatContext struct {
+	decimal        string
+	thousands      string
+	dateLayout     string
+	dateTimeLayout string
+	translate      func(string) string
+}
+
+var defaultFormatContext = formatContext{
+	decimal:        ".",
+	thousands:      ",",
+	dateLayout:     "2006-01-02",
+	dateTimeLayout: "2006-01-02 15:04:05",
+	translate:      func(s string) string { return s },
+}
+
+// builtinFormatter is a FormatterFactory that depends on the export locale.
+type builtinFormatter func(fc formatContext, args ...string) (func(interface{}) interface{}, error)
+
+// builtinFormatters are available to every exporter. Formatters registered with
+// RegisterFormatter or RegisterFormatterFactory take precedence.
+var builtinFormatters = map[string]builtinFormatter{
+	"currency":  currencyFormatter,
+	"percent":   percentFormatter,
+	"thousands": thousandsFormatter,
+	"date":      timeFormatter(false),
+	"datetime":  timeFormatter(true),
+	"bool":      boolFormatter,
+	"enum":      enumFormatter,
+	"truncate":  plainFormatter(truncateFormatter),
+	"upper":     plainFormatter(stringFormatter("upper", strings.ToUpper)),
+	"lower":     plainFormatter(stringFormatter("lower", strings.ToLower)),
+	"trim":      plainFormatter(stringFormatter("trim", strings.TrimSpace)),
+	"mask":      plainFormatter(maskFormatter),
+}
+
+// plainFormatter adapts a FormatterFactory that does not depend on the locale.
+func plainFormatter(f FormatterFactory) builtinFormatter {
+	return func(_ formatContext, args ...string) (func(interface{}) interface{}, error) {
+		return f(args...)
+	}
+}
+
+// RegisterFormatterFactory registers a parameterized formatter that YAML can reference with
+// arguments, e.g. `formatter: "stars(5)"`.
+func (e *ExcelDataExporter) RegisterFormatterFactory(name string, f FormatterFactory) *ExcelDataExporter {
+	if e.formatterFactories == nil {
+		e.formatterFactories = make(map[string]FormatterFactory)
+	}
+	e.formatterFactories[name] = f
+	e.resetFormatters()
+	return e
+}
+
+// resetFormatters drops the resolved formatter specs after a formatter or the locale changed.
+func (e *ExcelDataExporter) resetFormatters() {
+	e.cacheMu.Lock()
+	e.resolvedFormatters = nil
+	e.cacheMu.Unlock()
+}
+
+// formatValue applies the formatter of a column to a value: the Formatter func if set,
+// otherwise the FormatterName spec. Invalid specs leave the value unchanged (see ValidateFormatters).
+func (e *ExcelDataExporter) formatValue(col ColumnConfig, val interface{}) interface{} {
+	if col.Formatter != nil {
+		return col.Formatter(val)
+	}
+	if col.FormatterName == "" {
+		return val
+	}
+	if fn, err := e.resolveFormatter(col.FormatterName); err == nil {
+		return fn(val)
+	}
+	return val
+}
+
+// resolveFormatter returns the formatter for a spec such as "currency(EUR,2)" or "trim|upper|truncate(10)".
+// Resolved specs are cached.
+func (e *ExcelDataExporter) resolveFormatter(spec string) (func(interface{}) interface{}, error) {
+	e.cacheMu.RLock()
+	fn, ok := e.resolvedFormatters[spec]
+	e.cacheMu.RUnlock()
+	if ok {
+		return fn, nil
+	}
+
+	calls, err := parseFormatterSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	chain := make([]func(interface{}) interface{}, len(calls))
+	for i, call := range calls {
+		if chain[i], err = e.buildFormatter(call); err != nil {
+			return nil, err
+		}
+	}
+
+	fn = chain[0]
+	if len(chain) > 1 {
+		fn = func(v interface{}) interface{} {
+			for _, f := range chain {
+				v = f(v)
+			}
+			return v
+		}
+	}
+	e.cacheMu.Lock()
+	if e.resolvedFormatters == nil {
+		e.resolvedFormatters = make(map[string]func(interface{}) interface{})
+	}
+	e.resolvedFormatters[spec] = fn
+	e.cacheMu.Unlock()
+	return fn, nil
+}
+
+// buildFormatter builds one formatter of a chain.
+func (e *ExcelDataExporter) buildFormatter(call formatterCall) (func(interface{}) interface{}, error) {
+	if fn, ok := e.formatters[call.name]; ok {
+		if len(call.args) > 0 {
+			return nil, fmt.Errorf("formatter %s does not take arguments", call.name)
+		}
+		return fn, nil
+	}
+	var fn func(interface{}) interface{}
+	var err error
+	if factory, ok := e.formatterFactories[call.name]; ok {
+		fn, err = factory(call.args...)
+	} else if builtin, ok := builtinFormatters[call.name]; ok {
+		fn, err = builtin(e.formatContext(), call.args...)
+	} else {
+		return nil, fmt.Errorf("unknown formatter %q", call.name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("formatter %s: %w", call.name, err)
+	}
+	return fn, nil
+}
+
+// ValidateFormatters checks the formatter specs of all columns and reports unknown names and invalid arguments.
+func (e *ExcelDataExporter) ValidateFormatters() error {
+	var problems []string
+	check := func(sheet string, sections []*SectionConfig) {
+		for _, sec := range sections {
+			for _, col := range sec.Columns {
+				if col.Formatter != nil || col.FormatterName == "" {
+					continue
+				}
+				if _, err := e.resolveFormatter(col.FormatterName); err != nil {
+					problems = append(problems, fmt.Sprintf("sheet %s, section %s, column %s: %v", sheet, sec.ID, col.FieldName, err))
+				}
+			}
+		}
+	}
+
+	for _, sb := range e.sheets {
+		check(sb.name, sb.sections)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid formatters:\n%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// formatterCall is one element of a formatter chain: a name and its arguments (nil without parentheses).
+type formatterCall struct {
+	name string
+	args []string
+}
+
+// parseFormatterSpec parses "name", "name(arg, ...)" and chains joined with "|".
+// Arguments containing commas, parentheses or "|" can be quoted with ' or ".
+func parseFormatterSpec(spec string) ([]formatterCall, error) {
+	var calls []formatterCall
+	rest := strings.TrimSpace(spec)
+	for {
+		i := strings.IndexAny(rest, "(|")
+		if i < 0 {
+			i = len(rest)
+		}
+		call := formatterCall{name: strings.TrimSpace(rest[:i])}
+		if call.name == "" {
+			return nil, fmt.Errorf("invalid formatter %q: missing name", spec)
+		}
+		rest = rest[i:]
+
+		if strings.HasPrefix(rest, "(") {
+			args, n, err := parseFormatterArgs(rest[1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid formatter %q: %w", spec, err)
+			}
+			call.args = args
+			rest = strings.TrimSpace(rest[1+n:])
+		}
+		calls = append(calls, call)
+
+		if rest == "" {
+			return calls, nil
+		}
+		if !strings.HasPrefix(rest, "|") {
+			return nil, fmt.Errorf("invalid formatter %q: unexpected %q", spec, rest)
+		}
+		rest = strings.TrimSpace(rest[1:])
+	}
+}
+
+// parseFormatterArgs parses a comma-separated argument list up to the closing parenthesis
+// and returns the arguments and the number of bytes consumed.
+func parseFormatterArgs(s string) ([]string, int, error) {
+	args := []string{}
+	var cur strings.Builder
+	var quote byte
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				cur.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			if strings.TrimSpace(cur.String()) == "" {
+				cur.Reset()
+			}
+			quote, quoted = c, true
+		case c == ',' || c == ')':
+			arg := cur.String()
+			if !quoted {
+				arg = strings.TrimSpace(arg)
+			}
+			if c == ')' && len(args) == 0 && arg == "" && !quoted {
+				return args, i + 1, nil // No arguments: name()
+			}
+			args = append(args, arg)
+			cur.Reset()
+			quoted = false
+			if c == ')' {
+				return args, i + 1, nil
+			}
+		default:
+			if !(quoted && (c == ' ' || c == '\t')) {
+				cur.WriteByte(c)
+			}
+		}
+	}
+	return nil, 0, fmt.Errorf("missing closing parenthesis")
+}
+
+// argOr returns args[i], or def if it is missing or empty.
+func argOr(args []string, i int, def string) string {
+	if i < len(args) && args[i] != "" {
+		return args[i]
+	}
+	return def
+}
+
+// intArg parses args[i] as a non-negative integer, or returns def if it is missing.
+func intArg(args []string, i int, def int) (int, error) {
+	s := argOr(args, i, "")
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("argument %d: %q is not a non-negative integer", i+1, s)
+	}
+	return n, nil
+}
+
+// maxArgs reports an error if more than n arguments are given.
+func maxArgs(args []string, n int) error {
+	if len(args) > n {
+		return fmt.Errorf("takes at most %d arguments, got %d", n, len(args))
+	}
+	return nil
+}
+
+// toFloat converts numeric values (and numeric strings) to float64.
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+		return f, err == nil
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return 0, false
+		}
+		return toFloat(rv.Elem().Interface())
+	}
+	return 0, false
+}
+
+// groupThousands formats f with the given decimals and the separators of the locale.
+func groupThousands(f float64, decimals int, fc formatContext) string {
+	s := strconv.FormatFloat(math.Abs(f), 'f', decimals, 64)
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], fc.decimal+s[i+1:]
+	}
+	var b strings.Builder
+	if f < 0 && strings.Trim(s, "0.") != "" {
+		b.WriteByte('-')
+	}
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(fc.thousands)
+		}
+		b.WriteRune(c)
+	}
+	b.WriteString(frac)
+	return b.String()
+}
+
+// currencyFormatter: currency([code[, decimals]]), e.g. currency(EUR,2) -> "€1,200.50". Defaults to USD, 2.
+func currencyFormatter(fc formatContext, args ...string) (func(interface{}) interface{}, error) {
+	if err := maxArgs(args, 2); err != nil {
+		return nil, err
+	}
+	code := strings.ToUpper(argOr(args, 0, "USD"))
+	decimals, err := intArg(args, 1, 2)
+	if err != nil {
+		return nil, err
+	}
+	symbol, prefix := currencySymbols[code]
+	return func(v interface{}) interface{} {
+		f, ok := toFloat(v)
+		if !ok {
+			return v
+		}
+		amount := groupThousands(f, decimals, fc)
+		if !prefix {
+			return amount + " " + code
+		}
+		if strings.HasPrefix(amount, "-") {
+			return "-" + symbol + amount[1:]
+		}
+		return symbol + amount
+	}, nil
+}
+
+// percentFormatter: percent([decimals]) formats a ratio, e.g. 0.256 -> "25.6%" with percent(1). Defaults to 0.
+func percentFormatter(fc formatContext, args ...string) (func(interface{}) interface{}, error) {
+	if err := maxArgs(args, 1); err != nil {
+		return nil, err
+	}
+	decimals, err := intArg(args, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return func(v interface{}) interface{} {
+		f, ok := toFloat(v)
+		if !ok {
+			return v
+		}
+		return strings.Replace(strconv.FormatFloat(f*100, 'f', decimals, 64), ".", fc.decimal, 1) + "%"
+	}, nil
+}
+
+// thousandsFormatter: thousands([decimals]), e.g. 1234567 -> "1,234,567". Defaults to 0.
+func thousandsFormatter(fc formatContext, args ...string) (func(interface{}) interface{}, error) {
+	if err := maxArgs(args, 1); err != nil {
+		return nil, err
+	}
+	decimals, err := intArg(args, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return func(v interface{}) interface{} {
+		f, ok := toFloat(v)
+		if !ok {
+			return v
+		}
+		return groupThousands(f, decimals, fc)
+	}, nil
+}
+
+// timeFormatter returns a factory for date([layout]) and datetime([layout]) with a Go time layout,
+// defaulting to the layouts of the locale. time.Time values (and RFC 3339 strings) are formatted;
+// zero times become "".
+func timeFormatter(withTime bool) builtinFormatter {
+	return func(fc formatContext, args ...string) (func(interface{}) interface{}, error) {
+		if err := maxArgs(args, 1); err != nil {
+			return nil, err
+		}
+		layout := fc.dateLayout
+		if withTime {
+			layout = fc.dateTimeLayout
+		}
+		layout = argOr(args, 0, layout)
+		return func(v interface{}) interface{} {
+			var t time.Time
+			switch tv := v.(type) {
+			case time.Time:
+				t = tv
+			case *time.Time:
+				if tv == nil {
+					return ""
+				}
+				t = *tv
+			case string:
+				parsed, err := time.Parse(time.RFC3339, tv)
+				if err != nil {
+					return v
+				}
+				t = parsed
+			default:
+				return v
+			}
+			if t.IsZero() {
+				return ""
+			}
+			return t.Format(layout)
+		}, nil
+	}
+}
+
+// boolFormatter: bool([true label, false label]), e.g. bool(Active,Inactive). Defaults to Yes, No.
+// Labels are message keys of the locale.
+func boolFormatter(fc formatContext, args ...string) (func(interface{}) interface{}, error) {
+	if len(args) != 0 && len(args) != 2 {
+		return nil, fmt.Errorf("takes 0 or 2 arguments, got %d", len(args))
+	}
+	yes, no := fc.translate(argOr(args, 0, "Yes")), fc.translate(argOr(args, 1, "No"))
+	return func(v interface{}) interface{} {
+		switch b := v.(type) {
+		case bool:
+			if b {
+				return yes
+			}
+			return no
+		case *bool:
+			if b == nil {
+				return ""
+			}
+			if *b {
+				return yes
+			}
+			return no
+		}
+		return v
+	}, nil
+}
+
+// enumFormatter: enum(key=label, ...) maps values to labels, e.g. enum(M=Male,F=Female).
+// The key "*" is the label for unmapped values; without it they are unchanged. Labels are message keys of the locale.
+func enumFormatter(fc formatContext, args ...string) (func(interface{}) interface{}, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("requires at least one key=label argument")
+	}
+	labels := make(map[string]string, len(args))
+	for _, arg := range args {
+		i := strings.IndexByte(arg, '=')
+		if i <= 0 {
+			return nil, fmt.Errorf("argument %q is not key=label", arg)
+		}
+		labels[strings.TrimSpace(arg[:i])] = fc.translate(strings.TrimSpace(arg[i+1:]))
+	}
+	fallback, hasFallback := labels["*"]
+	return func(v interface{}) interface{} {
+		if label, ok := labels[fmt.Sprint(v)]; ok {
+			return label
+		}
+		if hasFallback {
+			return fallback
+		}
+		return v
+	}, nil
+}
+
+// truncateFormatter: truncate(length[, suffix]) shortens strings to length runes including the suffix ("..." by default).
+func truncateFormatter(args ...string) (func(interface{}) interface{}, error) {
+	if err := maxArgs(args, 2); err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("requires a length")
+	}
+	length, err := intArg(args, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	suffix := "..."
+	if len(args) > 1 {
+		suffix = args[1]
+	}
+	suffixLen := utf8.RuneCountInString(suffix)
+	if length <= suffixLen {
+		return nil, fmt.Errorf("length %d must be greater than the suffix length %d", length, suffixLen)
+	}
+	return func(v interface{}) interface{} {
+		s, ok := v.(string)
+		if !ok || utf8.RuneCountInString(s) <= length {
+			return v
+		}
+		return string([]rune(s)[:length-suffixLen]) + suffix
+	}, nil
+}
+
+// stringFormatter returns a factory for formatters without arguments applying fn to strings.
+func stringFormatter(name string, fn func(string) string) FormatterFactory {
+	return func(args ...string) (func(interface{}) interface{}, error) {
+		if len(args) > 0 {
+			return nil, fmt.Errorf("%s does not take arguments", name)
+		}
+		return func(v interface{}) interface{} {
+			if s, ok := v.(string); ok {
+				return fn(s)
+			}
+			return v
+		}, nil
+	}
+}
+
+// maskFormatter: mask([visible[, char]]) masks all but the last visible runes, e.g. mask(4) -> "*******1234".
+// Values are converted to strings first. Defaults to 4 and "*".
+func maskFormatter(args ...string) (func(interface{}) interface{}, error) {
+	if err := maxArgs(args, 2); err != nil {
+		return nil, err
+	}
+	visible, err := intArg(args, 0, 4)
+	if err != nil {
+		return nil, err
+	}
+	char := argOr(args, 1, "*")
+	return func(v interface{}) interface{} {
+		if v == nil {
+			return v
+		}
+		r := []rune(fmt.Sprint(v))
+		if len(r) <= visible {
+			return string(r)
+		}
+		return strings.Repeat(char, len(r)-visible) + string(r[len(r)-visible:])
+	}, nil
+}
+
+// BuiltinFormatters returns the names of the built-in formatters.
+func BuiltinFormatters() []string {
+	names := make([]string, 0, len(builtinFormatters))
+	for name := range builtinFormatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
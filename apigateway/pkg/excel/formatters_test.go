@@ -0,0 +1,139 @@
+package excel
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuiltinFormatters(t *testing.T) {
+	e := NewExcelDataExporter()
+	date := time.Date(2024, 3, 9, 14, 5, 0, 0, time.UTC)
+	active := true
+
+	tests := []struct {
+		spec string
+		in   interface{}
+		want interface{}
+	}{
+		{"currency", 1200.5, "$1,200.50"},
+		{"currency(EUR,2)", 1234567.891, "€1,234,567.89"},
+		{"currency(eur, 0)", -1500, "-€1,500"},
+		{"currency(CHF)", 12.5, "12.50 CHF"},
+		{"currency", "n/a", "n/a"},
+		{"percent(1)", 0.256, "25.6%"},
+		{"percent", 1, "100%"},
+		{"thousands", int64(1234567), "1,234,567"},
+		{"thousands(2)", "999.5", "999.50"},
+		{"date", date, "2024-03-09"},
+		{"date('Jan 2, 2006')", &date, "Mar 9, 2024"},
+		{"datetime", "2024-03-09T14:05:00Z", "2024-03-09 14:05:00"},
+		{"date", time.Time{}, ""},
+		{"bool", true, "Yes"},
+		{"bool(Active,Inactive)", &active, "Active"},
+		{"enum(M=Male, F=Female)", "F", "Female"},
+		{"enum(M=Male)", "X", "X"},
+		{"enum(1=One, *=Other)", 7, "Other"},
+		{"truncate(8)", "Employee Name", "Emplo..."},
+		{"truncate(5,'')", "Employee", "Emplo"},
+		{"upper", "abc", "ABC"},
+		{"lower", "ABC", "abc"},
+		{"mask", "4111111111111111", "************1111"},
+		{"mask(2,#)", 12345, "###45"},
+		{"trim|upper|truncate(4,'.')", "  hello ", "HEL."},
+	}
+	for _, tt := range tests {
+		fn, err := e.resolveFormatter(tt.spec)
+		if assert.NoError(t, err, tt.spec) {
+			assert.Equal(t, tt.want, fn(tt.in), tt.spec)
+		}
+	}
+}
+
+func TestFormatterSpecErrors(t *testing.T) {
+	e := NewExcelDataExporter()
+	for _, spec := range []string{
+		"money",
+		"currency(EUR,two)",
+		"currency(EUR,2",
+		"percent(1,2)",
+		"upper(1)",
+		"truncate",
+		"enum(Male)",
+		"upper|",
+		"upper lower",
+	} {
+		_, err := e.resolveFormatter(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestRegisteredFormattersTakePrecedence(t *testing.T) {
+	e := NewExcelDataExporter()
+	e.RegisterFormatter("currency", func(v interface{}) interface{} { return "custom" })
+	e.RegisterFormatterFactory("stars", func(args ...string) (func(interface{}) interface{}, error) {
+		n, err := intArg(args, 0, 3)
+		return func(interface{}) interface{} { return strings.Repeat("*", n) }, err
+	})
+
+	fn, err := e.resolveFormatter("currency")
+	assert.NoError(t, err)
+	assert.Equal(t, "custom", fn(1.0))
+
+	fn, err = e.resolveFormatter("stars(5)|lower")
+	assert.NoError(t, err)
+	assert.Equal(t, "*****", fn(nil))
+
+	_, err = e.resolveFormatter("currency(EUR)")
+	assert.Error(t, err) // Registered funcs take no arguments
+}
+
+func TestFormatterSpecInYAML(t *testing.T) {
+	yamlConfig := `
+sheets:
+  - name: "Salaries"
+    sections:
+      - id: "salaries"
+        show_header: true
+        columns:
+          - field_name: "Name"
+            header: "Name"
+            formatter: "trim|upper"
+          - field_name: "Salary"
+            header: "Salary"
+            formatter: "currency(EUR,2)"
+          - field_name: "Gender"
+            header: "Gender"
+            formatter: "genders"
+`
+	e, err := NewExcelDataExporterFromYamlConfig(yamlConfig)
+	assert.NoError(t, err)
+
+	err = e.ValidateFormatters()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `section salaries, column Gender: unknown formatter "genders"`)
+	}
+
+	e.RegisterFormatterFactory("genders", func(args ...string) (func(interface{}) interface{}, error) {
+		return enumFormatter(defaultFormatContext, args...)
+	})
+	assert.Error(t, e.ValidateFormatters()) // enum requires arguments
+
+	e.sheets[0].sections[0].Columns[2].FormatterName = "enum(M=Male,F=Female)"
+	assert.NoError(t, e.ValidateFormatters())
+
+	type salary struct {
+		Name   string
+		Salary float64
+		Gender string
+	}
+	e.BindSectionData("salaries", []salary{{" ada ", 5200, "F"}})
+	f, err := e.BuildExcel()
+	assert.NoError(t, err)
+	defer f.Close()
+
+	row, _ := f.GetRows("Salaries")
+	assert.Equal(t, []string{"ADA", "€5,200.00", "Female"}, row[1])
+}
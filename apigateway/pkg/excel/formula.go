@@ -0,0 +1,61 @@
+package excel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// formulaRefPattern matches field references in formula templates, e.g. {Price} or {section_id.Price}.
+var formulaRefPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// generateColumnFormula expands a ColumnConfig.Formula template for one data row.
+// {Field} resolves to the same row of the current section (placement), and
+// {section_id.Field} resolves to the same data row of another section placed by the run; rows of sections
+// split across sheets resolve to the part holding them.
+// The returned formula has no leading "=", as expected by excelize.
+func (e *ExcelDataExporter) generateColumnFormula(run *exportRun, col ColumnConfig, placement SectionPlacement, rowOffset int) (string, error) {
+	var firstErr error
+	formula := formulaRefPattern.ReplaceAllStringFunc(col.Formula, func(match string) string {
+		ref := strings.TrimSpace(match[1 : len(match)-1])
+		cell, err := e.resolveFormulaRef(run, ref, placement, rowOffset)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return match
+		}
+		return cell
+	})
+	if firstErr != nil {
+		return "", fmt.Errorf("formula for column %s: %w", col.FieldName, firstErr)
+	}
+	return strings.TrimPrefix(strings.TrimSpace(formula), "="), nil
+}
+
+// resolveFormulaRef resolves a single formula field reference to a cell address.
+// Local fields take precedence; otherwise the reference is split on a "." into section ID and field name.
+func (e *ExcelDataExporter) resolveFormulaRef(run *exportRun, ref string, placement SectionPlacement, rowOffset int) (string, error) {
+	if colOffset, ok := placement.FieldOffsets[ref]; ok {
+		return excelize.CoordinatesToCellName(placement.StartCol+colOffset, placement.StartRow+rowOffset)
+	}
+	for i := strings.Index(ref, "."); i >= 0; {
+		sectionID, fieldName := ref[:i], ref[i+1:]
+		if _, ok := run.placement(sectionID); ok {
+			return e.resolveCellAddress(run, placement, sectionID, fieldName, run.sectionRow(placement, rowOffset))
+		}
+		next := strings.Index(ref[i+1:], ".")
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return "", fmt.Errorf("field %s not found in %s", ref, placement.SectionID)
+}
+
+// quoteSheetName quotes a sheet name for use in a formula reference.
+func quoteSheetName(sheet string) string {
+	return "'" + strings.ReplaceAll(sheet, "'", "''") + "'"
+}
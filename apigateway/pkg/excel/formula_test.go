@@ -0,0 +1,150 @@
+package excel
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestFormulaColumnsFromYAML(t *testing.T) {
+	yamlConfig := `
+sheets:
+  - name: "Orders"
+    sections:
+      - id: "orders"
+        show_header: true
+        direction: "horizontal"
+        columns:
+          - field_name: "Price"
+            header: "Price"
+            locked: false
+          - field_name: "Quantity"
+            header: "Quantity"
+            locked: false
+          - field_name: "Total"
+            header: "Total"
+            formula: "={Price}*{Quantity}"
+      - id: "tax"
+        show_header: true
+        direction: "horizontal"
+        source_sections: ["orders"]
+        columns:
+          - field_name: "Tax"
+            header: "Tax"
+            formula: "={orders.Total}*0.1"
+`
+	exporter, err := NewExcelDataExporterFromYamlConfig(yamlConfig)
+	assert.NoError(t, err)
+
+	exporter.BindSectionData("orders", []map[string]interface{}{
+		{"Price": 10.0, "Quantity": 2},
+		{"Price": 5.5, "Quantity": 4},
+	})
+
+	f, err := exporter.BuildExcel()
+	assert.NoError(t, err)
+
+	// Row 1: Header, Rows 2-3: Data
+	formula, _ := f.GetCellFormula("Orders", "C2")
+	assert.Equal(t, "A2*B2", formula)
+	formula, _ = f.GetCellFormula("Orders", "C3")
+	assert.Equal(t, "A3*B3", formula)
+
+	formula, _ = f.GetCellFormula("Orders", "D3")
+	assert.Equal(t, "C3*0.1", formula)
+}
+
+func TestFormulaColumnRangeAndErrors(t *testing.T) {
+	exporter := NewExcelDataExporter()
+	exporter.AddSheet("Quarters").
+		AddSection(&SectionConfig{
+			Data: []map[string]interface{}{{"Q1": 1, "Q2": 2, "Q3": 3, "Q4": 4}},
+			Columns: []ColumnConfig{
+				{FieldName: "Q1"}, {FieldName: "Q2"}, {FieldName: "Q3"}, {FieldName: "Q4"},
+				{FieldName: "Year", Formula: "=SUM({Q1}:{Q4})"},
+				{FieldName: "Broken", Formula: "={Missing}+1"},
+			},
+		})
+
+	f, err := exporter.BuildExcel()
+	assert.NoError(t, err)
+
+	formula, _ := f.GetCellFormula("Quarters", "E1")
+	assert.Equal(t, "SUM(A1:D1)", formula)
+
+	val, _ := f.GetCellValue("Quarters", "F1")
+	assert.Contains(t, val, "Error:")
+}
+
+func TestFormulaColumnsStreaming(t *testing.T) {
+	exporter := NewExcelDataExporter()
+	exporter.AddSheet("Summary").
+		AddSection(&SectionConfig{
+			ID:         "lines",
+			ShowHeader: true,
+			Columns: []ColumnConfig{
+				{FieldName: "Price", Header: "Price"},
+				{FieldName: "Quantity", Header: "Quantity"},
+				{FieldName: "Total", Header: "Total", Formula: "={Price}*{Quantity}"},
+			},
+		})
+	exporter.AddSheet("Report").
+		AddSection(&SectionConfig{
+			ID: "report",
+			Columns: []ColumnConfig{
+				{FieldName: "Label"},
+				{FieldName: "Total", Formula: "={lines.Total}"},
+			},
+		})
+
+	type Line struct {
+		Price    float64
+		Quantity int
+	}
+	type Label struct{ Label string }
+
+	exporter.BindSectionData("report", []Label{{"First line"}})
+
+	buf := new(bytes.Buffer)
+	streamer, err := exporter.StartStream(buf)
+	assert.NoError(t, err)
+	assert.NoError(t, streamer.Write("lines", []Line{{10, 2}}))
+	assert.NoError(t, streamer.Write("lines", []Line{{3, 3}}))
+	assert.NoError(t, streamer.Close())
+
+	f, err := excelize.OpenReader(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	defer f.Close()
+
+	formula, _ := f.GetCellFormula("Summary", "C2")
+	assert.Equal(t, "A2*B2", formula)
+	formula, _ = f.GetCellFormula("Summary", "C3")
+	assert.Equal(t, "A3*B3", formula)
+
+	// Cross-sheet references are sheet-qualified
+	formula, _ = f.GetCellFormula("Report", "B1")
+	assert.Equal(t, "'Summary'!C2", formula)
+}
+
+func TestFormulaPlacementsArePerExport(t *testing.T) {
+	type order struct{ Price float64 }
+	e := NewExcelDataExporter()
+	// Totals refers to a section rendered after it, which an export cannot resolve
+	e.AddSheet("Totals").AddSection(&SectionConfig{ID: "totals", Data: []order{{}}, Columns: []ColumnConfig{
+		{FieldName: "Pay", Formula: "{orders.Price}"},
+	}})
+	e.AddSheet("Orders").AddSection(&SectionConfig{ID: "orders", Data: []order{{Price: 10}}})
+
+	// A second export does not resolve the reference against the placement of the first one
+	for i := 0; i < 2; i++ {
+		f, err := e.BuildExcel()
+		assert.NoError(t, err)
+		formula, _ := f.GetCellFormula("Totals", "A1")
+		assert.Empty(t, formula, "export %d", i+1)
+		value, _ := f.GetCellValue("Totals", "A1")
+		assert.Equal(t, "Error: formula for column Pay: field orders.Price not found in totals", value)
+		f.Close()
+	}
+}
@@ -0,0 +1,15 @@
+package excel
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excelsnapshot"
+)
+
+// TestReportGolden snapshots the stock review report, so template and renderer changes show up in the golden
+// file. Run with -args -update to accept them.
+func TestReportGolden(t *testing.T) {
+	_, data := newDiffWorkbook(t)
+	excelsnapshot.AssertGolden(t, openWorkbook(t, data), filepath.Join("testdata", "stock_review.golden.txt"), excelsnapshot.Options{})
+}
@@ -0,0 +1,203 @@
+package excel
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// HTMLOptions configures HTML rendering.
+type HTMLOptions struct {
+	Title           string // Document title, default is the report name
+	HideSheetTitles bool   // Do not render a heading per sheet
+	Fragment        bool   // Render only the body content (no <html>/<head>), e.g. for email bodies
+}
+
+const htmlTableStyle = "border-collapse:collapse;margin:0 0 16px 0;font-family:Calibri,Arial,sans-serif;font-size:14px"
+const htmlCellStyle = "border:1px solid #D0D0D0;padding:4px 8px"
+
+// ToHTML renders all sheets as a self-contained HTML document with inline CSS.
+// Sections become tables and titles become captions. Hidden sections and hidden field
+// rows are omitted; formula columns are rendered empty since they are evaluated by Excel.
+func (e *ExcelDataExporter) ToHTML(w io.Writer, opts HTMLOptions) error {
+	bw := bufio.NewWriter(w)
+
+	title := opts.Title
+	if title == "" {
+		title = e.reportName
+	}
+	if !opts.Fragment {
+		fmt.Fprintf(bw, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n", html.EscapeString(title))
+	}
+
+	for _, sb := range e.newRun().sheets {
+		fmt.Fprintf(bw, "<div class=\"sheet\" data-sheet=\"%s\">\n", html.EscapeString(sb.name))
+		if !opts.HideSheetTitles {
+			fmt.Fprintf(bw, "<h2 style=\"font-family:Calibri,Arial,sans-serif\">%s</h2>\n", html.EscapeString(sb.name))
+		}
+
+		// Consecutive horizontal sections are laid out side by side
+		inRow := false
+		for _, sec := range sb.sections {
+			if sec.Type == SectionTypeHidden {
+				continue
+			}
+			horizontal := sec.Direction == SectionDirectionHorizontal
+			if horizontal && !inRow {
+				bw.WriteString("<div style=\"display:flex;gap:16px;align-items:flex-start\">\n")
+			} else if !horizontal && inRow {
+				bw.WriteString("</div>\n")
+			}
+			inRow = horizontal
+
+			if err := e.renderHTMLSection(bw, sec); err != nil {
+				return err
+			}
+		}
+		if inRow {
+			bw.WriteString("</div>\n")
+		}
+		bw.WriteString("</div>\n")
+	}
+
+	if !opts.Fragment {
+		bw.WriteString("</body>\n</html>\n")
+	}
+	return bw.Flush()
+}
+
+func (e *ExcelDataExporter) renderHTMLSection(bw *bufio.Writer, sec *SectionConfig) error {
+	defaultTitle := &StyleTemplate{
+		Font:      &FontTemplate{Bold: true},
+		Alignment: &AlignmentTemplate{Horizontal: "center", Vertical: "top"},
+	}
+
+	// Title-only sections are a single merged cell
+	if sec.Type == SectionTypeTitleOnly {
+		if sec.Title == nil {
+			return nil
+		}
+		colSpan := sec.ColSpan
+		if colSpan <= 1 && len(sec.Columns) > 1 {
+			colSpan = len(sec.Columns)
+		}
+		if colSpan < 1 {
+			colSpan = 1
+		}
+		style := resolveStyle(sec.TitleStyle, defaultTitle, sec.Locked)
+		fmt.Fprintf(bw, "<table style=\"%s\"><tr><td colspan=\"%d\" style=\"%s\">%s</td></tr></table>\n",
+			htmlTableStyle, colSpan, styleCSS(style, sec.TitleHeight), htmlText(e.localizedTitle(sec.Title)))
+		return nil
+	}
+
+	sec.Columns = mergeSectionColumns(sec, sec.Data)
+	if len(sec.Columns) == 0 {
+		return nil
+	}
+
+	attrs := ""
+	if sec.ID != "" {
+		attrs = fmt.Sprintf(" id=\"%s\"", html.EscapeString(sec.ID))
+	}
+	fmt.Fprintf(bw, "<table%s style=\"%s\">\n", attrs, htmlTableStyle)
+
+	if sec.Title != nil {
+		style := resolveStyle(sec.TitleStyle, defaultTitle, sec.Locked)
+		fmt.Fprintf(bw, "<caption style=\"%s\">%s</caption>\n", styleCSS(style, sec.TitleHeight), htmlText(e.localizedTitle(sec.Title)))
+	}
+
+	if sec.ShowHeader {
+		defaultHeader := &StyleTemplate{
+			Font:      &FontTemplate{Bold: true},
+			Alignment: &AlignmentTemplate{Horizontal: "center", Vertical: "top"},
+		}
+		bw.WriteString("<thead><tr>")
+		for _, col := range sec.Columns {
+			style := resolveStyle(sec.HeaderStyle, defaultHeader, col.IsLocked(sec.Locked))
+			css := styleCSS(style, sec.HeaderHeight)
+			if col.Width > 0 {
+				css += fmt.Sprintf(";min-width:%dpx", int(col.Width*7))
+			}
+			fmt.Fprintf(bw, "<th style=\"%s\">%s</th>", css, html.EscapeString(e.localizedHeader(col)))
+		}
+		bw.WriteString("</tr></thead>\n")
+	}
+
+	v := reflect.ValueOf(sec.Data)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		bw.WriteString("<tbody>\n")
+		accessor := e.compileRowAccessor(sec.Columns, sec.Data)
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			rowStyle := dataRowStyle(sec, i, item)
+			bw.WriteString("<tr>")
+			for j, col := range sec.Columns {
+				height := sec.DataHeight
+				if col.Height > height {
+					height = col.Height
+				}
+				style := resolveStyle(rowStyle, nil, col.IsLocked(sec.Locked))
+				text := ""
+				if col.Formula == "" && col.CompareWith == nil {
+					text = accessor.text(j, item, "")
+				}
+				fmt.Fprintf(bw, "<td style=\"%s\">%s</td>", styleCSS(style, height), html.EscapeString(text))
+			}
+			bw.WriteString("</tr>\n")
+		}
+		bw.WriteString("</tbody>\n")
+	}
+
+	bw.WriteString("</table>\n")
+	return nil
+}
+
+// styleCSS converts a resolved style template to inline CSS.
+func styleCSS(style *StyleTemplate, height float64) string {
+	parts := []string{htmlCellStyle}
+	if style != nil {
+		if style.Font != nil {
+			if style.Font.Bold {
+				parts = append(parts, "font-weight:bold")
+			}
+			if style.Font.Color != "" {
+				parts = append(parts, "color:"+cssColor(style.Font.Color))
+			}
+		}
+		if style.Fill != nil && style.Fill.Color != "" {
+			parts = append(parts, "background-color:"+cssColor(style.Fill.Color))
+		}
+		if style.Alignment != nil {
+			if style.Alignment.Horizontal != "" {
+				parts = append(parts, "text-align:"+style.Alignment.Horizontal)
+			}
+			switch style.Alignment.Vertical {
+			case "":
+			case "center":
+				parts = append(parts, "vertical-align:middle")
+			default:
+				parts = append(parts, "vertical-align:"+style.Alignment.Vertical)
+			}
+		}
+	}
+	if height > 0 {
+		// Excel row heights are in points
+		parts = append(parts, fmt.Sprintf("height:%gpt", height))
+	}
+	return html.EscapeString(strings.Join(parts, ";"))
+}
+
+// cssColor normalizes a hex color ("FFFFFF" or "#FFFFFF") to CSS.
+func cssColor(color string) string {
+	return "#" + strings.TrimPrefix(color, "#")
+}
+
+func htmlText(v interface{}) string {
+	return html.EscapeString(fmt.Sprintf("%v", v))
+}
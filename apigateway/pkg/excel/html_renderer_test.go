@@ -0,0 +1,115 @@
+package excel
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToHTML(t *testing.T) {
+	yamlConfig := `
+name: "Employee <Report>"
+sheets:
+  - name: "Summary"
+    sections:
+      - type: "title"
+        title: "Quarterly Summary"
+        col_span: 3
+        title_style:
+          fill: { color: "#4F81BD" }
+      - id: "employees"
+        title: "Employees"
+        show_header: true
+        locked: true
+        data_style:
+          alignment: { horizontal: "right", vertical: "center" }
+        columns:
+          - field_name: "Name"
+            header: "Name"
+            hidden_field_name: "db_name"
+          - field_name: "Note"
+            header: "Note"
+            locked: false
+      - id: "meta"
+        type: "hidden"
+        columns:
+          - field_name: "Secret"
+`
+	exporter, err := NewExcelDataExporterFromYamlConfig(yamlConfig)
+	assert.NoError(t, err)
+	exporter.
+		BindSectionData("employees", []map[string]interface{}{
+			{"Name": "Alice & Bob", "Note": "<b>hi</b>"},
+		}).
+		BindSectionData("meta", []map[string]interface{}{{"Secret": "do-not-show"}})
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, exporter.ToHTML(buf, HTMLOptions{}))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "<!DOCTYPE html>"))
+	assert.Contains(t, out, "<title>Employee &lt;Report&gt;</title>")
+	assert.Contains(t, out, `<td colspan="3" style="border:1px solid #D0D0D0;padding:4px 8px;font-weight:bold;background-color:#4F81BD;text-align:center;vertical-align:top">Quarterly Summary</td>`)
+	assert.Contains(t, out, `<table id="employees"`)
+	assert.Contains(t, out, ">Employees</caption>")
+	assert.Contains(t, out, ">Alice &amp; Bob</td>")
+	assert.Contains(t, out, ">&lt;b&gt;hi&lt;/b&gt;</td>")
+
+	// Locked column is grayed, unlocked is not; data alignment maps to CSS
+	assert.Contains(t, out, `background-color:#E0E0E0;text-align:right;vertical-align:middle">Alice &amp; Bob`)
+	assert.Contains(t, out, `padding:4px 8px;text-align:right;vertical-align:middle">&lt;b&gt;hi&lt;/b&gt;`)
+
+	// Hidden sections and hidden field rows are omitted
+	assert.NotContains(t, out, "do-not-show")
+	assert.NotContains(t, out, "db_name")
+}
+
+func TestToHTMLFragmentHorizontalAndBanding(t *testing.T) {
+	exporter := NewExcelDataExporter()
+	exporter.AddSheet("Side by side").
+		AddSection(&SectionConfig{
+			ID:        "left",
+			Direction: SectionDirectionHorizontal,
+			Banding:   &BandingConfig{EvenStyle: &StyleTemplate{Fill: &FillTemplate{Color: "F2F2F2"}}},
+			Data:      []map[string]interface{}{{"A": 1}, {"A": 2}},
+			Columns:   []ColumnConfig{{FieldName: "A", Header: "A"}},
+		}).
+		AddSection(&SectionConfig{
+			ID:        "right",
+			Direction: SectionDirectionHorizontal,
+			Data:      []map[string]interface{}{{"B": 3}},
+			Columns:   []ColumnConfig{{FieldName: "B", Header: "B", Formula: "={B}*2"}},
+		})
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, exporter.ToHTML(buf, HTMLOptions{Fragment: true, HideSheetTitles: true}))
+	out := buf.String()
+
+	assert.False(t, strings.Contains(out, "<html>"))
+	assert.False(t, strings.Contains(out, "<h2"))
+	assert.Equal(t, 1, strings.Count(out, "display:flex"))
+	assert.Contains(t, out, `padding:4px 8px">1</td>`)
+	assert.Contains(t, out, `background-color:#F2F2F2">2</td>`)
+	// Formula columns are left to Excel
+	assert.Contains(t, out, `padding:4px 8px"></td>`)
+}
+
+func TestToHTMLFollowsRebinding(t *testing.T) {
+	exporter := NewExcelDataExporter()
+	exporter.AddSheet("People").AddSection(&SectionConfig{ID: "people", ShowHeader: true})
+
+	exporter.BindSectionData("people", []map[string]interface{}{{"Name": "first"}})
+	buf := new(bytes.Buffer)
+	assert.NoError(t, exporter.ToHTML(buf, HTMLOptions{Fragment: true}))
+	assert.Contains(t, buf.String(), "first")
+
+	// Data bound after an export is used by the next one
+	exporter.BindSectionData("people", []map[string]interface{}{{"Name": "second"}})
+	buf.Reset()
+	assert.NoError(t, exporter.ToHTML(buf, HTMLOptions{Fragment: true}))
+	assert.Contains(t, buf.String(), "second")
+	assert.NotContains(t, buf.String(), "first")
+	assert.Nil(t, exporter.GetSection("people").Data)
+}
@@ -0,0 +1,196 @@
+package excel
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// JSONDocument is the JSON form of a rendered report, keyed by sheet name and section ID.
+type JSONDocument struct {
+	ReportName    string               `json:"report_name,omitempty"`
+	ConfigVersion string               `json:"config_version,omitempty"`
+	Sheets        map[string]JSONSheet `json:"sheets"`
+}
+
+// JSONSheet holds the sections of a sheet keyed by section ID.
+// Sections without an ID are keyed "section_<n>" (1-based position in the sheet).
+type JSONSheet struct {
+	Index    int                    `json:"index"`
+	Sections map[string]JSONSection `json:"sections"`
+}
+
+// JSONSection holds column metadata and rows of one section.
+type JSONSection struct {
+	Index   int                      `json:"index"`
+	ID      string                   `json:"id,omitempty"`
+	Type    string                   `json:"type"`
+	Title   interface{}              `json:"title,omitempty"`
+	Locked  bool                     `json:"locked"`
+	Columns []JSONColumn             `json:"columns"`
+	Rows    []map[string]interface{} `json:"rows"`
+}
+
+// JSONColumn describes a column. Rows are keyed by FieldName.
+type JSONColumn struct {
+	FieldName       string `json:"field_name"`
+	Header          string `json:"header"`
+	HiddenFieldName string `json:"hidden_field_name,omitempty"`
+	Locked          bool   `json:"locked"`
+	Formula         string `json:"formula,omitempty"` // Formula and comparison columns have null values; Excel evaluates them
+}
+
+// jsonRecord is one line of the NDJSON output.
+type jsonRecord struct {
+	Type    string                 `json:"type"` // "section" or "row"
+	Sheet   string                 `json:"sheet"`
+	Section string                 `json:"section"`
+	Index   *int                   `json:"index,omitempty"`
+	Title   interface{}            `json:"title,omitempty"`
+	Locked  *bool                  `json:"locked,omitempty"`
+	Columns []JSONColumn           `json:"columns,omitempty"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+// jsonSectionKey returns the key of a section in its sheet.
+func jsonSectionKey(sec *SectionConfig, index int) string {
+	if sec.ID != "" {
+		return sec.ID
+	}
+	return fmt.Sprintf("section_%d", index+1)
+}
+
+// prepareJSONSection resolves the columns of a section of a run, returning the section metadata without rows.
+func (e *ExcelDataExporter) prepareJSONSection(sec *SectionConfig, index int) JSONSection {
+	sectionType := sec.Type
+	if sectionType == "" {
+		sectionType = SectionTypeFull
+	}
+	js := JSONSection{
+		Index:   index,
+		ID:      sec.ID,
+		Type:    sectionType,
+		Title:   e.localizedTitle(sec.Title),
+		Locked:  sec.Locked,
+		Columns: []JSONColumn{},
+		Rows:    []map[string]interface{}{},
+	}
+	if sectionType == SectionTypeTitleOnly {
+		return js
+	}
+
+	sec.Columns = mergeSectionColumns(sec, sec.Data)
+	for _, col := range sec.Columns {
+		js.Columns = append(js.Columns, JSONColumn{
+			FieldName:       col.FieldName,
+			Header:          e.localizedHeader(col),
+			HiddenFieldName: col.HiddenFieldName,
+			Locked:          col.IsLocked(sec.Locked),
+			Formula:         col.Formula,
+		})
+	}
+	return js
+}
+
+// eachJSONRow calls fn with every row of a section, as field name to formatted value.
+func (e *ExcelDataExporter) eachJSONRow(sec *SectionConfig, fn func(row map[string]interface{}) error) error {
+	if sec.Type == SectionTypeTitleOnly {
+		return nil
+	}
+	v := reflect.ValueOf(sec.Data)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil
+	}
+	accessor := e.compileRowAccessor(sec.Columns, sec.Data)
+	for i := 0; i < v.Len(); i++ {
+		item := v.Index(i)
+		for item.Kind() == reflect.Interface || item.Kind() == reflect.Ptr {
+			if item.IsNil() {
+				break
+			}
+			item = item.Elem()
+		}
+		row := make(map[string]interface{}, len(sec.Columns))
+		for j, col := range sec.Columns {
+			if col.Formula != "" || col.CompareWith != nil || !item.IsValid() {
+				row[col.FieldName] = nil
+				continue
+			}
+			row[col.FieldName] = accessor.value(j, item)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuildJSON builds the JSON document of the report with formatters applied.
+func (e *ExcelDataExporter) BuildJSON() (*JSONDocument, error) {
+	doc := &JSONDocument{
+		ReportName:    e.reportName,
+		ConfigVersion: e.configVersion,
+		Sheets:        make(map[string]JSONSheet, len(e.sheets)),
+	}
+	for i, sb := range e.newRun().sheets {
+		sheet := JSONSheet{Index: i, Sections: make(map[string]JSONSection, len(sb.sections))}
+		for j, sec := range sb.sections {
+			js := e.prepareJSONSection(sec, j)
+			if err := e.eachJSONRow(sec, func(row map[string]interface{}) error {
+				js.Rows = append(js.Rows, row)
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+			sheet.Sections[jsonSectionKey(sec, j)] = js
+		}
+		doc.Sheets[sb.name] = sheet
+	}
+	return doc, nil
+}
+
+// ToJSON writes the report as a single JSON document (see JSONDocument).
+func (e *ExcelDataExporter) ToJSON(w io.Writer) error {
+	doc, err := e.BuildJSON()
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(doc)
+}
+
+// ToNDJSON writes the report as newline-delimited JSON: for each section, a "section"
+// record with its column metadata followed by one "row" record per data row.
+// Rows are encoded one at a time, so the output can be consumed incrementally.
+func (e *ExcelDataExporter) ToNDJSON(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	for _, sb := range e.newRun().sheets {
+		for j, sec := range sb.sections {
+			js := e.prepareJSONSection(sec, j)
+			key := jsonSectionKey(sec, j)
+			if err := enc.Encode(jsonRecord{
+				Type:    "section",
+				Sheet:   sb.name,
+				Section: key,
+				Index:   &js.Index,
+				Title:   js.Title,
+				Locked:  &js.Locked,
+				Columns: js.Columns,
+			}); err != nil {
+				return err
+			}
+			if err := e.eachJSONRow(sec, func(row map[string]interface{}) error {
+				return enc.Encode(jsonRecord{Type: "row", Sheet: sb.name, Section: key, Data: row})
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,127 @@
+package excel
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func jsonExporter(t *testing.T) *ExcelDataExporter {
+	yamlConfig := `
+name: "Products"
+version: "3"
+sheets:
+  - name: "Executive Report"
+    sections:
+      - type: "title"
+        title: "Report"
+      - id: "product_section_editable"
+        show_header: true
+        locked: true
+        columns:
+          - field_name: "Name"
+            header: "Product Name"
+            hidden_field_name: "db_name"
+          - field_name: "Price"
+            header: "Price"
+            formatter: "currency"
+            locked: false
+          - field_name: "Total"
+            header: "Total"
+            formula: "={Price}*2"
+`
+	exporter, err := NewExcelDataExporterFromYamlConfig(yamlConfig)
+	assert.NoError(t, err)
+	exporter.RegisterFormatter("currency", func(v interface{}) interface{} {
+		return "$" + jsonNumber(v)
+	})
+	type Product struct {
+		Name  string
+		Price float64
+	}
+	exporter.BindSectionData("product_section_editable", []Product{{"Laptop", 10}, {"Phone", 5.5}})
+	return exporter
+}
+
+func jsonNumber(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func TestToJSON(t *testing.T) {
+	buf := new(bytes.Buffer)
+	assert.NoError(t, jsonExporter(t).ToJSON(buf))
+
+	var doc JSONDocument
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "Products", doc.ReportName)
+	assert.Equal(t, "3", doc.ConfigVersion)
+
+	sheet, ok := doc.Sheets["Executive Report"]
+	assert.True(t, ok)
+	assert.Equal(t, "title", sheet.Sections["section_1"].Type)
+
+	sec := sheet.Sections["product_section_editable"]
+	assert.Equal(t, 1, sec.Index)
+	assert.Equal(t, []JSONColumn{
+		{FieldName: "Name", Header: "Product Name", HiddenFieldName: "db_name", Locked: true},
+		{FieldName: "Price", Header: "Price", Locked: false},
+		{FieldName: "Total", Header: "Total", Locked: true, Formula: "={Price}*2"},
+	}, sec.Columns)
+	assert.Equal(t, []map[string]interface{}{
+		{"Name": "Laptop", "Price": "$10", "Total": nil},
+		{"Name": "Phone", "Price": "$5.5", "Total": nil},
+	}, sec.Rows)
+}
+
+func TestToNDJSON(t *testing.T) {
+	buf := new(bytes.Buffer)
+	assert.NoError(t, jsonExporter(t).ToNDJSON(buf))
+
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var rec map[string]interface{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		records = append(records, rec)
+	}
+	if !assert.Len(t, records, 4) {
+		return
+	}
+
+	assert.Equal(t, "section", records[0]["type"])
+	assert.Equal(t, "section_1", records[0]["section"])
+
+	assert.Equal(t, "section", records[1]["type"])
+	assert.Equal(t, "product_section_editable", records[1]["section"])
+	assert.Len(t, records[1]["columns"], 3)
+
+	assert.Equal(t, "row", records[2]["type"])
+	assert.Equal(t, "Executive Report", records[2]["sheet"])
+	assert.Equal(t, map[string]interface{}{"Name": "Laptop", "Price": "$10", "Total": nil}, records[2]["data"])
+	assert.Equal(t, "Phone", records[3]["data"].(map[string]interface{})["Name"])
+}
+
+func TestJSONFollowsRebinding(t *testing.T) {
+	exporter := NewExcelDataExporter()
+	exporter.AddSheet("People").AddSection(&SectionConfig{ID: "people", ShowHeader: true})
+
+	for _, name := range []string{"first", "second"} {
+		// Data bound after an export is used by the next one
+		exporter.BindSectionData("people", []map[string]interface{}{{"Name": name}})
+		var js, nd bytes.Buffer
+		assert.NoError(t, exporter.ToJSON(&js))
+		assert.NoError(t, exporter.ToNDJSON(&nd))
+		for _, out := range []string{js.String(), nd.String()} {
+			assert.Contains(t, out, name)
+			if name == "second" {
+				assert.NotContains(t, out, "first")
+			}
+		}
+	}
+	assert.Nil(t, exporter.GetSection("people").Data)
+	assert.Empty(t, exporter.GetSection("people").Columns)
+}
@@ -0,0 +1,361 @@
+package excel
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LocaleBundle holds the messages and formats of one export locale, loaded from a YAML or JSON file:
+//
+//	locale: de
+//	decimal_separator: ","
+//	thousands_separator: "."
+//	date_format: "02.01.2006"
+//	messages:
+//	  report.title: "Mitarbeiterbericht"
+//	  gender:
+//	    male: "Männlich"   # key gender.male
+type LocaleBundle struct {
+	Locale             string `yaml:"locale" json:"locale"`
+	DecimalSeparator   string `yaml:"decimal_separator" json:"decimal_separator"`     // Used by the built-in formatters (default ".")
+	ThousandsSeparator string `yaml:"thousands_separator" json:"thousands_separator"` // Used by the built-in formatters (default ",")
+	DateFormat         string `yaml:"date_format" json:"date_format"`                 // Go layout for dates (default "2006-01-02")
+	DateTimeFormat     string `yaml:"datetime_format" json:"datetime_format"`         // Go layout for date-times (default "2006-01-02 15:04:05")
+	NumberFormat       string `yaml:"number_format" json:"number_format"`             // Excel number format of float cells, e.g. "#,##0.00"
+	IntegerFormat      string `yaml:"integer_format" json:"integer_format"`           // Excel number format of integer cells, e.g. "#,##0"
+	// Messages maps message keys to translations. Nested maps are flattened with "." separators.
+	Messages map[string]string `yaml:"-" json:"-"`
+}
+
+// T returns the translation of a message key, or the key itself if the bundle has no such message.
+func (b *LocaleBundle) T(key string) string {
+	if b == nil {
+		return key
+	}
+	if msg, ok := b.Messages[key]; ok {
+		return msg
+	}
+	return key
+}
+
+// ParseLocaleBundle parses a locale bundle. format is "yaml" or "json".
+func ParseLocaleBundle(data []byte, format string) (*LocaleBundle, error) {
+	var raw struct {
+		LocaleBundle `yaml:",inline"`
+		Messages     map[string]interface{} `yaml:"messages" json:"messages"`
+	}
+	var err error
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		err = yaml.Unmarshal(data, &raw)
+	case "json":
+		err = json.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported locale bundle format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode locale bundle: %w", err)
+	}
+
+	b := raw.LocaleBundle
+	b.Messages = make(map[string]string)
+	flattenMessages("", raw.Messages, b.Messages)
+	return &b, nil
+}
+
+// flattenMessages flattens nested message maps into dotted keys.
+func flattenMessages(prefix string, m interface{}, out map[string]string) {
+	switch v := m.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			flattenMessages(joinKey(prefix, k), child, out)
+		}
+	case map[interface{}]interface{}:
+		for k, child := range v {
+			flattenMessages(joinKey(prefix, fmt.Sprint(k)), child, out)
+		}
+	case nil:
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// LoadLocaleBundle loads a locale bundle from a .yaml, .yml or .json file.
+// The locale defaults to the file name without extension (e.g. "de.yaml" -> "de").
+func LoadLocaleBundle(path string) (*LocaleBundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ext := filepath.Ext(path)
+	b, err := ParseLocaleBundle(data, strings.TrimPrefix(ext, "."))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if b.Locale == "" {
+		b.Locale = strings.TrimSuffix(filepath.Base(path), ext)
+	}
+	return b, nil
+}
+
+// Locales is a set of locale bundles with a fallback locale.
+type Locales struct {
+	bundles  map[string]*LocaleBundle
+	fallback string
+}
+
+// NewLocales creates an empty set of locale bundles. fallback is the locale used when no requested locale matches.
+func NewLocales(fallback string) *Locales {
+	return &Locales{bundles: make(map[string]*LocaleBundle), fallback: normalizeLocale(fallback)}
+}
+
+// LoadLocales loads all .yaml, .yml and .json bundles of a directory.
+func LoadLocales(dir, fallback string) (*Locales, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	l := NewLocales(fallback)
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if entry.IsDir() {
+			continue
+		}
+		b, err := LoadLocaleBundle(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		l.Add(b)
+	}
+	return l, nil
+}
+
+// Add adds (or replaces) a bundle.
+func (l *Locales) Add(b *LocaleBundle) *Locales {
+	l.bundles[normalizeLocale(b.Locale)] = b
+	return l
+}
+
+// Get returns the bundle of a locale tag, trying the base language next ("de-AT" -> "de"), or nil.
+func (l *Locales) Get(tag string) *LocaleBundle {
+	tag = normalizeLocale(tag)
+	if b, ok := l.bundles[tag]; ok {
+		return b
+	}
+	if i := strings.IndexByte(tag, '-'); i > 0 {
+		return l.bundles[tag[:i]]
+	}
+	return nil
+}
+
+// Negotiate picks the bundle for a request: lang (e.g. the ?lang= parameter) if it matches,
+// otherwise the best match of an Accept-Language header, otherwise the fallback locale.
+func (l *Locales) Negotiate(lang, acceptLanguage string) *LocaleBundle {
+	if lang != "" {
+		if b := l.Get(lang); b != nil {
+			return b
+		}
+	}
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if tag == "*" {
+			break
+		}
+		if b := l.Get(tag); b != nil {
+			return b
+		}
+	}
+	return l.bundles[l.fallback]
+}
+
+// parseAcceptLanguage returns the language tags of an Accept-Language header by descending quality.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag, q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	out := make([]string, len(tags))
+	for i, t := range tags {
+		out[i] = t.tag
+	}
+	return out
+}
+
+// normalizeLocale lowercases a locale tag and uses "-" separators ("pt_BR" -> "pt-br").
+func normalizeLocale(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+// SetLocale sets the export locale. Section titles and column headers that are message keys of the bundle
+// are translated when written, including headers of detected fields; the built-in formatters use its
+// separators, date layouts and translated enum/bool labels, and typed date and number cells get its formats.
+// The sections keep the message keys, so the locale can be changed between exports.
+func (e *ExcelDataExporter) SetLocale(b *LocaleBundle) *ExcelDataExporter {
+	e.locale = b
+	e.resetFormatters()
+	return e
+}
+
+// Locale returns the export locale, or nil.
+func (e *ExcelDataExporter) Locale() *LocaleBundle {
+	return e.locale
+}
+
+// localizedTitle returns a section title translated into the export locale. Titles other than strings are
+// written as they are.
+func (e *ExcelDataExporter) localizedTitle(title interface{}) interface{} {
+	if s, ok := title.(string); ok && s != "" {
+		return e.locale.T(s)
+	}
+	return title
+}
+
+// localizedHeader returns the header of a column translated into the export locale.
+func (e *ExcelDataExporter) localizedHeader(col ColumnConfig) string {
+	return e.locale.T(col.Header)
+}
+
+// formatContext returns the settings of the built-in formatters for the export locale.
+func (e *ExcelDataExporter) formatContext() formatContext {
+	fc := defaultFormatContext
+	b := e.locale
+	if b == nil {
+		return fc
+	}
+	if b.DecimalSeparator != "" {
+		fc.decimal = b.DecimalSeparator
+	}
+	if b.ThousandsSeparator != "" {
+		fc.thousands = b.ThousandsSeparator
+	}
+	if b.DateFormat != "" {
+		fc.dateLayout = b.DateFormat
+	}
+	if b.DateTimeFormat != "" {
+		fc.dateTimeLayout = b.DateTimeFormat
+	}
+	fc.translate = b.T
+	return fc
+}
+
+// localizedCellStyle returns the data style of a column whose values are like sample, with the number
+// format of the export locale for typed date and number cells. Explicit number formats are kept.
+func (e *ExcelDataExporter) localizedCellStyle(style *StyleTemplate, sample interface{}) *StyleTemplate {
+	if e.locale == nil || style == nil || style.NumFmt != "" {
+		return style
+	}
+	fc := e.formatContext()
+	numFmt := ""
+	switch v := sample.(type) {
+	case time.Time:
+		numFmt = excelDateFormat(timeLayout(v, fc))
+	case *time.Time:
+		if v != nil {
+			numFmt = excelDateFormat(timeLayout(*v, fc))
+		}
+	case float32, float64:
+		numFmt = e.locale.NumberFormat
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		numFmt = e.locale.IntegerFormat
+	}
+	if numFmt == "" {
+		return style
+	}
+	s := *style
+	s.NumFmt = numFmt
+	return &s
+}
+
+// columnSample returns the formatted value of a column in the first data row, or nil for formula columns.
+func (e *ExcelDataExporter) columnSample(col ColumnConfig, dataVal reflect.Value) interface{} {
+	if e.locale == nil || col.CompareWith != nil || col.Formula != "" || dataVal.Kind() != reflect.Slice || dataVal.Len() == 0 {
+		return nil
+	}
+	return e.cellValue(col, dataVal.Index(0))
+}
+
+// timeLayout returns the date layout for values without a time of day, otherwise the date-time layout.
+func timeLayout(t time.Time, fc formatContext) string {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return fc.dateLayout
+	}
+	return fc.dateTimeLayout
+}
+
+// goLayoutTokens maps Go time layout elements to Excel number format codes, longest first.
+var goLayoutTokens = []struct{ layout, excel string }{
+	{"January", "mmmm"}, {"Monday", "dddd"}, {"2006", "yyyy"},
+	{"Jan", "mmm"}, {"Mon", "ddd"}, {"MST", ""},
+	{"01", "mm"}, {"02", "dd"}, {"03", "hh"}, {"04", "mm"}, {"05", "ss"}, {"06", "yy"}, {"15", "hh"},
+	{"PM", "AM/PM"}, {"pm", "am/pm"},
+	{"1", "m"}, {"2", "d"}, {"3", "h"}, {"4", "m"}, {"5", "s"},
+}
+
+// excelDateFormat converts a Go time layout to an Excel number format, e.g. "02.01.2006" -> `dd"."mm"."yyyy`.
+// Other letters and dots (which Excel number formats read as decimal points) are quoted as literals.
+func excelDateFormat(layout string) string {
+	var b strings.Builder
+	for len(layout) > 0 {
+		matched := false
+		for _, tok := range goLayoutTokens {
+			if strings.HasPrefix(layout, tok.layout) {
+				b.WriteString(tok.excel)
+				layout = layout[len(tok.layout):]
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		c := layout[0]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '.' {
+			fmt.Fprintf(&b, `"%c"`, c)
+		} else {
+			b.WriteByte(c)
+		}
+		layout = layout[1:]
+	}
+	return b.String()
+}
@@ -0,0 +1,164 @@
+package excel
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const deBundle = `
+locale: de
+decimal_separator: ","
+thousands_separator: "."
+date_format: "02.01.2006"
+messages:
+  report:
+    title: "Mitarbeiterbericht"
+  header:
+    name: "Name"
+    salary: "Gehalt"
+    hired: "Eingestellt"
+    gender: "Geschlecht"
+  gender:
+    male: "Männlich"
+    female: "Weiblich"
+`
+
+const frBundle = `{
+  "locale": "fr",
+  "decimal_separator": ",",
+  "thousands_separator": " ",
+  "messages": {"header": {"salary": "Salaire"}}
+}`
+
+func TestParseLocaleBundle(t *testing.T) {
+	de, err := ParseLocaleBundle([]byte(deBundle), "yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "de", de.Locale)
+	assert.Equal(t, ",", de.DecimalSeparator)
+	assert.Equal(t, "Weiblich", de.T("gender.female"))
+	assert.Equal(t, "missing.key", de.T("missing.key"))
+
+	fr, err := ParseLocaleBundle([]byte(frBundle), "json")
+	assert.NoError(t, err)
+	assert.Equal(t, "Salaire", fr.T("header.salary"))
+	assert.Equal(t, " ", fr.ThousandsSeparator)
+
+	_, err = ParseLocaleBundle([]byte(deBundle), "toml")
+	assert.Error(t, err)
+}
+
+func TestLocalesNegotiate(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "de.yaml"), []byte(deBundle), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "fr.json"), []byte(frBundle), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "en.yml"), []byte("messages: {}"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a bundle"), 0o644))
+
+	locales, err := LoadLocales(dir, "en")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "de", locales.Negotiate("de", "fr").Locale)
+	assert.Equal(t, "de", locales.Negotiate("de_AT", "").Locale)
+	assert.Equal(t, "fr", locales.Negotiate("xx", "it;q=0.9, fr-CA;q=0.8, de;q=0.5").Locale)
+	assert.Equal(t, "de", locales.Negotiate("", "fr;q=0.3, de").Locale)
+	assert.Equal(t, "en", locales.Negotiate("", "it, *;q=0.1").Locale) // File name is the default locale
+	assert.Equal(t, "en", locales.Negotiate("", "").Locale)
+	assert.Nil(t, locales.Get("it"))
+}
+
+func TestSetLocale(t *testing.T) {
+	type employee struct {
+		Name   string
+		Salary float64
+		Hired  time.Time
+		Gender string
+	}
+	de, err := ParseLocaleBundle([]byte(deBundle), "yaml")
+	assert.NoError(t, err)
+
+	e := NewExcelDataExporter()
+	e.AddSheet("Employees").AddSection(&SectionConfig{
+		Title:      "report.title",
+		ShowHeader: true,
+		Data:       []employee{{"Ada", 5200.5, time.Date(2021, 3, 9, 0, 0, 0, 0, time.UTC), "F"}},
+		Columns: []ColumnConfig{
+			{FieldName: "Name", Header: "header.name"},
+			{FieldName: "Salary", Header: "header.salary", FormatterName: "currency(EUR,2)"},
+			{FieldName: "Hired", Header: "header.hired"},
+			{FieldName: "Gender", Header: "header.gender", FormatterName: "enum(M=gender.male,F=gender.female)"},
+		},
+	})
+	e.SetLocale(de)
+
+	// Sections added after SetLocale are translated as well
+	e.AddSheet("Notes").AddSection(&SectionConfig{Title: "report.title", Type: SectionTypeTitleOnly})
+
+	f, err := e.BuildExcel()
+	assert.NoError(t, err)
+	defer f.Close()
+
+	rows, err := f.GetRows("Employees")
+	assert.NoError(t, err)
+	assert.Equal(t, "Mitarbeiterbericht", rows[0][0])
+	assert.Equal(t, []string{"Name", "Gehalt", "Eingestellt", "Geschlecht"}, rows[1])
+	assert.Equal(t, []string{"Ada", "€5.200,50", "09.03.2021", "Weiblich"}, rows[2])
+
+	title, _ := f.GetCellValue("Notes", "A1")
+	assert.Equal(t, "Mitarbeiterbericht", title)
+}
+
+func TestSetLocaleAgain(t *testing.T) {
+	type product struct {
+		Name  string
+		Price float64
+	}
+	de, err := ParseLocaleBundle([]byte("locale: de\nmessages:\n  report.title: Bericht\n  Name: Produkt\n  Price: Preis\n"), "yaml")
+	assert.NoError(t, err)
+	fr, err := ParseLocaleBundle([]byte("locale: fr\nmessages:\n  report.title: Rapport\n  Name: Produit\n  Price: Prix\n"), "yaml")
+	assert.NoError(t, err)
+
+	// Price has no column configuration: its header is detected from the data
+	e := NewExcelDataExporter()
+	e.AddSheet("Products").AddSection(&SectionConfig{
+		ID:         "products",
+		Title:      "report.title",
+		ShowHeader: true,
+		Data:       []product{{"Widget", 9.5}},
+		Columns:    []ColumnConfig{{FieldName: "Name", Header: "Name"}},
+	})
+
+	for _, tc := range []struct {
+		locale *LocaleBundle
+		rows   [][]string
+	}{
+		{de, [][]string{{"Bericht"}, {"Produkt", "Preis"}}},
+		{de, [][]string{{"Bericht"}, {"Produkt", "Preis"}}},
+		{fr, [][]string{{"Rapport"}, {"Produit", "Prix"}}},
+		{nil, [][]string{{"report.title"}, {"Name", "Price"}}},
+	} {
+		e.SetLocale(tc.locale)
+		f, err := e.BuildExcel()
+		assert.NoError(t, err)
+		rows, _ := f.GetRows("Products")
+		assert.Equal(t, tc.rows, rows[:2])
+		f.Close()
+
+		var buf bytes.Buffer
+		assert.NoError(t, e.ToCSV(&buf))
+		assert.Equal(t, strings.Join(tc.rows[1], ","), strings.Split(buf.String(), "\n")[1])
+	}
+	assert.Equal(t, "report.title", e.sheets[0].sections[0].Title)
+}
+
+func TestExcelDateFormat(t *testing.T) {
+	assert.Equal(t, `dd"."mm"."yyyy`, excelDateFormat("02.01.2006"))
+	assert.Equal(t, "yyyy-mm-dd hh:mm:ss", excelDateFormat("2006-01-02 15:04:05"))
+	assert.Equal(t, "mmm d, yyyy h:mm AM/PM", excelDateFormat("Jan 2, 2006 3:04 PM"))
+	assert.Equal(t, `yyyy-mm-dd"T"hh:mm`, excelDateFormat("2006-01-02T15:04"))
+}
@@ -0,0 +1,333 @@
+package excel
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Template schemas understood by MigrateTemplate.
+const (
+	SchemaV1 = "v1" // pkg/simpleexcel
+	SchemaV2 = "v2" // pkg/excel and pkg/simpleexcelv2, the newest schema
+	SchemaV3 = "v3" // pkg/simpleexcelv3
+)
+
+// MigrationResult is the outcome of a template migration.
+type MigrationResult struct {
+	From    string   // Schema the template was migrated from
+	YAML    []byte   // The migrated template
+	Changes []string // Edits that keep the rendering of the source schema
+	Notes   []string // Behavior differences that cannot be expressed in the template
+}
+
+// schemaKeys lists the keys each schema reads, per level of the template. The newest schema reads all keys.
+var schemaKeys = map[string]map[string][]string{
+	SchemaV1: {
+		"template": {"workbook_protection", "encryption", "sheets"},
+		"sheet":    {"name", "protection", "sections"},
+		"section": {"id", "title", "col_span", "type", "locked", "show_header", "direction", "position",
+			"title_style", "header_style", "data_style", "columns"},
+		"column": {"field_name", "header", "width", "locked", "formatter", "hidden_field_name"},
+		"style":  {"font", "fill", "locked"},
+	},
+	SchemaV3: {
+		"template": {"sheets"},
+		"sheet":    {"name", "sections"},
+		"section": {"id", "title", "col_span", "source_sections", "type", "locked", "show_header", "direction",
+			"position", "title_style", "header_style", "data_style", "title_height", "header_height", "data_height",
+			"has_filter", "columns"},
+		"column": {"field_name", "header", "width", "height", "locked", "formatter", "hidden_field_name",
+			"compare_with", "compare_against"},
+		"style": {"font", "fill", "alignment", "locked"},
+	},
+}
+
+// DetectTemplateSchema returns the oldest schema that reads every key of a template: v1, then v3, then v2.
+// Templates using only the keys shared by all schemas are reported as v1; pass the schema to MigrateTemplate
+// when it is known.
+func DetectTemplateSchema(yamlConfig []byte) (string, error) {
+	tmpl, err := decodeTemplate(yamlConfig)
+	if err != nil {
+		return "", err
+	}
+	return detectSchema(tmpl), nil
+}
+
+func detectSchema(tmpl *templateMap) string {
+	for _, schema := range []string{SchemaV1, SchemaV3} {
+		if len(unknownKeys(tmpl, schema)) == 0 {
+			return schema
+		}
+	}
+	return SchemaV2
+}
+
+// MigrateTemplate upgrades a YAML template to the newest schema. from is the schema of the template; empty
+// detects it (see DetectTemplateSchema). Where the newest schema renders a template differently, the migrated
+// template spells out the old behavior and the change is listed; differences that cannot be spelled out are
+// listed as notes. Comments are not kept.
+func MigrateTemplate(yamlConfig []byte, from string) (*MigrationResult, error) {
+	tmpl, err := decodeTemplate(yamlConfig)
+	if err != nil {
+		return nil, err
+	}
+	if from == "" {
+		from = detectSchema(tmpl)
+	}
+
+	res := &MigrationResult{From: from}
+	switch from {
+	case SchemaV1, SchemaV3:
+		for _, key := range unknownKeys(tmpl, from) {
+			res.Notes = append(res.Notes, fmt.Sprintf("%s was ignored by %s and now applies", key, from))
+		}
+		if from == SchemaV1 {
+			migrateV1(tmpl, res)
+		}
+		noteFormatters(tmpl, from, res)
+	case SchemaV2:
+	default:
+		return nil, fmt.Errorf("unknown template schema %q", from)
+	}
+
+	if res.YAML, err = yaml.Marshal(tmpl); err != nil {
+		return nil, fmt.Errorf("encode yaml: %w", err)
+	}
+	return res, nil
+}
+
+// migrateV1 spells out the v1 rendering: titles and headers without alignment, title-only sections without the
+// bold default font, and protected sheets without the auto filter.
+func migrateV1(tmpl *templateMap, res *MigrationResult) {
+	for i, sheet := range tmpl.list("sheets") {
+		sheetPath := fmt.Sprintf("sheets[%d]", i)
+		locked := sheet.get("protection") != nil
+		for j, sec := range sheet.list("sections") {
+			secPath := fmt.Sprintf("%s.sections[%d]", sheetPath, j)
+			locked = locked || templateSectionLocked(sec)
+
+			// v1 reads an empty title as no title, the core renders an empty title row
+			if title, ok := sec.get("title").(string); ok && title == "" {
+				sec.remove("title")
+				res.Changes = append(res.Changes, fmt.Sprintf("%s.title: removed the empty title", secPath))
+			}
+
+			if sec.get("type") == SectionTypeTitleOnly {
+				if sec.get("title") == nil {
+					continue
+				}
+				if sec.isTrue("locked") {
+					res.Notes = append(res.Notes, fmt.Sprintf("%s: locked title-only sections are now locked and gray", secPath))
+				}
+				style := sec.child("title_style")
+				if style.get("font") == nil {
+					style.set("font", &templateMap{})
+					res.Changes = append(res.Changes, fmt.Sprintf("%s.title_style.font: set to {} to keep the title regular", secPath))
+				}
+				unalign(style, secPath+".title_style", res)
+				continue
+			}
+
+			if sec.get("title") != nil {
+				unalign(sec.child("title_style"), secPath+".title_style", res)
+			}
+			if sec.isTrue("show_header") {
+				unalign(sec.child("header_style"), secPath+".header_style", res)
+			}
+			if sec.isTrue("locked") {
+				res.Notes = append(res.Notes, fmt.Sprintf("%s: data fields missing from columns are now added after them", secPath))
+			}
+		}
+		if locked {
+			protection := sheet.child("protection")
+			if protection.get("auto_filter") == nil {
+				protection.set("auto_filter", false)
+				res.Changes = append(res.Changes, fmt.Sprintf("%s.protection.auto_filter: set to false, the v1 default", sheetPath))
+			}
+		}
+	}
+	res.Notes = append(res.Notes,
+		"workbooks now carry report metadata (custom document properties) and defined names for sections")
+}
+
+// unalign sets an empty alignment on a style, which keeps the centered default from applying.
+func unalign(style *templateMap, path string, res *MigrationResult) {
+	if style.get("alignment") == nil {
+		style.set("alignment", &templateMap{})
+		res.Changes = append(res.Changes, fmt.Sprintf("%s.alignment: set to {} to keep the default alignment", path))
+	}
+}
+
+// noteFormatters lists the formatter names that the newest schema may resolve to built-in formatters; the old
+// schemas only used registered formatters.
+func noteFormatters(tmpl *templateMap, from string, res *MigrationResult) {
+	builtins := make(map[string]bool)
+	for _, name := range BuiltinFormatters() {
+		builtins[name] = true
+	}
+	for i, sheet := range tmpl.list("sheets") {
+		for j, sec := range sheet.list("sections") {
+			for k, col := range sec.list("columns") {
+				name, _ := col.get("formatter").(string)
+				if name == "" || !(builtins[name] || strings.ContainsAny(name, "(|")) {
+					continue
+				}
+				res.Notes = append(res.Notes, fmt.Sprintf(
+					"sheets[%d].sections[%d].columns[%d].formatter: %q now resolves to built-in formatters unless a formatter of that name is registered (%s only used registered formatters)",
+					i, j, k, name, from))
+			}
+		}
+	}
+}
+
+// templateSectionLocked returns true if a section or one of its columns is locked.
+func templateSectionLocked(sec *templateMap) bool {
+	if sec.isTrue("locked") {
+		return true
+	}
+	for _, col := range sec.list("columns") {
+		if col.isTrue("locked") {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownKeys returns the paths of the keys of a template that a schema does not read.
+func unknownKeys(tmpl *templateMap, schema string) []string {
+	keys := schemaKeys[schema]
+	var unknown []string
+	check := func(m *templateMap, level, path string) {
+		for _, item := range m.items {
+			key := fmt.Sprint(item.Key)
+			if !stringInSlice(keys[level], key) {
+				unknown = append(unknown, path+key)
+			}
+		}
+	}
+	check(tmpl, "template", "")
+	for i, sheet := range tmpl.list("sheets") {
+		sheetPath := fmt.Sprintf("sheets[%d].", i)
+		check(sheet, "sheet", sheetPath)
+		for j, sec := range sheet.list("sections") {
+			secPath := fmt.Sprintf("%ssections[%d].", sheetPath, j)
+			check(sec, "section", secPath)
+			for _, name := range []string{"title_style", "header_style", "data_style"} {
+				if style, ok := sec.get(name).(*templateMap); ok {
+					check(style, "style", secPath+name+".")
+				}
+			}
+			for k, col := range sec.list("columns") {
+				check(col, "column", fmt.Sprintf("%scolumns[%d].", secPath, k))
+			}
+		}
+	}
+	return unknown
+}
+
+// templateMap is a YAML mapping that keeps the order of its keys and can be edited in place.
+type templateMap struct {
+	items yaml.MapSlice
+}
+
+// decodeTemplate decodes a template into nested templateMaps.
+func decodeTemplate(yamlConfig []byte) (*templateMap, error) {
+	if len(yamlConfig) == 0 {
+		return nil, fmt.Errorf("yaml config is empty")
+	}
+	var tmpl yaml.MapSlice
+	if err := yaml.Unmarshal(yamlConfig, &tmpl); err != nil {
+		return nil, fmt.Errorf("decode yaml: %w", err)
+	}
+	return toTemplateMap(tmpl).(*templateMap), nil
+}
+
+func toTemplateMap(v interface{}) interface{} {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		m := &templateMap{items: make(yaml.MapSlice, len(v))}
+		for i, item := range v {
+			m.items[i] = yaml.MapItem{Key: item.Key, Value: toTemplateMap(item.Value)}
+		}
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = toTemplateMap(v[i])
+		}
+	}
+	return v
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (m *templateMap) MarshalYAML() (interface{}, error) {
+	if m.items == nil {
+		return yaml.MapSlice{}, nil
+	}
+	return m.items, nil
+}
+
+func (m *templateMap) get(key string) interface{} {
+	for _, item := range m.items {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+func (m *templateMap) set(key string, value interface{}) {
+	for i := range m.items {
+		if m.items[i].Key == key {
+			m.items[i].Value = value
+			return
+		}
+	}
+	m.items = append(m.items, yaml.MapItem{Key: key, Value: value})
+}
+
+func (m *templateMap) remove(key string) {
+	for i := range m.items {
+		if m.items[i].Key == key {
+			m.items = append(m.items[:i], m.items[i+1:]...)
+			return
+		}
+	}
+}
+
+// child returns the mapping stored under key, adding an empty one if there is none.
+func (m *templateMap) child(key string) *templateMap {
+	if c, ok := m.get(key).(*templateMap); ok {
+		return c
+	}
+	c := &templateMap{}
+	m.set(key, c)
+	return c
+}
+
+// list returns the mappings of the list stored under key.
+func (m *templateMap) list(key string) []*templateMap {
+	items, _ := m.get(key).([]interface{})
+	maps := make([]*templateMap, 0, len(items))
+	for _, item := range items {
+		if c, ok := item.(*templateMap); ok {
+			maps = append(maps, c)
+		}
+	}
+	return maps
+}
+
+func (m *templateMap) isTrue(key string) bool {
+	b, _ := m.get(key).(bool)
+	return b
+}
+
+func stringInSlice(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
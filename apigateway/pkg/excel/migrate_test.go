@@ -0,0 +1,185 @@
+package excel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+const migrateV1Template = `
+workbook_protection:
+  password_key: "wb"
+sheets:
+  - name: "Products"
+    sections:
+      - type: "title"
+        title: "Product Report"
+        col_span: 2
+      - id: "products"
+        title: "Products"
+        show_header: true
+        locked: true
+        header_style:
+          font:
+            bold: true
+          fill:
+            color: "DCE6F1"
+        columns:
+          - field_name: "Name"
+            header: "Name"
+          - field_name: "Price"
+            header: "Price"
+            formatter: "currency"
+  - name: "Notes"
+    sections:
+      - id: "notes"
+        columns:
+          - field_name: "Text"
+`
+
+func TestDetectTemplateSchema(t *testing.T) {
+	cases := []struct {
+		name   string
+		config string
+		schema string
+	}{
+		{"v1", migrateV1Template, SchemaV1},
+		{"v3", "sheets:\n  - name: S\n    sections:\n      - id: s\n        has_filter: true\n", SchemaV3},
+		{"v2", registryProducts, SchemaV2},
+		{"mixed", "encryption:\n  password_key: k\nsheets:\n  - name: S\n    sections:\n      - id: s\n        title_height: 20\n", SchemaV2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			schema, err := DetectTemplateSchema([]byte(tc.config))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.schema, schema)
+		})
+	}
+}
+
+func TestMigrateTemplateV1(t *testing.T) {
+	res, err := MigrateTemplate([]byte(migrateV1Template), "")
+	assert.NoError(t, err)
+	assert.Equal(t, SchemaV1, res.From)
+
+	var tmpl ReportTemplate
+	assert.NoError(t, yaml.Unmarshal(res.YAML, &tmpl))
+	assert.Equal(t, "wb", tmpl.WorkbookProtection.PasswordKey)
+
+	products := tmpl.Sheets[0]
+	if assert.NotNil(t, products.Protection) && assert.NotNil(t, products.Protection.AutoFilter) {
+		assert.False(t, *products.Protection.AutoFilter)
+	}
+	titleOnly := products.Sections[0]
+	if assert.NotNil(t, titleOnly.TitleStyle) {
+		assert.Equal(t, &FontTemplate{}, titleOnly.TitleStyle.Font)
+		assert.Equal(t, &AlignmentTemplate{}, titleOnly.TitleStyle.Alignment)
+	}
+	sec := products.Sections[1]
+	if assert.NotNil(t, sec.TitleStyle) {
+		assert.Nil(t, sec.TitleStyle.Font, "the bold default font still applies")
+		assert.Equal(t, &AlignmentTemplate{}, sec.TitleStyle.Alignment)
+	}
+	if assert.NotNil(t, sec.HeaderStyle) {
+		assert.Equal(t, "DCE6F1", sec.HeaderStyle.Fill.Color)
+		assert.Equal(t, &AlignmentTemplate{}, sec.HeaderStyle.Alignment)
+	}
+	assert.Equal(t, "currency", sec.Columns[1].FormatterName)
+
+	// A sheet without locked cells, titles or headers needs no changes
+	notes := tmpl.Sheets[1]
+	assert.Nil(t, notes.Protection)
+	assert.Nil(t, notes.Sections[0].TitleStyle)
+	assert.Nil(t, notes.Sections[0].HeaderStyle)
+
+	assert.Len(t, res.Changes, 5)
+	assert.Contains(t, res.Changes, "sheets[0].protection.auto_filter: set to false, the v1 default")
+	assert.Contains(t, strings.Join(res.Notes, "\n"), "sheets[0].sections[1]: data fields missing from columns are now added after them")
+	assert.Contains(t, strings.Join(res.Notes, "\n"), `sheets[0].sections[1].columns[1].formatter: "currency"`)
+}
+
+func TestMigrateTemplateKeepsOrder(t *testing.T) {
+	res, err := MigrateTemplate([]byte(migrateV1Template), SchemaV1)
+	assert.NoError(t, err)
+	out := string(res.YAML)
+	assert.True(t, strings.Index(out, "workbook_protection") < strings.Index(out, "sheets"))
+	assert.True(t, strings.Index(out, "field_name: Name") < strings.Index(out, "field_name: Price"))
+
+	// Migrating again changes nothing
+	again, err := MigrateTemplate(res.YAML, SchemaV1)
+	assert.NoError(t, err)
+	assert.Empty(t, again.Changes)
+	assert.Equal(t, string(res.YAML), string(again.YAML))
+}
+
+func TestMigrateTemplateNotes(t *testing.T) {
+	t.Run("keys unknown to the source schema", func(t *testing.T) {
+		config := "sheets:\n  - name: S\n    sections:\n      - id: s\n        as_table: true\n"
+		res, err := MigrateTemplate([]byte(config), SchemaV3)
+		assert.NoError(t, err)
+		assert.Empty(t, res.Changes)
+		assert.Equal(t, []string{"sheets[0].sections[0].as_table was ignored by v3 and now applies"}, res.Notes)
+	})
+
+	t.Run("formatter specs", func(t *testing.T) {
+		config := "sheets:\n  - name: S\n    sections:\n      - id: s\n        columns:\n          - field_name: A\n            formatter: \"trim|upper\"\n          - field_name: B\n            formatter: \"my_formatter\"\n"
+		res, err := MigrateTemplate([]byte(config), SchemaV3)
+		assert.NoError(t, err)
+		if assert.Len(t, res.Notes, 1) {
+			assert.Contains(t, res.Notes[0], "columns[0].formatter")
+		}
+	})
+
+	t.Run("newest schema", func(t *testing.T) {
+		res, err := MigrateTemplate([]byte(registryProducts), SchemaV2)
+		assert.NoError(t, err)
+		assert.Empty(t, res.Changes)
+		assert.Empty(t, res.Notes)
+	})
+}
+
+func TestMigrateTemplateErrors(t *testing.T) {
+	_, err := MigrateTemplate(nil, "")
+	assert.Error(t, err)
+
+	_, err = MigrateTemplate([]byte("sheets: ["), "")
+	assert.Error(t, err)
+
+	_, err = MigrateTemplate([]byte(registryProducts), "v4")
+	assert.EqualError(t, err, `unknown template schema "v4"`)
+}
+
+func TestMigrateTemplateDropsEmptyTitles(t *testing.T) {
+	config := `
+sheets:
+  - name: "Products"
+    sections:
+      - type: "title"
+        title: ""
+      - id: "products"
+        title: ""
+        show_header: true
+        columns:
+          - field_name: "Name"
+            header: "Name"
+`
+	res, err := MigrateTemplate([]byte(config), SchemaV1)
+	assert.NoError(t, err)
+	assert.Contains(t, res.Changes, "sheets[0].sections[0].title: removed the empty title")
+	assert.Contains(t, res.Changes, "sheets[0].sections[1].title: removed the empty title")
+	assert.NotContains(t, string(res.YAML), "title:")
+
+	exporter, err := NewExcelDataExporterFromYamlConfig(string(res.YAML))
+	assert.NoError(t, err)
+	f, err := exporter.BindSectionData("products", []registryProduct{{Name: "Widget"}}).BuildExcel()
+	assert.NoError(t, err)
+
+	// No title rows: the header is the first row
+	rows, err := f.GetRows("Products")
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, rows) {
+		assert.Equal(t, []string{"Name", "Price"}, rows[0])
+	}
+}
@@ -0,0 +1,204 @@
+package excel
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func BenchmarkRenderSections(b *testing.B) {
+	// Create a reasonably large dataset
+	type BenchRow struct {
+		ID    int
+		Name  string
+		Value float64
+		Note  string
+	}
+
+	rows := 1000 // 1000 rows per section for benchmark
+	data := make([]BenchRow, rows)
+	for i := 0; i < rows; i++ {
+		data[i] = BenchRow{
+			ID:    i,
+			Name:  fmt.Sprintf("Item %d", i),
+			Value: float64(i) * 1.5,
+			Note:  "Some long note to simulate content",
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		exporter := NewExcelDataExporter()
+		exporter.AddSheet("BenchSheet").
+			AddSection(&SectionConfig{
+				Title:      "Benchmark Section",
+				Data:       data,
+				ShowHeader: true,
+				Columns: []ColumnConfig{
+					{FieldName: "ID", Header: "ID", Width: 10},
+					{FieldName: "Name", Header: "Name", Width: 30},
+					{FieldName: "Value", Header: "Value", Width: 20},
+					{FieldName: "Note", Header: "Notes", Width: 50},
+				},
+			})
+
+		_, err := exporter.BuildExcel()
+		if err != nil {
+			b.Fatalf("BuildExcel failed: %v", err)
+		}
+	}
+}
+
+// millionCellRow has 10 columns; millionCellRows rows of it make a 1M-cell export.
+type millionCellRow struct {
+	ID       int
+	SKU      string
+	Name     string
+	Category string
+	Price    float64
+	Quantity int
+	Discount float64
+	Active   bool
+	Created  time.Time
+	Note     string
+}
+
+const millionCellRows = 100000
+
+func newMillionCellData() []millionCellRow {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	data := make([]millionCellRow, millionCellRows)
+	for i := range data {
+		data[i] = millionCellRow{
+			ID:       i,
+			SKU:      fmt.Sprintf("SKU-%06d", i),
+			Name:     fmt.Sprintf("Item %d", i),
+			Category: "Hardware",
+			Price:    float64(i) * 1.5,
+			Quantity: i % 100,
+			Discount: 0.1,
+			Active:   i%2 == 0,
+			Created:  created,
+			Note:     "Some long note to simulate content",
+		}
+	}
+	return data
+}
+
+// newMillionCellPointers returns the rows as pointers, the usual shape of repository results.
+func newMillionCellPointers(data []millionCellRow) []*millionCellRow {
+	ptrs := make([]*millionCellRow, len(data))
+	for i := range data {
+		ptrs[i] = &data[i]
+	}
+	return ptrs
+}
+
+func newMillionCellExporter(data interface{}) *ExcelDataExporter {
+	e := NewExcelDataExporter()
+	e.AddSheet("Items").AddSection(&SectionConfig{
+		ID:         "items",
+		Data:       data,
+		ShowHeader: true,
+		Columns: []ColumnConfig{
+			{FieldName: "Price", FormatterName: "currency(USD,2)"},
+			{FieldName: "Discount", FormatterName: "percent"},
+			{FieldName: "Created", FormatterName: "date"},
+		},
+	})
+	return e
+}
+
+// BenchmarkExport1MCells exports 100,000 rows of 10 columns, three of them formatted.
+func BenchmarkExport1MCells(b *testing.B) {
+	data := newMillionCellData()
+
+	b.Run("BuildExcel", func(b *testing.B) {
+		e := newMillionCellExporter(data)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			f, err := e.BuildExcel()
+			if err != nil {
+				b.Fatalf("BuildExcel failed: %v", err)
+			}
+			f.Close()
+		}
+	})
+
+	b.Run("BuildExcelPointers", func(b *testing.B) {
+		e := newMillionCellExporter(newMillionCellPointers(data))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			f, err := e.BuildExcel()
+			if err != nil {
+				b.Fatalf("BuildExcel failed: %v", err)
+			}
+			f.Close()
+		}
+	})
+
+	b.Run("Stream", func(b *testing.B) {
+		e := newMillionCellExporter(data)
+		e.GetSheet("Items").sections[0].Data = nil
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s, err := e.StartStream(io.Discard)
+			if err != nil {
+				b.Fatalf("StartStream failed: %v", err)
+			}
+			if err := s.Write("items", data); err != nil {
+				b.Fatalf("Write failed: %v", err)
+			}
+			if err := s.Close(); err != nil {
+				b.Fatalf("Close failed: %v", err)
+			}
+		}
+	})
+
+	b.Run("CSV", func(b *testing.B) {
+		e := newMillionCellExporter(data)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := e.ToCSV(io.Discard); err != nil {
+				b.Fatalf("ToCSV failed: %v", err)
+			}
+		}
+	})
+}
+
+// BenchmarkCellValues reads the formatted values of 1M cells without writing them, by field name per cell
+// (cellValue) and with the accessor compiled for the section (rowAccessor).
+func BenchmarkCellValues(b *testing.B) {
+	data := newMillionCellData()
+	e := newMillionCellExporter(data)
+	sec := e.GetSheet("Items").sections[0]
+	cols := mergeSectionColumns(sec, data)
+	rows := reflect.ValueOf(data)
+
+	b.Run("cellValue", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for r := 0; r < rows.Len(); r++ {
+				item := rows.Index(r)
+				for _, col := range cols {
+					_ = e.cellValue(col, item)
+				}
+			}
+		}
+	})
+
+	b.Run("rowAccessor", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			accessor := e.compileRowAccessor(cols, data)
+			for r := 0; r < rows.Len(); r++ {
+				item := rows.Index(r)
+				for j := range cols {
+					_ = accessor.value(j, item)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,382 @@
+package excel
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Custom document property names written by the exporter.
+const (
+	PropReportName    = "ReportName"
+	PropConfigVersion = "ConfigVersion"
+	PropConfigHash    = "ConfigHash"
+	PropGeneratedAt   = "GeneratedAt"
+	PropRequestID     = "RequestID"
+	PropRedaction     = "RedactionPolicy"
+	// PropSectionLayout is the prefix of the properties holding the section layout as JSON, split into
+	// SectionLayout1, SectionLayout2, ... because Excel drops text properties longer than 255 characters.
+	PropSectionLayout = "SectionLayout"
+)
+
+// maxPropertyLength is the longest text property value Excel keeps.
+const maxPropertyLength = 255
+
+const (
+	customPropsPath        = "docProps/custom.xml"
+	customPropsContentType = "application/vnd.openxmlformats-officedocument.custom-properties+xml"
+	customPropsRelType     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/custom-properties"
+	customPropsFmtID       = "{D5CDD505-2E9C-101B-9397-08002B2CF9AE}"
+	packageRelsPath        = "_rels/.rels"
+	contentTypesPath       = "[Content_Types].xml"
+)
+
+var (
+	// ErrNoReportMetadata is returned when a workbook was not produced by the exporter.
+	ErrNoReportMetadata = errors.New("workbook has no report metadata")
+	// ErrStaleTemplate is returned when a workbook was produced by a different template version.
+	ErrStaleTemplate = errors.New("workbook was generated from a different template")
+)
+
+// DocumentProperties defines the workbook core properties and extra custom properties.
+// Title defaults to the report name.
+type DocumentProperties struct {
+	Title       string            `yaml:"title"`
+	Subject     string            `yaml:"subject"`
+	Author      string            `yaml:"author"`
+	Keywords    string            `yaml:"keywords"`
+	Category    string            `yaml:"category"`
+	Description string            `yaml:"description"`
+	Custom      map[string]string `yaml:"custom"` // Additional custom properties
+}
+
+// ReportMetadata identifies the template and run that produced a workbook.
+type ReportMetadata struct {
+	ReportName    string
+	ConfigVersion string
+	ConfigHash    string // SHA-256 of the YAML config (empty for programmatic exporters)
+	GeneratedAt   time.Time
+	RequestID     string
+	Redaction     string            // Name of the redaction policy applied, if any
+	Layout        []SectionLayout   // Where the sections were rendered (not written by the streamer)
+	Custom        map[string]string // All other custom properties
+}
+
+// SetDocumentProperties sets the core and custom document properties (Programmatic).
+func (e *ExcelDataExporter) SetDocumentProperties(props *DocumentProperties) *ExcelDataExporter {
+	e.properties = props
+	return e
+}
+
+// SetReportInfo sets the report name and config version embedded in the workbook (Programmatic).
+func (e *ExcelDataExporter) SetReportInfo(name, version string) *ExcelDataExporter {
+	e.reportName = name
+	e.configVersion = version
+	return e
+}
+
+// SetRequestID sets the caller-supplied request ID embedded in the workbook.
+func (e *ExcelDataExporter) SetRequestID(id string) *ExcelDataExporter {
+	e.requestID = id
+	return e
+}
+
+// Metadata returns the metadata that will be embedded in exported workbooks.
+// GeneratedAt is set when the workbook is written.
+func (e *ExcelDataExporter) Metadata() ReportMetadata {
+	return ReportMetadata{
+		ReportName:    e.reportName,
+		ConfigVersion: e.configVersion,
+		ConfigHash:    e.configHash,
+		RequestID:     e.requestID,
+		Redaction:     e.redactionName(),
+	}
+}
+
+// configHash returns the hex SHA-256 of a YAML config.
+func configHash(yamlConfig string) string {
+	sum := sha256.Sum256([]byte(yamlConfig))
+	return hex.EncodeToString(sum[:])
+}
+
+// hasDocumentProperties returns true if there is anything to write.
+func (e *ExcelDataExporter) hasDocumentProperties() bool {
+	return e.properties != nil || e.reportName != "" || e.configVersion != "" || e.configHash != "" || e.requestID != "" || e.redaction != nil
+}
+
+// applyDocumentProperties writes core and custom document properties to the file, with the section layout
+// of the export.
+func (e *ExcelDataExporter) applyDocumentProperties(f *excelize.File, layout []SectionLayout) error {
+	if !e.hasDocumentProperties() {
+		return nil
+	}
+	props := e.properties
+	if props == nil {
+		props = &DocumentProperties{}
+	}
+	generatedAt := time.Now().UTC().Format(time.RFC3339)
+
+	title := props.Title
+	if title == "" {
+		title = e.reportName
+	}
+	if err := f.SetDocProps(&excelize.DocProperties{
+		Title:          title,
+		Subject:        props.Subject,
+		Creator:        props.Author,
+		LastModifiedBy: props.Author,
+		Keywords:       props.Keywords,
+		Category:       props.Category,
+		Description:    props.Description,
+		Identifier:     e.requestID,
+		Version:        e.configVersion,
+		Created:        generatedAt,
+		Modified:       generatedAt,
+	}); err != nil {
+		return fmt.Errorf("set document properties: %w", err)
+	}
+
+	custom := make(map[string]string, len(props.Custom)+6)
+	for k, v := range props.Custom {
+		custom[k] = v
+	}
+	for k, v := range map[string]string{
+		PropReportName:    e.reportName,
+		PropConfigVersion: e.configVersion,
+		PropConfigHash:    e.configHash,
+		PropGeneratedAt:   generatedAt,
+		PropRequestID:     e.requestID,
+		PropRedaction:     e.redactionName(),
+	} {
+		if v != "" {
+			custom[k] = v
+		}
+	}
+	if len(layout) > 0 {
+		layout, err := json.Marshal(layout)
+		if err != nil {
+			return fmt.Errorf("encode section layout: %w", err)
+		}
+		for i := 0; len(layout) > 0; i++ {
+			n := len(layout)
+			if n > maxPropertyLength {
+				n = maxPropertyLength
+				for !utf8.RuneStart(layout[n]) {
+					n--
+				}
+			}
+			custom[fmt.Sprintf("%s%d", PropSectionLayout, i+1)] = string(layout[:n])
+			layout = layout[n:]
+		}
+	}
+	return setCustomProps(f, custom)
+}
+
+// =============================================================================
+// Custom properties part (docProps/custom.xml)
+// =============================================================================
+
+type xlsxCustomProperties struct {
+	XMLName    xml.Name             `xml:"http://schemas.openxmlformats.org/officeDocument/2006/custom-properties Properties"`
+	VT         string               `xml:"xmlns:vt,attr"`
+	Properties []xlsxCustomProperty `xml:"property"`
+}
+
+type xlsxCustomProperty struct {
+	FmtID string `xml:"fmtid,attr"`
+	PID   int    `xml:"pid,attr"`
+	Name  string `xml:"name,attr"`
+	Value string `xml:"vt:lpwstr"`
+}
+
+// decodeCustomProperties reads string properties regardless of namespace prefix.
+type decodeCustomProperties struct {
+	Properties []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"lpwstr"`
+	} `xml:"property"`
+}
+
+// setCustomProps stores the custom properties part and registers it in the package.
+func setCustomProps(f *excelize.File, props map[string]string) error {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	part := xlsxCustomProperties{VT: "http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes"}
+	for i, name := range names {
+		part.Properties = append(part.Properties, xlsxCustomProperty{
+			FmtID: customPropsFmtID,
+			PID:   i + 2, // pid 0 and 1 are reserved
+			Name:  name,
+			Value: props[name],
+		})
+	}
+	output, err := xml.Marshal(part)
+	if err != nil {
+		return err
+	}
+	return storeCustomPropsPart(f, append([]byte(xml.Header), output...))
+}
+
+// storeCustomPropsPart writes docProps/custom.xml to the package, with its content type override in
+// [Content_Types].xml and its relationship in _rels/.rels. It is the only code relying on excelize
+// internals, and is written against excelize v2.8.0, which has no custom properties API: parts are stored
+// in File.Pkg, and the parsed copies of the content types (File.ContentTypes) and package relationships
+// (File.Relationships) are dropped so that excelize re-reads the updated parts. Check it when upgrading
+// excelize; properties_test.go verifies the saved package entries.
+func storeCustomPropsPart(f *excelize.File, part []byte) error {
+	// register inserts entry before the closing tag of a package part, unless the custom properties part
+	// is already registered. cached is excelize's parsed copy of the part, if any.
+	register := func(path string, cached interface{}, closingTag, entry string) error {
+		var content string
+		if cached != nil {
+			output, err := xml.Marshal(cached)
+			if err != nil {
+				return err
+			}
+			content = xml.Header + string(output)
+		} else if raw, ok := f.Pkg.Load(path); ok {
+			content = string(raw.([]byte))
+		}
+		if strings.Contains(content, customPropsPath) {
+			return nil
+		}
+		i := strings.LastIndex(content, closingTag)
+		if i < 0 {
+			return fmt.Errorf("register %s: malformed %s", customPropsPath, path)
+		}
+		f.Pkg.Store(path, []byte(content[:i]+entry+content[i:]))
+		return nil
+	}
+
+	f.Pkg.Store(customPropsPath, part)
+
+	var contentTypes interface{}
+	if f.ContentTypes != nil {
+		contentTypes = f.ContentTypes
+	}
+	if err := register(contentTypesPath, contentTypes, "</Types>",
+		`<Override PartName="/`+customPropsPath+`" ContentType="`+customPropsContentType+`"></Override>`); err != nil {
+		return err
+	}
+	f.ContentTypes = nil
+
+	rels, _ := f.Relationships.Load(packageRelsPath)
+	if err := register(packageRelsPath, rels, "</Relationships>",
+		`<Relationship Id="rIdCustomProps" Type="`+customPropsRelType+`" Target="`+customPropsPath+`"></Relationship>`); err != nil {
+		return err
+	}
+	f.Relationships.Delete(packageRelsPath)
+	return nil
+}
+
+// getCustomProps reads the custom properties of a workbook.
+func getCustomProps(f *excelize.File) (map[string]string, error) {
+	raw, ok := f.Pkg.Load(customPropsPath)
+	if !ok {
+		return nil, nil
+	}
+	var part decodeCustomProperties
+	if err := xml.Unmarshal(raw.([]byte), &part); err != nil {
+		return nil, fmt.Errorf("decode custom properties: %w", err)
+	}
+	props := make(map[string]string, len(part.Properties))
+	for _, p := range part.Properties {
+		props[p.Name] = p.Value
+	}
+	return props, nil
+}
+
+// =============================================================================
+// Reading metadata back
+// =============================================================================
+
+// ReadReportMetadata reads the report metadata embedded by the exporter.
+// Returns ErrNoReportMetadata if the workbook has none.
+func ReadReportMetadata(f *excelize.File) (*ReportMetadata, error) {
+	props, err := getCustomProps(f)
+	if err != nil {
+		return nil, err
+	}
+	if props[PropReportName] == "" && props[PropConfigVersion] == "" && props[PropConfigHash] == "" {
+		return nil, ErrNoReportMetadata
+	}
+
+	meta := &ReportMetadata{Custom: make(map[string]string)}
+	layoutParts := make(map[int]string)
+	for name, value := range props {
+		if strings.HasPrefix(name, PropSectionLayout) {
+			if i, err := strconv.Atoi(name[len(PropSectionLayout):]); err == nil {
+				layoutParts[i] = value
+				continue
+			}
+		}
+		switch name {
+		case PropReportName:
+			meta.ReportName = value
+		case PropConfigVersion:
+			meta.ConfigVersion = value
+		case PropConfigHash:
+			meta.ConfigHash = value
+		case PropRequestID:
+			meta.RequestID = value
+		case PropRedaction:
+			meta.Redaction = value
+		case PropGeneratedAt:
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", PropGeneratedAt, value, err)
+			}
+			meta.GeneratedAt = t
+		default:
+			meta.Custom[name] = value
+		}
+	}
+	if len(layoutParts) > 0 {
+		var sb strings.Builder
+		for i := 1; i <= len(layoutParts); i++ {
+			part, ok := layoutParts[i]
+			if !ok {
+				return nil, fmt.Errorf("invalid %s: part %d is missing", PropSectionLayout, i)
+			}
+			sb.WriteString(part)
+		}
+		if err := json.Unmarshal([]byte(sb.String()), &meta.Layout); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", PropSectionLayout, err)
+		}
+	}
+	return meta, nil
+}
+
+// VerifyTemplate checks that a workbook (e.g. one uploaded for import) was generated from
+// this exporter's template. The report name and config version must match; the config hash
+// is compared when both sides have one. Returns an error wrapping ErrStaleTemplate on mismatch.
+func (e *ExcelDataExporter) VerifyTemplate(f *excelize.File) (*ReportMetadata, error) {
+	meta, err := ReadReportMetadata(f)
+	if err != nil {
+		return nil, err
+	}
+	if meta.ReportName != e.reportName {
+		return meta, fmt.Errorf("%w: report %q, expected %q", ErrStaleTemplate, meta.ReportName, e.reportName)
+	}
+	if meta.ConfigVersion != e.configVersion {
+		return meta, fmt.Errorf("%w: version %q, expected %q", ErrStaleTemplate, meta.ConfigVersion, e.configVersion)
+	}
+	if meta.ConfigHash != "" && e.configHash != "" && meta.ConfigHash != e.configHash {
+		return meta, fmt.Errorf("%w: config hash %s, expected %s", ErrStaleTemplate, meta.ConfigHash, e.configHash)
+	}
+	return meta, nil
+}
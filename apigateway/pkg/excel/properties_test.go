@@ -0,0 +1,187 @@
+package excel
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+const propertiesYAML = `
+name: "Salary Report"
+version: "1.2"
+description: "Monthly salaries"
+properties:
+  author: "HR System"
+  subject: "Payroll"
+  custom:
+    Department: "Finance"
+sheets:
+  - name: "Salaries"
+    sections:
+      - id: "salaries"
+        show_header: true
+        columns:
+          - field_name: "Name"
+            header: "Name"
+`
+
+func TestDocumentPropertiesRoundTrip(t *testing.T) {
+	exporter, err := NewExcelDataExporterFromYamlConfig(propertiesYAML)
+	assert.NoError(t, err)
+	exporter.BindSectionData("salaries", []map[string]interface{}{{"Name": "Alice"}})
+	exporter.SetRequestID("req-123")
+
+	before := time.Now().UTC().Add(-time.Second)
+	data, err := exporter.ToBytes()
+	assert.NoError(t, err)
+
+	assert.Contains(t, zipPart(t, data, "[Content_Types].xml"), "/docProps/custom.xml")
+	assert.Contains(t, zipPart(t, data, "_rels/.rels"), "docProps/custom.xml")
+
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	assert.NoError(t, err)
+	defer f.Close()
+
+	core, err := f.GetDocProps()
+	assert.NoError(t, err)
+	assert.Equal(t, "Salary Report", core.Title)
+	assert.Equal(t, "HR System", core.Creator)
+	assert.Equal(t, "Payroll", core.Subject)
+	assert.Equal(t, "Monthly salaries", core.Description)
+
+	meta, err := ReadReportMetadata(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "Salary Report", meta.ReportName)
+	assert.Equal(t, "1.2", meta.ConfigVersion)
+	assert.Equal(t, configHash(propertiesYAML), meta.ConfigHash)
+	assert.Len(t, meta.ConfigHash, 64)
+	assert.Equal(t, "req-123", meta.RequestID)
+	assert.Equal(t, "Finance", meta.Custom["Department"])
+	assert.False(t, meta.GeneratedAt.Before(before.Truncate(time.Second)))
+
+	// Same template: accepted
+	_, err = exporter.VerifyTemplate(f)
+	assert.NoError(t, err)
+}
+
+// TestCustomPropsPackageEntries pins the package entries written by storeCustomPropsPart, which relies on
+// excelize internals: the custom properties part must be registered exactly once, next to the entries
+// excelize writes, and survive a reopen and save by excelize.
+func TestCustomPropsPackageEntries(t *testing.T) {
+	type contentTypes struct {
+		Overrides []struct {
+			PartName    string `xml:"PartName,attr"`
+			ContentType string `xml:"ContentType,attr"`
+		} `xml:"Override"`
+	}
+	type relationships struct {
+		Relationships []struct {
+			Type   string `xml:"Type,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	check := func(data []byte) {
+		t.Helper()
+		var types contentTypes
+		assert.NoError(t, xml.Unmarshal([]byte(zipPart(t, data, "[Content_Types].xml")), &types))
+		parts := make(map[string][]string)
+		for _, o := range types.Overrides {
+			parts[o.PartName] = append(parts[o.PartName], o.ContentType)
+		}
+		assert.Equal(t, []string{customPropsContentType}, parts["/"+customPropsPath])
+		assert.Len(t, parts["/xl/workbook.xml"], 1)
+
+		var rels relationships
+		assert.NoError(t, xml.Unmarshal([]byte(zipPart(t, data, "_rels/.rels")), &rels))
+		targets := make(map[string][]string)
+		for _, r := range rels.Relationships {
+			targets[r.Type] = append(targets[r.Type], strings.TrimPrefix(r.Target, "/"))
+		}
+		assert.Equal(t, []string{customPropsPath}, targets[customPropsRelType])
+		assert.Len(t, targets["http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument"], 1)
+		assert.Contains(t, zipPart(t, data, customPropsPath), PropReportName)
+	}
+
+	exporter, err := NewExcelDataExporterFromYamlConfig(propertiesYAML)
+	assert.NoError(t, err)
+	exporter.BindSectionData("salaries", []map[string]interface{}{{"Name": "Alice"}})
+	f, err := exporter.BuildExcel()
+	assert.NoError(t, err)
+	// Writing the properties again must not register the part twice
+	assert.NoError(t, exporter.applyDocumentProperties(f, nil))
+	buf, err := f.WriteToBuffer()
+	assert.NoError(t, err)
+	check(buf.Bytes())
+
+	reopened, err := excelize.OpenReader(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	defer reopened.Close()
+	resaved, err := reopened.WriteToBuffer()
+	assert.NoError(t, err)
+	check(resaved.Bytes())
+}
+
+func TestVerifyTemplateRejectsStaleVersion(t *testing.T) {
+	exporter, err := NewExcelDataExporterFromYamlConfig(propertiesYAML)
+	assert.NoError(t, err)
+	data, err := exporter.ToBytes()
+	assert.NoError(t, err)
+
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	assert.NoError(t, err)
+	defer f.Close()
+
+	newer, err := NewExcelDataExporterFromYamlConfig(strings.Replace(propertiesYAML, `version: "1.2"`, `version: "1.3"`, 1))
+	assert.NoError(t, err)
+	meta, err := newer.VerifyTemplate(f)
+	assert.True(t, errors.Is(err, ErrStaleTemplate), "expected ErrStaleTemplate, got %v", err)
+	assert.Equal(t, "1.2", meta.ConfigVersion)
+
+	// Same version but edited config
+	edited, err := NewExcelDataExporterFromYamlConfig(strings.Replace(propertiesYAML, `header: "Name"`, `header: "Employee"`, 1))
+	assert.NoError(t, err)
+	_, err = edited.VerifyTemplate(f)
+	assert.True(t, errors.Is(err, ErrStaleTemplate), "expected ErrStaleTemplate, got %v", err)
+}
+
+func TestReadReportMetadataMissing(t *testing.T) {
+	exporter := NewExcelDataExporter()
+	exporter.AddSheet("Plain").AddSection(&SectionConfig{
+		Data:    []map[string]interface{}{{"A": 1}},
+		Columns: []ColumnConfig{{FieldName: "A"}},
+	})
+	f, err := exporter.BuildExcel()
+	assert.NoError(t, err)
+
+	_, err = ReadReportMetadata(f)
+	assert.True(t, errors.Is(err, ErrNoReportMetadata))
+}
+
+func TestDocumentPropertiesStreamingEncrypted(t *testing.T) {
+	exporter, err := NewExcelDataExporterFromYamlConfig(propertiesYAML + `
+encryption:
+  password_key: "open"
+`)
+	assert.NoError(t, err)
+	exporter.SetPassword("open", "pw").SetRequestID("req-456")
+
+	buf := new(bytes.Buffer)
+	streamer, err := exporter.StartStream(buf)
+	assert.NoError(t, err)
+	assert.NoError(t, streamer.Write("salaries", []map[string]interface{}{{"Name": "Bob"}}))
+	assert.NoError(t, streamer.Close())
+
+	f, err := excelize.OpenReader(bytes.NewReader(buf.Bytes()), excelize.Options{Password: "pw"})
+	assert.NoError(t, err)
+	defer f.Close()
+
+	meta, err := exporter.VerifyTemplate(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "req-456", meta.RequestID)
+}
@@ -0,0 +1,191 @@
+package excel
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ProtectionConfig controls sheet protection.
+// Passwords are never stored in the template: PasswordKey names a password
+// supplied at runtime via SetPassword. Unset options keep the exporter defaults.
+type ProtectionConfig struct {
+	PasswordKey         string `yaml:"password_key"`
+	Algorithm           string `yaml:"algorithm"` // e.g. "SHA-512"; empty uses the legacy Excel hash
+	SelectLockedCells   *bool  `yaml:"select_locked_cells"`
+	SelectUnlockedCells *bool  `yaml:"select_unlocked_cells"`
+	FormatCells         *bool  `yaml:"format_cells"`
+	FormatColumns       *bool  `yaml:"format_columns"`
+	FormatRows          *bool  `yaml:"format_rows"`
+	InsertColumns       *bool  `yaml:"insert_columns"`
+	InsertRows          *bool  `yaml:"insert_rows"`
+	InsertHyperlinks    *bool  `yaml:"insert_hyperlinks"`
+	DeleteColumns       *bool  `yaml:"delete_columns"`
+	DeleteRows          *bool  `yaml:"delete_rows"`
+	Sort                *bool  `yaml:"sort"`
+	AutoFilter          *bool  `yaml:"auto_filter"`
+	PivotTables         *bool  `yaml:"pivot_tables"`
+}
+
+// WorkbookProtectionConfig protects the workbook structure (add/delete/rename/move sheets).
+type WorkbookProtectionConfig struct {
+	PasswordKey   string `yaml:"password_key"`
+	Algorithm     string `yaml:"algorithm"`
+	LockStructure *bool  `yaml:"lock_structure"` // Default true
+	LockWindows   bool   `yaml:"lock_windows"`
+}
+
+// EncryptionConfig encrypts the whole file with an open password.
+type EncryptionConfig struct {
+	PasswordKey string `yaml:"password_key"`
+}
+
+// defaultSheetProtection is the permission set applied to sheets with locked cells.
+var defaultSheetProtection = excelize.SheetProtectionOptions{
+	FormatCells:         false,
+	FormatColumns:       true,
+	FormatRows:          true,
+	InsertColumns:       false,
+	InsertRows:          false,
+	InsertHyperlinks:    false,
+	DeleteColumns:       false,
+	DeleteRows:          false,
+	Sort:                false,
+	AutoFilter:          true,
+	PivotTables:         false,
+	SelectLockedCells:   true,
+	SelectUnlockedCells: true,
+}
+
+func boolOr(v *bool, def bool) bool {
+	if v != nil {
+		return *v
+	}
+	return def
+}
+
+// options returns the excelize protection options with config overrides applied.
+func (p *ProtectionConfig) options(password string) *excelize.SheetProtectionOptions {
+	opts := defaultSheetProtection
+	opts.Password = password
+	if p == nil {
+		return &opts
+	}
+	if password != "" {
+		opts.AlgorithmName = p.Algorithm
+	}
+	opts.SelectLockedCells = boolOr(p.SelectLockedCells, opts.SelectLockedCells)
+	opts.SelectUnlockedCells = boolOr(p.SelectUnlockedCells, opts.SelectUnlockedCells)
+	opts.FormatCells = boolOr(p.FormatCells, opts.FormatCells)
+	opts.FormatColumns = boolOr(p.FormatColumns, opts.FormatColumns)
+	opts.FormatRows = boolOr(p.FormatRows, opts.FormatRows)
+	opts.InsertColumns = boolOr(p.InsertColumns, opts.InsertColumns)
+	opts.InsertRows = boolOr(p.InsertRows, opts.InsertRows)
+	opts.InsertHyperlinks = boolOr(p.InsertHyperlinks, opts.InsertHyperlinks)
+	opts.DeleteColumns = boolOr(p.DeleteColumns, opts.DeleteColumns)
+	opts.DeleteRows = boolOr(p.DeleteRows, opts.DeleteRows)
+	opts.Sort = boolOr(p.Sort, opts.Sort)
+	opts.AutoFilter = boolOr(p.AutoFilter, opts.AutoFilter)
+	opts.PivotTables = boolOr(p.PivotTables, opts.PivotTables)
+	return &opts
+}
+
+// SetPassword supplies the runtime value of a password referenced by password_key.
+func (e *ExcelDataExporter) SetPassword(key, password string) *ExcelDataExporter {
+	e.passwords[key] = password
+	return e
+}
+
+// ProtectWorkbook enables workbook structure protection (Programmatic).
+func (e *ExcelDataExporter) ProtectWorkbook(cfg *WorkbookProtectionConfig) *ExcelDataExporter {
+	e.workbookProtection = cfg
+	return e
+}
+
+// Encrypt enables whole-file encryption with the open password named by cfg.PasswordKey (Programmatic).
+func (e *ExcelDataExporter) Encrypt(cfg *EncryptionConfig) *ExcelDataExporter {
+	e.encryption = cfg
+	return e
+}
+
+// Protect sets the protection options of the sheet. A configured sheet is always protected.
+func (sb *SheetBuilder) Protect(cfg *ProtectionConfig) *SheetBuilder {
+	sb.protection = cfg
+	return sb
+}
+
+// password looks up a runtime password. An empty key means no password.
+func (e *ExcelDataExporter) password(key string) (string, error) {
+	if key == "" {
+		return "", nil
+	}
+	pw, ok := e.passwords[key]
+	if !ok || pw == "" {
+		return "", fmt.Errorf("password %q was not supplied", key)
+	}
+	return pw, nil
+}
+
+// sectionsHaveLockedCells returns true if any section or column is locked.
+func sectionsHaveLockedCells(sections []*SectionConfig) bool {
+	for _, sec := range sections {
+		if sec.Locked {
+			return true
+		}
+		for _, col := range sec.Columns {
+			if col.Locked != nil && *col.Locked {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// protectSheet protects a sheet that has locked cells or an explicit protection config.
+func (e *ExcelDataExporter) protectSheet(f *excelize.File, sheet string, cfg *ProtectionConfig, hasLockedCells bool) error {
+	if cfg == nil && !hasLockedCells {
+		return nil
+	}
+	var key string
+	if cfg != nil {
+		key = cfg.PasswordKey
+	}
+	pw, err := e.password(key)
+	if err != nil {
+		return fmt.Errorf("protect sheet %s: %w", sheet, err)
+	}
+	return f.ProtectSheet(sheet, cfg.options(pw))
+}
+
+// protectWorkbook applies workbook structure protection, if configured.
+func (e *ExcelDataExporter) protectWorkbook(f *excelize.File) error {
+	cfg := e.workbookProtection
+	if cfg == nil {
+		return nil
+	}
+	pw, err := e.password(cfg.PasswordKey)
+	if err != nil {
+		return fmt.Errorf("protect workbook: %w", err)
+	}
+	return f.ProtectWorkbook(&excelize.WorkbookProtectionOptions{
+		Password:      pw,
+		AlgorithmName: cfg.Algorithm,
+		LockStructure: boolOr(cfg.LockStructure, true),
+		LockWindows:   cfg.LockWindows,
+	})
+}
+
+// saveOptions returns the options used when writing the file (the open password, if encrypted).
+func (e *ExcelDataExporter) saveOptions() ([]excelize.Options, error) {
+	if e.encryption == nil {
+		return nil, nil
+	}
+	if e.encryption.PasswordKey == "" {
+		return nil, fmt.Errorf("encryption requires password_key")
+	}
+	pw, err := e.password(e.encryption.PasswordKey)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt workbook: %w", err)
+	}
+	return []excelize.Options{{Password: pw}}, nil
+}
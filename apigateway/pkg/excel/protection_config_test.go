@@ -0,0 +1,172 @@
+package excel
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+// zipPart returns the content of a part of an (unencrypted) xlsx file.
+func zipPart(t *testing.T, data []byte, name string) string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	for _, zf := range zr.File {
+		if zf.Name == name {
+			rc, err := zf.Open()
+			if err != nil {
+				t.Fatalf("open %s: %v", name, err)
+			}
+			defer rc.Close()
+			b, _ := io.ReadAll(rc)
+			return string(b)
+		}
+	}
+	t.Fatalf("part %s not found", name)
+	return ""
+}
+
+const protectedSalaryYAML = `
+workbook_protection:
+  password_key: "structure"
+encryption:
+  password_key: "open"
+sheets:
+  - name: "Salaries"
+    protection:
+      password_key: "sheet"
+      algorithm: "SHA-512"
+      sort: true
+      auto_filter: false
+      insert_rows: true
+      select_locked_cells: false
+    sections:
+      - id: "salaries"
+        locked: true
+        show_header: true
+        columns:
+          - field_name: "Name"
+            header: "Name"
+          - field_name: "Amount"
+            header: "Amount"
+`
+
+func TestProtectionOptionsFromYAML(t *testing.T) {
+	yamlConfig := `
+sheets:
+  - name: "Employees"
+    protection:
+      password_key: "sheet"
+      sort: true
+      auto_filter: false
+      insert_rows: true
+      select_locked_cells: false
+    sections:
+      - id: "employees"
+        locked: true
+        columns:
+          - field_name: "Name"
+`
+	exporter, err := NewExcelDataExporterFromYamlConfig(yamlConfig)
+	assert.NoError(t, err)
+	exporter.BindSectionData("employees", []map[string]interface{}{{"Name": "Alice"}})
+	exporter.SetPassword("sheet", "s3cret")
+
+	data, err := exporter.ToBytes()
+	assert.NoError(t, err)
+
+	// Excel stores protected (disallowed) actions as true
+	sheetXML := zipPart(t, data, "xl/worksheets/sheet1.xml")
+	assert.Contains(t, sheetXML, `sort="false"`)
+	assert.Contains(t, sheetXML, `autoFilter="true"`)
+	assert.Contains(t, sheetXML, `insertRows="false"`)
+	assert.Contains(t, sheetXML, `selectLockedCells="true"`)
+	assert.Contains(t, sheetXML, `password="`)
+	assert.NotContains(t, sheetXML, "s3cret")
+
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.Error(t, f.UnprotectSheet("Employees", "wrong"))
+	assert.NoError(t, f.UnprotectSheet("Employees", "s3cret"))
+}
+
+func TestProtectionWithoutLockedCells(t *testing.T) {
+	exporter := NewExcelDataExporter()
+	exporter.AddSheet("Open").
+		Protect(&ProtectionConfig{}).
+		AddSection(&SectionConfig{
+			Data:    []map[string]interface{}{{"A": 1}},
+			Columns: []ColumnConfig{{FieldName: "A"}},
+		})
+
+	data, err := exporter.ToBytes()
+	assert.NoError(t, err)
+	assert.Contains(t, zipPart(t, data, "xl/worksheets/sheet1.xml"), "<sheetProtection")
+}
+
+func TestWorkbookProtectionAndEncryption(t *testing.T) {
+	exporter, err := NewExcelDataExporterFromYamlConfig(protectedSalaryYAML)
+	assert.NoError(t, err)
+	exporter.BindSectionData("salaries", []map[string]interface{}{{"Name": "Alice", "Amount": 5000}})
+	exporter.SetPassword("sheet", "sheet-pw").
+		SetPassword("structure", "structure-pw").
+		SetPassword("open", "open-pw")
+
+	data, err := exporter.ToBytes()
+	assert.NoError(t, err)
+
+	_, err = excelize.OpenReader(bytes.NewReader(data))
+	assert.Error(t, err, "encrypted file should not open without a password")
+
+	f, err := excelize.OpenReader(bytes.NewReader(data), excelize.Options{Password: "open-pw"})
+	assert.NoError(t, err)
+	defer f.Close()
+
+	v, _ := f.GetCellValue("Salaries", "A2")
+	assert.Equal(t, "Alice", v)
+	assert.Error(t, f.UnprotectWorkbook("wrong"))
+	assert.NoError(t, f.UnprotectWorkbook("structure-pw"))
+	assert.NoError(t, f.UnprotectSheet("Salaries", "sheet-pw"))
+}
+
+func TestMissingRuntimePassword(t *testing.T) {
+	exporter, err := NewExcelDataExporterFromYamlConfig(protectedSalaryYAML)
+	assert.NoError(t, err)
+	exporter.SetPassword("sheet", "sheet-pw").SetPassword("structure", "structure-pw")
+
+	_, err = exporter.ToBytes()
+	if assert.Error(t, err) {
+		assert.True(t, strings.Contains(err.Error(), `"open"`), err.Error())
+	}
+}
+
+func TestStreamingProtectionAndEncryption(t *testing.T) {
+	exporter, err := NewExcelDataExporterFromYamlConfig(protectedSalaryYAML)
+	assert.NoError(t, err)
+	exporter.SetPassword("sheet", "sheet-pw").
+		SetPassword("structure", "structure-pw").
+		SetPassword("open", "open-pw")
+
+	buf := new(bytes.Buffer)
+	streamer, err := exporter.StartStream(buf)
+	assert.NoError(t, err)
+	assert.NoError(t, streamer.Write("salaries", []map[string]interface{}{{"Name": "Bob", "Amount": 4000}}))
+	assert.NoError(t, streamer.Close())
+
+	f, err := excelize.OpenReader(bytes.NewReader(buf.Bytes()), excelize.Options{Password: "open-pw"})
+	assert.NoError(t, err)
+	defer f.Close()
+
+	v, _ := f.GetCellValue("Salaries", "A2")
+	assert.Equal(t, "Bob", v)
+	assert.NoError(t, f.UnprotectSheet("Salaries", "sheet-pw"))
+	assert.NoError(t, f.UnprotectWorkbook("structure-pw"))
+}
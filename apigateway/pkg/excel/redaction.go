@@ -0,0 +1,324 @@
+package excel
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Redaction actions of a RedactionPolicy.
+const (
+	RedactFull = "full" // Export the value as is
+	RedactMask = "mask" // Keep the first letter of each word ("John Doe" -> "J*** D**"); other values become "****"
+	RedactHash = "hash" // Replace the value with a stable pseudonym (HMAC-SHA256, see RedactionPolicy.HashKey)
+	RedactYear = "year" // Keep only the year of dates
+	RedactDrop = "drop" // Remove the column from the export
+)
+
+// ErrNoRedactionRoles is returned by SetRole for templates without redaction roles.
+var ErrNoRedactionRoles = errors.New("no redaction roles configured")
+
+// redactedMask replaces masked values other than strings.
+const redactedMask = "****"
+
+// RedactionPolicy maps the sensitivity tags of columns (ColumnConfig.Sensitivity) to redaction actions.
+// Columns without a tag are always exported as is.
+type RedactionPolicy struct {
+	Name  string            `yaml:"-"`     // Written to the RedactionPolicy document property
+	Rules map[string]string `yaml:"rules"` // Sensitivity tag -> action (full, mask, hash, year, drop)
+	// Default is the action for tagged columns without a rule (default mask, so new tags are never exported in full by accident).
+	Default string `yaml:"default"`
+	// HashKey is the password key (see SetPassword) of the HMAC key for hashed values. Without it values are
+	// hashed with plain SHA-256, which can be reversed by guessing for values such as birth dates.
+	HashKey string `yaml:"hash_key"`
+}
+
+// RedactionConfig defines the redaction policies of a template and the policy of each role, so the same
+// template produces e.g. a full HR version and a redacted manager version:
+//
+//	redaction:
+//	  roles:
+//	    hr: full            # "full" needs no policy
+//	    manager: manager
+//	  policies:
+//	    manager:
+//	      rules: {pii: mask, birth_date: year, salary: drop, employee_id: hash}
+type RedactionConfig struct {
+	Policies map[string]*RedactionPolicy `yaml:"policies"`
+	Roles    map[string]string           `yaml:"roles"` // Role -> policy name
+}
+
+// Validate checks the actions of the policy.
+func (p *RedactionPolicy) Validate() error {
+	if err := validateRedactAction(p.Default); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	tags := make([]string, 0, len(p.Rules))
+	for tag := range p.Rules {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		if err := validateRedactAction(p.Rules[tag]); err != nil {
+			return fmt.Errorf("rule %s: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+func validateRedactAction(action string) error {
+	switch action {
+	case "", RedactFull, RedactMask, RedactHash, RedactYear, RedactDrop:
+		return nil
+	}
+	return fmt.Errorf("unknown redaction action %q", action)
+}
+
+// Action returns the redaction action for a sensitivity tag.
+func (p *RedactionPolicy) Action(sensitivity string) string {
+	if p == nil || sensitivity == "" {
+		return RedactFull
+	}
+	if action, ok := p.Rules[sensitivity]; ok && action != "" {
+		return action
+	}
+	if p.Default != "" {
+		return p.Default
+	}
+	return RedactMask
+}
+
+// Validate checks the policies and that every role refers to a policy.
+func (c *RedactionConfig) Validate() error {
+	for name, p := range c.Policies {
+		if p == nil {
+			return fmt.Errorf("redaction policy %s is empty", name)
+		}
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("redaction policy %s: %w", name, err)
+		}
+	}
+	for role, name := range c.Roles {
+		if _, ok := c.Policies[name]; !ok && name != RedactFull {
+			return fmt.Errorf("role %s: unknown redaction policy %q", role, name)
+		}
+	}
+	return nil
+}
+
+// PolicyForRole returns a copy of the redaction policy of a role, or nil for roles that see full values.
+func (c *RedactionConfig) PolicyForRole(role string) (*RedactionPolicy, error) {
+	if c == nil {
+		return nil, ErrNoRedactionRoles
+	}
+	name, ok := c.Roles[role]
+	if !ok {
+		return nil, fmt.Errorf("unknown role %q", role)
+	}
+	if name == RedactFull {
+		return nil, nil
+	}
+	p, ok := c.Policies[name]
+	if !ok {
+		return nil, fmt.Errorf("role %s: unknown redaction policy %q", role, name)
+	}
+	policy := *p
+	if policy.Name == "" {
+		policy.Name = name
+	}
+	return &policy, nil
+}
+
+// RestrictedPolicy returns the most restrictive policy: every tagged column is dropped.
+func RestrictedPolicy() *RedactionPolicy {
+	return &RedactionPolicy{Name: "restricted", Default: RedactDrop}
+}
+
+// SetRedactionConfig sets the redaction policies and roles (Programmatic; YAML: redaction).
+func (e *ExcelDataExporter) SetRedactionConfig(cfg *RedactionConfig) *ExcelDataExporter {
+	e.redactionConfig = cfg
+	return e
+}
+
+// SetRole applies the redaction policy of a role defined in the redaction config.
+func (e *ExcelDataExporter) SetRole(role string) error {
+	p, err := e.redactionConfig.PolicyForRole(role)
+	if err != nil {
+		return err
+	}
+	e.SetRedactionPolicy(p)
+	return nil
+}
+
+// SetRestrictedRole applies RestrictedPolicy, for callers whose role is not known. Like SetRole it returns
+// ErrNoRedactionRoles for templates without redaction roles, which are exported as is.
+func (e *ExcelDataExporter) SetRestrictedRole() error {
+	if e.redactionConfig == nil {
+		return ErrNoRedactionRoles
+	}
+	e.SetRedactionPolicy(RestrictedPolicy())
+	return nil
+}
+
+// SetRedactionPolicy sets the redaction policy of the export (nil: no redaction). It applies to every output
+// (BuildExcel, streaming, CSV, JSON, HTML) started afterwards. Each export removes the columns the policy
+// drops from its copy of the sections, so the configured sections keep every column.
+func (e *ExcelDataExporter) SetRedactionPolicy(p *RedactionPolicy) *ExcelDataExporter {
+	e.redaction = p
+	return e
+}
+
+// RedactionPolicy returns the redaction policy of the export, or nil.
+func (e *ExcelDataExporter) RedactionPolicy() *RedactionPolicy {
+	return e.redaction
+}
+
+// redactionName returns the name of the redaction policy for the report metadata.
+func (e *ExcelDataExporter) redactionName() string {
+	if e.redaction == nil {
+		return ""
+	}
+	if e.redaction.Name == "" {
+		return "custom"
+	}
+	return e.redaction.Name
+}
+
+// redactStrictness orders the redaction actions from the least to the most restrictive.
+var redactStrictness = map[string]int{RedactFull: 0, RedactYear: 1, RedactMask: 2, RedactHash: 3, RedactDrop: 4}
+
+// redactSection removes the columns dropped by the redaction policy from the copy of a section made for an
+// export (see newRun). Their field names are remembered, so they are not detected from the data again
+// (see mergeSectionColumns).
+// Expression columns inherit the sensitivity of the most restricted column they read, so a derived
+// value (e.g. "Salary * 12") is redacted, or dropped, like its source.
+func (e *ExcelDataExporter) redactSection(sec *SectionConfig) {
+	if e.redaction == nil {
+		return
+	}
+	tags := make(map[string]string, len(sec.Columns))
+	for _, col := range sec.Columns {
+		if col.Sensitivity != "" {
+			tags[col.FieldName] = col.Sensitivity
+		}
+	}
+	for i, col := range sec.Columns {
+		if col.Expr == "" || len(tags) == 0 {
+			continue
+		}
+		strictest := e.redaction.Action(col.Sensitivity)
+		for _, field := range exprFields(col.Expr) {
+			tag, ok := tags[field]
+			if !ok {
+				tag, ok = tags[strings.SplitN(field, ".", 2)[0]]
+			}
+			if action := e.redaction.Action(tag); ok && redactStrictness[action] > redactStrictness[strictest] {
+				sec.Columns[i].Sensitivity, strictest = tag, action
+			}
+		}
+	}
+
+	kept := sec.Columns[:0]
+	for _, col := range sec.Columns {
+		if e.redaction.Action(col.Sensitivity) != RedactDrop {
+			kept = append(kept, col)
+			continue
+		}
+		if sec.dropped == nil {
+			sec.dropped = make(map[string]bool)
+		}
+		sec.dropped[col.FieldName] = true
+	}
+	sec.Columns = kept
+}
+
+// mergeSectionColumns merges the columns of a section with the fields detected from data,
+// leaving out the columns dropped by the redaction policy.
+func mergeSectionColumns(sec *SectionConfig, data interface{}) []ColumnConfig {
+	cols := mergeColumns(data, sec.Columns)
+	if len(sec.dropped) == 0 {
+		return cols
+	}
+	kept := make([]ColumnConfig, 0, len(cols))
+	for _, col := range cols {
+		if !sec.dropped[col.FieldName] {
+			kept = append(kept, col)
+		}
+	}
+	return kept
+}
+
+// redactValue redacts a raw column value with an action other than full or drop.
+func (e *ExcelDataExporter) redactValue(action string, val interface{}) interface{} {
+	rv := reflect.ValueOf(val)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	val = rv.Interface()
+
+	switch action {
+	case RedactYear:
+		if t, err := timeValue(val); err == nil {
+			if t.IsZero() {
+				return nil
+			}
+			return t.Year()
+		}
+		return redactedMask
+	case RedactHash:
+		text := fmt.Sprint(val)
+		if t, ok := val.(time.Time); ok {
+			text = t.Format(time.RFC3339)
+		}
+		key, err := e.password(e.redaction.HashKey)
+		if err != nil {
+			return fmt.Sprintf("Error: hash key: %v", err)
+		}
+		if key == "" {
+			sum := sha256.Sum256([]byte(text))
+			return hex.EncodeToString(sum[:6])
+		}
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(text))
+		return hex.EncodeToString(mac.Sum(nil)[:6])
+	}
+
+	if s, ok := val.(string); ok {
+		return maskWords(s)
+	}
+	return redactedMask
+}
+
+// maskWords keeps the first letter of each word and replaces the other letters and digits with "*".
+func maskWords(s string) string {
+	var sb strings.Builder
+	first := true
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			first = true
+			sb.WriteRune(r)
+		case first:
+			first = false
+			sb.WriteRune(r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteByte('*')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,255 @@
+package excel
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+const redactionConfig = `
+name: "Staff"
+redaction:
+  roles:
+    hr: full
+    manager: manager
+  policies:
+    manager:
+      hash_key: "pseudonyms"
+      rules:
+        pii: mask
+        birth_date: year
+        salary: drop
+        employee_id: hash
+sheets:
+  - name: "Staff"
+    sections:
+      - id: "staff"
+        show_header: true
+        columns:
+          - field_name: "ID"
+            header: "ID"
+            sensitivity: "employee_id"
+          - field_name: "Name"
+            header: "Name"
+            sensitivity: "pii"
+          - field_name: "BirthDate"
+            header: "Born"
+            sensitivity: "birth_date"
+            formatter: "date"
+          - field_name: "Salary"
+            header: "Salary"
+            sensitivity: "salary"
+          - field_name: "Phone"
+            header: "Phone"
+            sensitivity: "contact"
+          - field_name: "Dept"
+            header: "Department"
+`
+
+type redactedEmployee struct {
+	ID        int
+	Name      string
+	BirthDate time.Time
+	Salary    float64
+	Phone     string
+	Dept      string
+}
+
+var redactionStaff = []redactedEmployee{
+	{7, "John Doe", time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC), 5200, "555-0100", "R&D"},
+	{8, "Mary Ann", time.Time{}, 6100, "", "Sales"},
+}
+
+func redactionExporter(t *testing.T, role string) *ExcelDataExporter {
+	e, err := NewExcelDataExporterFromYamlConfig(redactionConfig)
+	assert.NoError(t, err)
+	e.SetPassword("pseudonyms", "s3cret")
+	assert.NoError(t, e.SetRole(role))
+	return e
+}
+
+func TestRedactionRoles(t *testing.T) {
+	hr := redactionExporter(t, "hr")
+	hr.BindSectionData("staff", redactionStaff)
+	f, err := hr.BuildExcel()
+	assert.NoError(t, err)
+	defer f.Close()
+	rows, _ := f.GetRows("Staff")
+	assert.Equal(t, []string{"ID", "Name", "Born", "Salary", "Phone", "Department"}, rows[0])
+	assert.Equal(t, []string{"7", "John Doe", "1990-05-17", "5200", "555-0100", "R&D"}, rows[1])
+
+	manager := redactionExporter(t, "manager")
+	manager.BindSectionData("staff", redactionStaff)
+	f, err = manager.BuildExcel()
+	assert.NoError(t, err)
+	defer f.Close()
+	rows, _ = f.GetRows("Staff")
+	// Salary is dropped and not detected from the data again; unknown tags are masked
+	assert.Equal(t, []string{"ID", "Name", "Born", "Phone", "Department"}, rows[0])
+	assert.Len(t, rows[1][0], 12)
+	assert.Equal(t, []string{"J*** D**", "1990", "5**-****", "R&D"}, rows[1][1:])
+	assert.Equal(t, []string{"M*** A**", "", "", "Sales"}, rows[2][1:])
+
+	meta, err := ReadReportMetadata(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "manager", meta.Redaction)
+
+	// Hashes are stable pseudonyms that depend on the hash key
+	again := redactionExporter(t, "manager")
+	assert.Equal(t, rows[1][0], again.redactValue(RedactHash, 7))
+	again.SetPassword("pseudonyms", "other")
+	assert.NotEqual(t, rows[1][0], again.redactValue(RedactHash, 7))
+}
+
+func TestRedactionAppliesToAllOutputs(t *testing.T) {
+	var csv bytes.Buffer
+	e := redactionExporter(t, "manager")
+	e.BindSectionData("staff", redactionStaff)
+	assert.NoError(t, e.ToCSV(&csv))
+	assert.Contains(t, csv.String(), "J*** D**,1990,5**-****,R&D")
+
+	var js bytes.Buffer
+	assert.NoError(t, e.ToJSON(&js))
+	assert.Contains(t, js.String(), `"Name":"J*** D**"`)
+
+	var html bytes.Buffer
+	assert.NoError(t, e.ToHTML(&html, HTMLOptions{}))
+
+	for _, out := range []string{csv.String(), js.String(), html.String()} {
+		assert.NotContains(t, out, "John Doe")
+		assert.NotContains(t, out, "5200")
+		assert.NotContains(t, out, "1990-05-17")
+	}
+
+	// Streaming, with the policy applied before the stream starts
+	var buf bytes.Buffer
+	e = redactionExporter(t, "manager")
+	streamer, err := e.StartStream(&buf)
+	assert.NoError(t, err)
+	assert.NoError(t, streamer.Write("staff", redactionStaff))
+	assert.NoError(t, streamer.Close())
+
+	f, err := excelize.OpenReader(&buf)
+	assert.NoError(t, err)
+	defer f.Close()
+	rows, _ := f.GetRows("Staff")
+	assert.Equal(t, []string{"ID", "Name", "Born", "Phone", "Department"}, rows[0])
+	assert.Equal(t, []string{"J*** D**", "1990", "5**-****", "R&D"}, rows[1][1:])
+}
+
+func TestRedactionFluent(t *testing.T) {
+	e := NewExcelDataExporter().SetRedactionPolicy(&RedactionPolicy{Name: "external", Rules: map[string]string{"pii": RedactDrop}})
+	e.AddSheet("People").AddSection(&SectionConfig{
+		ShowHeader: true,
+		Data:       redactionStaff,
+		Columns: []ColumnConfig{
+			{FieldName: "Name", Header: "Name", Sensitivity: "pii"},
+			{FieldName: "Tenure", Header: "Note", Expr: `"Employee " + ID`},
+			{FieldName: "Pay", Header: "Pay", Expr: "Salary * 12", Sensitivity: "salary"},
+		},
+	})
+	f, err := e.BuildExcel()
+	assert.NoError(t, err)
+	defer f.Close()
+	rows, _ := f.GetRows("People")
+	assert.Equal(t, []string{"Note", "Pay"}, rows[0][:2])
+	assert.Equal(t, []string{"Employee 7", "****"}, rows[1][:2])
+	assert.NotContains(t, strings.Join(rows[0], ","), "Name")
+}
+
+func TestRedactionDerivedColumns(t *testing.T) {
+	e := NewExcelDataExporter().SetRedactionPolicy(&RedactionPolicy{Rules: map[string]string{"pii": RedactMask, "salary": RedactDrop}})
+	e.AddSheet("People").AddSection(&SectionConfig{
+		ShowHeader: true,
+		Data:       redactionStaff,
+		Columns: []ColumnConfig{
+			{FieldName: "Name", Header: "Name", Sensitivity: "pii"},
+			{FieldName: "Salary", Header: "Salary", Sensitivity: "salary"},
+			{FieldName: "Annual", Header: "Annual", Expr: "Salary * 12"},
+			{FieldName: "Greeting", Header: "Greeting", Expr: `"Hi " + Name`},
+			{FieldName: "Team", Header: "Team", Expr: `Dept + "!"`},
+		},
+	})
+	f, err := e.BuildExcel()
+	assert.NoError(t, err)
+	defer f.Close()
+	rows, _ := f.GetRows("People")
+	// Derived columns are redacted like the columns they read: Annual is dropped with Salary
+	assert.Equal(t, []string{"Name", "Greeting", "Team"}, rows[0][:3])
+	assert.Equal(t, []string{"J*** D**", "H* J*** D**", "R&D!"}, rows[1][:3])
+
+	var csv, js bytes.Buffer
+	assert.NoError(t, e.ToCSV(&csv))
+	assert.NoError(t, e.ToJSON(&js))
+	for _, out := range []string{strings.Join(rows[1], ","), csv.String(), js.String()} {
+		assert.NotContains(t, out, "62400")
+		assert.NotContains(t, out, "John")
+	}
+}
+
+func TestRedactionRestrictedRole(t *testing.T) {
+	e, err := NewExcelDataExporterFromYamlConfig(redactionConfig)
+	assert.NoError(t, err)
+	assert.NoError(t, e.SetRestrictedRole())
+	e.BindSectionData("staff", redactionStaff)
+	f, err := e.BuildExcel()
+	assert.NoError(t, err)
+	defer f.Close()
+	rows, _ := f.GetRows("Staff")
+	// Every tagged column is dropped, whatever the configured policies allow
+	assert.Equal(t, [][]string{{"Department"}, {"R&D"}, {"Sales"}}, rows)
+
+	meta, err := ReadReportMetadata(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "restricted", meta.Redaction)
+
+	assert.ErrorIs(t, NewExcelDataExporter().SetRestrictedRole(), ErrNoRedactionRoles)
+}
+
+func TestRedactionLeavesConfigUnchanged(t *testing.T) {
+	e := redactionExporter(t, "manager")
+	e.BindSectionData("staff", redactionStaff)
+	var csv bytes.Buffer
+	assert.NoError(t, e.ToCSV(&csv))
+	assert.NotContains(t, csv.String(), "Salary")
+	assert.Len(t, e.sheets[0].sections[0].Columns, 6)
+	assert.Empty(t, e.sheets[0].sections[0].dropped)
+
+	// Without the policy, the next export has the dropped column again
+	e.SetRedactionPolicy(nil)
+	f, err := e.BuildExcel()
+	assert.NoError(t, err)
+	defer f.Close()
+	rows, _ := f.GetRows("Staff")
+	assert.Equal(t, []string{"ID", "Name", "Born", "Salary", "Phone", "Department"}, rows[0])
+	assert.Equal(t, "5200", rows[1][3])
+
+	// Roles get a copy of their policy
+	p, err := e.redactionConfig.PolicyForRole("manager")
+	assert.NoError(t, err)
+	assert.Equal(t, "manager", p.Name)
+	p.Rules = nil
+	assert.Empty(t, e.redactionConfig.Policies["manager"].Name)
+	assert.NotEmpty(t, e.redactionConfig.Policies["manager"].Rules)
+}
+
+func TestRedactionConfigErrors(t *testing.T) {
+	_, err := NewExcelDataExporterFromYamlConfig(strings.Replace(redactionConfig, "salary: drop", "salary: shred", 1))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `redaction policy manager: rule salary: unknown redaction action "shred"`)
+	}
+
+	_, err = NewExcelDataExporterFromYamlConfig(strings.Replace(redactionConfig, "manager: manager", "manager: managers", 1))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `role manager: unknown redaction policy "managers"`)
+	}
+
+	e, err := NewExcelDataExporterFromYamlConfig(redactionConfig)
+	assert.NoError(t, err)
+	assert.EqualError(t, e.SetRole("intern"), `unknown role "intern"`)
+	assert.EqualError(t, NewExcelDataExporter().SetRole("hr"), "no redaction roles configured")
+}
@@ -0,0 +1,344 @@
+package excel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrReportNotFound is returned for report names that are not in the registry.
+var ErrReportNotFound = errors.New("report not found")
+
+// ParseReportFormat parses the "?format=" value of a report export: xlsx (default), csv or json.
+func ParseReportFormat(s string) (ExportFormat, error) {
+	if ExportFormat(s) == FormatJSON {
+		return FormatJSON, nil
+	}
+	return ParseExportFormat(s)
+}
+
+// ToFormat exports the report in the given format: xlsx (ToWriter), csv (ToCSVBundle, a ZIP archive with a
+// CSV per section, as a report usually has several sections) or json (ToJSON).
+func (e *ExcelDataExporter) ToFormat(w io.Writer, format ExportFormat) error {
+	switch format {
+	case FormatXLSX, "":
+		return e.ToWriter(w)
+	case FormatCSV:
+		return e.ToCSVBundle(w, CSVOptions{})
+	case FormatJSON:
+		return e.ToJSON(w)
+	}
+	return fmt.Errorf("unsupported export format %q", format)
+}
+
+// ReportContentType returns the MIME type of a report exported with ToFormat.
+func (f ExportFormat) ReportContentType() string {
+	if f == FormatCSV {
+		return "application/zip"
+	}
+	return f.ContentType()
+}
+
+// ReportExtension returns the file extension of a report exported with ToFormat, e.g. "zip" for csv.
+func (f ExportFormat) ReportExtension() string {
+	switch f {
+	case FormatCSV:
+		return "zip"
+	case "":
+		return string(FormatXLSX)
+	}
+	return string(f)
+}
+
+// SectionProvider returns the data of a section for one export. params are the query parameters of the request.
+type SectionProvider func(ctx context.Context, params url.Values) (interface{}, error)
+
+// ReportInfo describes a report of the registry.
+type ReportInfo struct {
+	Name      string           `json:"name"`
+	Title     string           `json:"title,omitempty"`   // The name of the template
+	Version   string           `json:"version,omitempty"` // The version of the template
+	File      string           `json:"file"`
+	LoadedAt  time.Time        `json:"loaded_at"`
+	Sections  []string         `json:"sections"`            // IDs of the sections
+	Schedules []ReportSchedule `json:"schedules,omitempty"` // Recurring exports declared by the template
+	Error     string           `json:"error,omitempty"`     // Validation error of a newer version of the file, which is not served
+}
+
+// ReportRegistry serves the YAML report templates (.yaml, .yml) of a directory by name, the file name without
+// extension. Section data comes from providers registered by section ID. Templates are validated when they are
+// (re)loaded; a template that fails validation keeps serving its last valid version.
+type ReportRegistry struct {
+	dir    string
+	logger Logger
+
+	mu        sync.RWMutex
+	reports   map[string]*registeredReport
+	failures  map[string]*reportFailure
+	providers map[string]SectionProvider
+	configure []func(*ExcelDataExporter)
+}
+
+type registeredReport struct {
+	info   ReportInfo
+	config string
+	hash   string
+}
+
+// reportFailure is the validation error of a template file version.
+type reportFailure struct {
+	hash string
+	err  error
+}
+
+// NewReportRegistry creates a registry for the templates of dir. Call Reload to load them.
+func NewReportRegistry(dir string) *ReportRegistry {
+	return &ReportRegistry{
+		dir:       dir,
+		reports:   make(map[string]*registeredReport),
+		failures:  make(map[string]*reportFailure),
+		providers: make(map[string]SectionProvider),
+	}
+}
+
+// SetLogger attaches a logger for template (re)loads.
+func (r *ReportRegistry) SetLogger(l Logger) *ReportRegistry {
+	r.logger = l
+	return r
+}
+
+// RegisterProvider registers the data provider of a section ID, shared by all templates with that section.
+func (r *ReportRegistry) RegisterProvider(sectionID string, p SectionProvider) *ReportRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[sectionID] = p
+	return r
+}
+
+// Configure registers a function applied to every exporter of the registry before validation and export,
+// e.g. to register formatters.
+func (r *ReportRegistry) Configure(fn func(*ExcelDataExporter)) *ReportRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.configure = append(r.configure, fn)
+	return r
+}
+
+// Reload loads new and changed templates and drops the templates of removed files. It returns an error listing
+// the templates that failed validation; those keep serving their last valid version.
+func (r *ReportRegistry) Reload() error {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return fmt.Errorf("read report directory: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var problems []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), ext)
+		seen[name] = true
+		if err := r.reload(name, filepath.Join(r.dir, entry.Name())); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	r.mu.Lock()
+	for name := range r.reports {
+		if !seen[name] {
+			delete(r.reports, name)
+			r.log("report %s removed", name)
+		}
+	}
+	for name := range r.failures {
+		if !seen[name] {
+			delete(r.failures, name)
+		}
+	}
+	r.mu.Unlock()
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid report templates:\n%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// reload loads one template file if its content changed.
+func (r *ReportRegistry) reload(name, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	config := string(data)
+	hash := configHash(config)
+
+	r.mu.RLock()
+	current, failure := r.reports[name], r.failures[name]
+	r.mu.RUnlock()
+	if current != nil && current.hash == hash {
+		return nil
+	}
+	if failure != nil && failure.hash == hash {
+		return failure.err
+	}
+
+	e, err := r.validate(config)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.failures[name] = &reportFailure{hash: hash, err: err}
+		if current != nil {
+			r.log("report %s: %s is invalid, keeping the version loaded at %s: %v", name, path, current.info.LoadedAt.Format(time.RFC3339), err)
+		} else {
+			r.log("report %s: %s is invalid: %v", name, path, err)
+		}
+		return err
+	}
+
+	info := ReportInfo{Name: name, Title: e.reportName, Version: e.configVersion, File: path, LoadedAt: time.Now(), Schedules: e.Schedules()}
+	for _, sb := range e.sheets {
+		for _, sec := range sb.sections {
+			if sec.ID != "" {
+				info.Sections = append(info.Sections, sec.ID)
+			}
+		}
+	}
+	r.reports[name] = &registeredReport{info: info, config: config, hash: hash}
+	delete(r.failures, name)
+	r.log("report %s loaded from %s", name, path)
+	return nil
+}
+
+// validate creates an exporter from a template and checks its formatters.
+func (r *ReportRegistry) validate(config string) (*ExcelDataExporter, error) {
+	e, err := NewExcelDataExporterFromYamlConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	configure := r.configure
+	r.mu.RUnlock()
+	for _, fn := range configure {
+		fn(e)
+	}
+	if err := e.ValidateFormatters(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Watch reloads the templates every interval until ctx is done. Reload errors are logged.
+func (r *ReportRegistry) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Reload(); err != nil && !strings.HasPrefix(err.Error(), "invalid report templates") {
+				r.log("reload reports: %v", err)
+			}
+		}
+	}
+}
+
+// Reports returns the reports of the registry, sorted by name.
+func (r *ReportRegistry) Reports() []ReportInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reports := make([]ReportInfo, 0, len(r.reports))
+	for name, report := range r.reports {
+		info := report.info
+		if failure := r.failures[name]; failure != nil {
+			info.Error = failure.err.Error()
+		}
+		reports = append(reports, info)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Name < reports[j].Name })
+	return reports
+}
+
+// NewExporter creates an exporter for a report, with the data of every section that has a provider bound.
+func (r *ReportRegistry) NewExporter(ctx context.Context, name string, params url.Values) (*ExcelDataExporter, error) {
+	r.mu.RLock()
+	report := r.reports[name]
+	configure := r.configure
+	providers := make(map[string]SectionProvider, len(r.providers))
+	for id, p := range r.providers {
+		providers[id] = p
+	}
+	r.mu.RUnlock()
+	if report == nil {
+		return nil, fmt.Errorf("%w: %s", ErrReportNotFound, name)
+	}
+
+	e, err := newRegistryExporter(report, configure)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range report.info.Sections {
+		p, ok := providers[id]
+		if !ok {
+			continue
+		}
+		data, err := p(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("report %s, section %s: %w", name, id, err)
+		}
+		e.BindSectionData(id, data)
+	}
+	return e, nil
+}
+
+// Template creates an exporter for a report without section data, e.g. to verify or compare workbooks
+// returned by users (VerifyTemplate, DiffWorkbooks).
+func (r *ReportRegistry) Template(name string) (*ExcelDataExporter, error) {
+	r.mu.RLock()
+	report := r.reports[name]
+	configure := r.configure
+	r.mu.RUnlock()
+	if report == nil {
+		return nil, fmt.Errorf("%w: %s", ErrReportNotFound, name)
+	}
+	return newRegistryExporter(report, configure)
+}
+
+// newRegistryExporter creates the exporter of a registered template.
+func newRegistryExporter(report *registeredReport, configure []func(*ExcelDataExporter)) (*ExcelDataExporter, error) {
+	e, err := NewExcelDataExporterFromYamlConfig(report.config)
+	if err != nil {
+		return nil, err
+	}
+	for _, fn := range configure {
+		fn(e)
+	}
+	return e, nil
+}
+
+// Export renders a report in the given format.
+func (r *ReportRegistry) Export(ctx context.Context, w io.Writer, name string, format ExportFormat, params url.Values) error {
+	e, err := r.NewExporter(ctx, name, params)
+	if err != nil {
+		return err
+	}
+	return e.ToFormat(w, format)
+}
+
+func (r *ReportRegistry) log(format string, args ...interface{}) {
+	if r.logger != nil {
+		r.logger.Log(format, args...)
+	}
+}
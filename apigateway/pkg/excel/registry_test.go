@@ -0,0 +1,142 @@
+package excel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const registryProducts = `
+name: "Products"
+version: "1"
+sheets:
+  - name: "Products"
+    sections:
+      - id: "products"
+        show_header: true
+        columns:
+          - field_name: "Name"
+            header: "Name"
+          - field_name: "Price"
+            header: "Price"
+`
+
+type registryProduct struct {
+	Name  string
+	Price float64
+}
+
+func writeTemplate(t *testing.T, dir, file, config string) {
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(config), 0o644))
+}
+
+func newTestRegistry(t *testing.T) (*ReportRegistry, string) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "products.yaml", registryProducts)
+	writeTemplate(t, dir, "notes.txt", "not a template")
+
+	r := NewReportRegistry(dir)
+	r.RegisterProvider("products", func(ctx context.Context, params url.Values) (interface{}, error) {
+		count, err := strconv.Atoi(params.Get("count"))
+		if err != nil {
+			return nil, errors.New("count must be a number")
+		}
+		products := make([]registryProduct, count)
+		for i := range products {
+			products[i] = registryProduct{Name: "P" + strconv.Itoa(i+1), Price: float64(i+1) * 10}
+		}
+		return products, nil
+	})
+	assert.NoError(t, r.Reload())
+	return r, dir
+}
+
+func TestReportRegistryExport(t *testing.T) {
+	r, _ := newTestRegistry(t)
+
+	reports := r.Reports()
+	if assert.Len(t, reports, 1) {
+		assert.Equal(t, "products", reports[0].Name)
+		assert.Equal(t, "Products", reports[0].Title)
+		assert.Equal(t, []string{"products"}, reports[0].Sections)
+	}
+
+	var csv bytes.Buffer
+	assert.NoError(t, r.Export(context.Background(), &csv, "products", FormatCSV, url.Values{"count": {"2"}}))
+	assert.Equal(t, "Name,Price\nP1,10\nP2,20\n", readBundle(t, csv.Bytes())["Products/products.csv"])
+	assert.Equal(t, "application/zip", FormatCSV.ReportContentType())
+	assert.Equal(t, "zip", FormatCSV.ReportExtension())
+	assert.Equal(t, "xlsx", ExportFormat("").ReportExtension())
+
+	var js bytes.Buffer
+	assert.NoError(t, r.Export(context.Background(), &js, "products", FormatJSON, url.Values{"count": {"1"}}))
+	assert.Contains(t, js.String(), `"Name":"P1"`)
+
+	err := r.Export(context.Background(), &csv, "products", FormatCSV, url.Values{})
+	assert.EqualError(t, err, "report products, section products: count must be a number")
+
+	_, err = r.NewExporter(context.Background(), "invoices", nil)
+	assert.True(t, errors.Is(err, ErrReportNotFound))
+}
+
+func TestReportRegistryReload(t *testing.T) {
+	r, dir := newTestRegistry(t)
+	loadedAt := r.Reports()[0].LoadedAt
+
+	// Unchanged files are not loaded again
+	assert.NoError(t, r.Reload())
+	assert.Equal(t, loadedAt, r.Reports()[0].LoadedAt)
+
+	// An invalid edit keeps the old version
+	writeTemplate(t, dir, "products.yaml", strings.Replace(registryProducts, `header: "Price"`, `header: "Price"
+            expr: "Price *"`, 1))
+	err := r.Reload()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "products: sheet Products, section products, column Price")
+	}
+	reports := r.Reports()
+	assert.Equal(t, loadedAt, reports[0].LoadedAt)
+	assert.Contains(t, reports[0].Error, "unexpected end of expression")
+
+	var csv bytes.Buffer
+	assert.NoError(t, r.Export(context.Background(), &csv, "products", FormatCSV, url.Values{"count": {"1"}}))
+	assert.Equal(t, "Name,Price\nP1,10\n", readBundle(t, csv.Bytes())["Products/products.csv"])
+
+	// A fixed file replaces it
+	writeTemplate(t, dir, "products.yaml", strings.Replace(registryProducts, `header: "Price"`, `header: "Price (USD)"`, 1))
+	assert.NoError(t, r.Reload())
+	assert.Empty(t, r.Reports()[0].Error)
+	csv.Reset()
+	assert.NoError(t, r.Export(context.Background(), &csv, "products", FormatCSV, url.Values{"count": {"1"}}))
+	assert.Equal(t, "Name,Price (USD)\nP1,10\n", readBundle(t, csv.Bytes())["Products/products.csv"])
+
+	// New files are added and removed files dropped
+	writeTemplate(t, dir, "prices.yml", registryProducts)
+	assert.NoError(t, os.Remove(filepath.Join(dir, "products.yaml")))
+	assert.NoError(t, r.Reload())
+	reports = r.Reports()
+	if assert.Len(t, reports, 1) {
+		assert.Equal(t, "prices", reports[0].Name)
+	}
+}
+
+func TestParseReportFormat(t *testing.T) {
+	for in, want := range map[string]ExportFormat{"": FormatXLSX, "xlsx": FormatXLSX, "csv": FormatCSV, "json": FormatJSON} {
+		got, err := ParseReportFormat(in)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseReportFormat("pdf")
+	assert.Error(t, err)
+	_, err = ParseExportFormat("json")
+	assert.Error(t, err, "json is not a streaming format")
+	assert.Equal(t, "application/json", FormatJSON.ContentType())
+}
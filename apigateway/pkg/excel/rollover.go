@@ -0,0 +1,171 @@
+package excel
+
+import (
+	"fmt"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// SheetSplit records where a sheet was continued on a new sheet because it reached the row limit.
+type SheetSplit struct {
+	Sheet      string // Sheet that reached the limit
+	NextSheet  string // Continuation sheet, e.g. "Large Export (2)"
+	SectionID  string // Section being written when the limit was reached
+	RowsBefore int    // Data rows of the section written before the split (0 if the section starts on NextSheet)
+}
+
+// ExcelResult is a workbook built by BuildExcelResult with the splits of its sheets.
+type ExcelResult struct {
+	File   *excelize.File
+	Splits []SheetSplit // Sheets continued on a new sheet, in order (see SetMaxRowsPerSheet)
+}
+
+// sheetPage is one output sheet of a template sheet after rollover.
+type sheetPage struct {
+	name     string
+	sections []*SectionConfig
+}
+
+// SetMaxRowsPerSheet sets the row limit after which a sheet continues on a new sheet (Programmatic).
+// Zero or a value above Excel's limit means excelize.TotalRows (1,048,576).
+func (e *ExcelDataExporter) SetMaxRowsPerSheet(n int) *ExcelDataExporter {
+	e.maxRowsPerSheet = n
+	return e
+}
+
+// maxRows returns the effective row limit per sheet.
+func (e *ExcelDataExporter) maxRows() int {
+	if e.maxRowsPerSheet <= 0 || e.maxRowsPerSheet > excelize.TotalRows {
+		return excelize.TotalRows
+	}
+	return e.maxRowsPerSheet
+}
+
+// continuationSheetName returns the name of the n-th sheet of a template sheet, e.g. "Large Export (2)".
+// The base name is shortened to keep within Excel's sheet name length, and names already in use are skipped.
+func continuationSheetName(base string, n int, used func(string) bool) string {
+	for ; ; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		name := base
+		for utf8.RuneCountInString(name)+len(suffix) > excelize.MaxSheetNameLength {
+			r := []rune(name)
+			name = string(r[:len(r)-1])
+		}
+		if name += suffix; !used(name) {
+			return name
+		}
+	}
+}
+
+// partID returns the section ID used for the n-th part of a split section (tables, defined names, metadata).
+// The first part keeps the section ID.
+func partID(id string, part int) string {
+	if id == "" || part <= 1 {
+		return id
+	}
+	return fmt.Sprintf("%s_%d", id, part)
+}
+
+// isFlowLayout returns true if all sections are stacked vertically, so that rows can be split across sheets.
+func isFlowLayout(sections []*SectionConfig) bool {
+	for _, sec := range sections {
+		if sec.Direction == SectionDirectionHorizontal || sec.Position != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// headRows returns the number of rows rendered above the data of a section.
+func headRows(sec *SectionConfig) int {
+	n := 0
+	if sec.Title != nil {
+		n++
+	}
+	if sec.Type == SectionTypeTitleOnly {
+		return n
+	}
+	if hasHiddenFields(sec) {
+		n++
+	}
+	if sec.ShowHeader {
+		n++
+	}
+	return n
+}
+
+// sliceRange returns data[from:to] for slice data.
+func sliceRange(data interface{}, from, to int) interface{} {
+	return reflect.ValueOf(data).Slice(from, to).Interface()
+}
+
+// paginate splits a sheet into pages of at most maxRows rows. A section that does not fit continues on
+// the next page with its title and header repeated. Sheets with horizontal or positioned sections are
+// not split; renderSections rejects them if they exceed the limit. Data must already be bound to the
+// sections. Splits are recorded on the run.
+func (e *ExcelDataExporter) paginate(run *exportRun, sb *SheetBuilder, used map[string]bool) ([]sheetPage, error) {
+	if !isFlowLayout(sb.sections) {
+		return []sheetPage{{name: sb.name, sections: sb.sections}}, nil
+	}
+
+	limit := e.maxRows()
+	pages := []sheetPage{{name: sb.name}}
+	row := 1
+	newPage := func(sec *SectionConfig, rowsBefore int) {
+		name := continuationSheetName(sb.name, len(pages)+1, func(name string) bool { return used[name] })
+		used[name] = true
+		run.splits = append(run.splits, SheetSplit{Sheet: pages[len(pages)-1].name, NextSheet: name, SectionID: sec.ID, RowsBefore: rowsBefore})
+		pages = append(pages, sheetPage{name: name})
+		row = 1
+	}
+
+	for _, sec := range sb.sections {
+		sec.Columns = mergeSectionColumns(sec, sec.Data)
+		head := headRows(sec)
+		dataLen := e.getDataLength(run, sec)
+		if sec.Type == SectionTypeTitleOnly {
+			dataLen = 0
+		}
+		splittable := reflect.ValueOf(sec.Data).Kind() == reflect.Slice
+
+		offset := 0
+		for part := 1; ; {
+			page := &pages[len(pages)-1]
+			remaining := dataLen - offset
+			fit := limit - row - head + 1 // Data rows that fit on the current page
+			if remaining <= fit {
+				if offset == 0 {
+					page.sections = append(page.sections, sec)
+				} else {
+					page.sections = append(page.sections, sectionPart(sec, part, offset, dataLen))
+				}
+				run.addPart(sec.ID, partID(sec.ID, part), offset)
+				row += head + remaining
+				break
+			}
+			if fit < 1 || !splittable {
+				if row == 1 {
+					return nil, fmt.Errorf("sheet %s: section %s needs %d rows, more than the %d rows allowed per sheet", sb.name, sec.ID, head+remaining, limit)
+				}
+				newPage(sec, offset)
+				continue
+			}
+			page.sections = append(page.sections, sectionPart(sec, part, offset, offset+fit))
+			run.addPart(sec.ID, partID(sec.ID, part), offset)
+			offset += fit
+			part++
+			newPage(sec, offset)
+		}
+	}
+	return pages, nil
+}
+
+// sectionPart returns a copy of a section holding data rows [from, to).
+func sectionPart(sec *SectionConfig, part, from, to int) *SectionConfig {
+	c := *sec
+	c.ID = partID(sec.ID, part)
+	c.Data = sliceRange(sec.Data, from, to)
+	return &c
+}
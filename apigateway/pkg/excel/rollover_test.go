@@ -0,0 +1,229 @@
+package excel
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+type rolloverItem struct {
+	ID   int
+	Name string
+}
+
+func rolloverItems(n int) []rolloverItem {
+	items := make([]rolloverItem, n)
+	for i := range items {
+		items[i] = rolloverItem{ID: i + 1, Name: fmt.Sprintf("Item %d", i+1)}
+	}
+	return items
+}
+
+func rolloverExporter(data interface{}) *ExcelDataExporter {
+	return NewExcelDataExporter().
+		SetMaxRowsPerSheet(5).
+		AddSheet("Large Export").
+		AddSection(&SectionConfig{
+			ID:         "items",
+			Title:      "Items",
+			Data:       data,
+			ShowHeader: true,
+			Columns: []ColumnConfig{
+				{FieldName: "ID", Header: "ID"},
+				{FieldName: "Name", Header: "Name"},
+			},
+		}).
+		AddSection(&SectionConfig{Type: SectionTypeTitleOnly, Title: "End of report"}).
+		Build().
+		AddSheet("Summary").
+		AddSection(&SectionConfig{Title: "Summary"}).
+		Build()
+}
+
+func TestRolloverBuildExcel(t *testing.T) {
+	exporter := rolloverExporter(rolloverItems(7))
+	res, err := exporter.BuildExcelResult()
+	assert.NoError(t, err)
+	f := res.File
+	defer f.Close()
+
+	assert.Equal(t, []string{"Large Export", "Large Export (2)", "Large Export (3)", "Summary"}, f.GetSheetList())
+	assert.Equal(t, []SheetSplit{
+		{Sheet: "Large Export", NextSheet: "Large Export (2)", SectionID: "items", RowsBefore: 3},
+		{Sheet: "Large Export (2)", NextSheet: "Large Export (3)", SectionID: "items", RowsBefore: 6},
+	}, res.Splits)
+
+	// Title and header are repeated on the continuation sheets
+	rows, _ := f.GetRows("Large Export (2)")
+	assert.Equal(t, [][]string{{"Items"}, {"ID", "Name"}, {"4", "Item 4"}, {"5", "Item 5"}, {"6", "Item 6"}}, rows)
+	rows, _ = f.GetRows("Large Export (3)")
+	assert.Equal(t, [][]string{{"Items"}, {"ID", "Name"}, {"7", "Item 7"}, {"End of report"}}, rows)
+
+	// Each part has its own defined name
+	refs := make(map[string]string)
+	for _, dn := range f.GetDefinedName() {
+		refs[dn.Name] = dn.RefersTo
+	}
+	assert.Equal(t, "'Large Export'!$A$3:$B$5", refs["items"])
+	assert.Equal(t, "'Large Export (3)'!$A$3:$B$3", refs["items_3"])
+}
+
+func TestRolloverStreaming(t *testing.T) {
+	exporter := rolloverExporter(nil)
+	buf := new(bytes.Buffer)
+	streamer, err := exporter.StartStream(buf)
+	assert.NoError(t, err)
+
+	items := rolloverItems(7)
+	assert.NoError(t, streamer.Write("items", items[:4]))
+	assert.NoError(t, streamer.Write("items", items[4:]))
+	assert.NoError(t, streamer.Close())
+
+	assert.Equal(t, []SheetSplit{
+		{Sheet: "Large Export", NextSheet: "Large Export (2)", SectionID: "items", RowsBefore: 3},
+		{Sheet: "Large Export (2)", NextSheet: "Large Export (3)", SectionID: "items", RowsBefore: 6},
+	}, streamer.Splits())
+
+	f, err := excelize.OpenReader(buf)
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, []string{"Large Export", "Large Export (2)", "Large Export (3)", "Summary"}, f.GetSheetList())
+
+	rows, _ := f.GetRows("Large Export (2)")
+	assert.Equal(t, [][]string{{"Items"}, {"ID", "Name"}, {"4", "Item 4"}, {"5", "Item 5"}, {"6", "Item 6"}}, rows)
+	rows, _ = f.GetRows("Large Export (3)")
+	assert.Equal(t, [][]string{{"Items"}, {"ID", "Name"}, {"7", "Item 7"}, {"End of report"}}, rows)
+	rows, _ = f.GetRows("Summary")
+	assert.Equal(t, [][]string{{"Summary"}}, rows)
+}
+
+func TestRolloverStreamingSkipsTemplateSheetNames(t *testing.T) {
+	exporter := rolloverExporter(nil)
+	exporter.GetSheet("Summary").name = "Large Export (2)"
+	buf := new(bytes.Buffer)
+	streamer, err := exporter.StartStream(buf)
+	assert.NoError(t, err)
+	assert.NoError(t, streamer.Write("items", rolloverItems(4)))
+	assert.NoError(t, streamer.Close())
+
+	f, err := excelize.OpenReader(buf)
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, []string{"Large Export", "Large Export (3)", "Large Export (2)"}, f.GetSheetList())
+	rows, _ := f.GetRows("Large Export (2)")
+	assert.Equal(t, [][]string{{"Summary"}}, rows)
+}
+
+func TestRolloverReferencesResolvePerPart(t *testing.T) {
+	build := func(sheet string, check interface{}) *ExcelDataExporter {
+		exporter := rolloverExporter(nil)
+		exporter.GetSheet(sheet).AddSection(&SectionConfig{
+			ID:   "check",
+			Data: check,
+			Columns: []ColumnConfig{
+				{FieldName: "ID", Header: "ID"},
+				{FieldName: "Name", Header: "Name", Formula: "{items.Name}"},
+				{FieldName: "Diff", Header: "Diff", CompareWith: &CompareConfig{SectionID: "items", FieldName: "ID"}, CompareAgainst: &CompareConfig{SectionID: "check", FieldName: "ID"}},
+			},
+		})
+		return exporter
+	}
+	formulas := func(f *excelize.File, sheet string, cells ...string) []string {
+		var formulas []string
+		for _, cell := range cells {
+			formula, _ := f.GetCellFormula(sheet, cell)
+			formulas = append(formulas, formula)
+		}
+		return formulas
+	}
+	expected := []string{"'Large Export'!B3", "'Large Export'!B5", "'Large Export (2)'!B3", "'Large Export (2)'!B4"}
+
+	exporter := build("Summary", rolloverItems(5))
+	exporter.BindSectionData("items", rolloverItems(5))
+	f, err := exporter.BuildExcel()
+	assert.NoError(t, err)
+	defer f.Close()
+	// The last row of the check section continues on "Summary (2)"
+	assert.Equal(t, expected, append(formulas(f, "Summary", "B2", "B4", "B5"), formulas(f, "Summary (2)", "B1")...))
+	assert.Equal(t, []string{`IF('Large Export (2)'!A3<>A5, 'Large Export (2)'!A3, "")`}, formulas(f, "Summary", "C5"))
+
+	// Streamed sections resolve the same way; the check section continues on a third sheet
+	exporter = build("Large Export", nil)
+	buf := new(bytes.Buffer)
+	streamer, err := exporter.StartStream(buf)
+	assert.NoError(t, err)
+	assert.NoError(t, streamer.Write("items", rolloverItems(5)))
+	assert.NoError(t, streamer.Write("check", rolloverItems(5)))
+	assert.NoError(t, streamer.Close())
+	f, err = excelize.OpenReader(buf)
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, expected, formulas(f, "Large Export (3)", "B1", "B3", "B4", "B5"))
+}
+
+func TestRolloverLimitTooSmall(t *testing.T) {
+	exporter := rolloverExporter(rolloverItems(3)).SetMaxRowsPerSheet(2)
+	_, err := exporter.BuildExcel()
+	assert.Error(t, err)
+}
+
+func TestRolloverLayoutNotSplit(t *testing.T) {
+	for _, sec := range []*SectionConfig{
+		{ID: "side", Direction: SectionDirectionHorizontal, ShowHeader: true, Data: rolloverItems(5)},
+		{ID: "placed", Position: "D2", ShowHeader: true, Data: rolloverItems(5)},
+	} {
+		exporter := NewExcelDataExporter().SetMaxRowsPerSheet(5)
+		exporter.AddSheet("Layout").AddSection(sec)
+		_, err := exporter.BuildExcel()
+		if assert.Error(t, err, sec.ID) {
+			assert.Contains(t, err.Error(), "sheet Layout needs")
+			assert.Contains(t, err.Error(), "sheets with horizontal or positioned sections are not split")
+		}
+
+		// Within the limit they are rendered as is
+		exporter.SetMaxRowsPerSheet(10)
+		res, err := exporter.BuildExcelResult()
+		if assert.NoError(t, err, sec.ID) {
+			assert.Empty(t, res.Splits)
+			res.File.Close()
+		}
+	}
+}
+
+func TestRolloverFromYAML(t *testing.T) {
+	exporter, err := NewExcelDataExporterFromYamlConfig(`
+max_rows_per_sheet: 10
+sheets:
+  - name: "Data"
+    sections:
+      - id: "items"
+        show_header: true
+`)
+	assert.NoError(t, err)
+	exporter.BindSectionData("items", rolloverItems(20))
+
+	var buf bytes.Buffer
+	splits, err := exporter.ToWriterSplits(&buf)
+	assert.NoError(t, err)
+	assert.Len(t, splits, 2)
+	f, err := excelize.OpenReader(&buf)
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, []string{"Data", "Data (2)", "Data (3)"}, f.GetSheetList())
+}
+
+func TestContinuationSheetName(t *testing.T) {
+	none := func(string) bool { return false }
+	assert.Equal(t, "Large Export (2)", continuationSheetName("Large Export", 2, none))
+
+	long := strings.Repeat("x", 31)
+	name := continuationSheetName(long, 12, none)
+	assert.Equal(t, strings.Repeat("x", 26)+" (12)", name)
+
+	used := func(name string) bool { return name == "Data (2)" }
+	assert.Equal(t, "Data (3)", continuationSheetName("Data", 2, used))
+}
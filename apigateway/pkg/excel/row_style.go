@@ -0,0 +1,85 @@
+package excel
+
+import (
+	"reflect"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// RowStyler returns an additional style for a data row.
+// rowIndex is the 0-based index of the row within its section and item is the bound data element.
+// Returning nil keeps the section's data (and banding) style for that row.
+type RowStyler func(rowIndex int, item interface{}) *StyleTemplate
+
+// BandingConfig defines alternating styles for data rows.
+// The first data row of a section is odd.
+type BandingConfig struct {
+	OddStyle  *StyleTemplate `yaml:"odd_style"`
+	EvenStyle *StyleTemplate `yaml:"even_style"`
+}
+
+// hasRowStyles returns true if data rows of the section may differ in style.
+func hasRowStyles(sec *SectionConfig) bool {
+	return sec.Banding != nil || sec.RowStyler != nil
+}
+
+// mergeStyle overlays the non-nil parts of overlay on top of base.
+func mergeStyle(base, overlay *StyleTemplate) *StyleTemplate {
+	if overlay == nil {
+		return base
+	}
+	if base == nil {
+		return overlay
+	}
+	s := *base
+	if overlay.Font != nil {
+		s.Font = overlay.Font
+	}
+	if overlay.Fill != nil {
+		s.Fill = overlay.Fill
+	}
+	if overlay.Alignment != nil {
+		s.Alignment = overlay.Alignment
+	}
+	if overlay.Locked != nil {
+		s.Locked = overlay.Locked
+	}
+	return &s
+}
+
+// dataRowStyle returns the data style for a row before lock resolution,
+// combining the section data style, banding, and the row styler (in that order).
+func dataRowStyle(sec *SectionConfig, rowIndex int, item reflect.Value) *StyleTemplate {
+	style := sec.DataStyle
+	if sec.Banding != nil {
+		if rowIndex%2 == 0 {
+			style = mergeStyle(style, sec.Banding.OddStyle)
+		} else {
+			style = mergeStyle(style, sec.Banding.EvenStyle)
+		}
+	}
+	if sec.RowStyler != nil {
+		var raw interface{}
+		if item.IsValid() && item.CanInterface() {
+			raw = item.Interface()
+		}
+		style = mergeStyle(style, sec.RowStyler(rowIndex, raw))
+	}
+	return style
+}
+
+// rowStyleIDs resolves the style ID of every column for a single data row.
+// Locked columns without an explicit fill are still auto-grayed by resolveStyle.
+func (e *ExcelDataExporter) rowStyleIDs(f *excelize.File, sec *SectionConfig, defaultDataStyle *StyleTemplate, rowIndex int, item reflect.Value) ([]int, error) {
+	base := dataRowStyle(sec, rowIndex, item)
+	ids := make([]int, len(sec.Columns))
+	for j, col := range sec.Columns {
+		style := resolveStyle(base, defaultDataStyle, col.IsLocked(sec.Locked))
+		id, err := e.createStyle(f, style)
+		if err != nil {
+			return nil, err
+		}
+		ids[j] = id
+	}
+	return ids, nil
+}
@@ -0,0 +1,172 @@
+package excel
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func cellFillColor(t *testing.T, f *excelize.File, sheet, cell string) string {
+	t.Helper()
+	styleID, err := f.GetCellStyle(sheet, cell)
+	if err != nil {
+		t.Fatalf("GetCellStyle %s failed: %v", cell, err)
+	}
+	style, err := f.GetStyle(styleID)
+	if err != nil {
+		t.Fatalf("GetStyle %s failed: %v", cell, err)
+	}
+	if len(style.Fill.Color) == 0 {
+		return ""
+	}
+	return style.Fill.Color[0]
+}
+
+func TestBandingFromYAML(t *testing.T) {
+	yamlConfig := `
+sheets:
+  - name: "Banded"
+    sections:
+      - id: "rows"
+        show_header: true
+        banding:
+          odd_style:
+            fill:
+              color: "#FFFFFF"
+          even_style:
+            fill:
+              color: "#F2F2F2"
+        columns:
+          - field_name: "Name"
+            header: "Name"
+`
+	exporter, err := NewExcelDataExporterFromYamlConfig(yamlConfig)
+	assert.NoError(t, err)
+
+	exporter.BindSectionData("rows", []map[string]interface{}{
+		{"Name": "A"}, {"Name": "B"}, {"Name": "C"},
+	})
+
+	f, err := exporter.BuildExcel()
+	assert.NoError(t, err)
+
+	// Row 1: Header, Rows 2-4: Data
+	assert.Equal(t, "FFFFFF", cellFillColor(t, f, "Banded", "A2"))
+	assert.Equal(t, "F2F2F2", cellFillColor(t, f, "Banded", "A3"))
+	assert.Equal(t, "FFFFFF", cellFillColor(t, f, "Banded", "A4"))
+}
+
+func TestBandingContinuesAcrossSplits(t *testing.T) {
+	banded := func() *ExcelDataExporter {
+		return NewExcelDataExporter().
+			SetMaxRowsPerSheet(4).
+			AddSheet("Banded").
+			AddSection(&SectionConfig{
+				ID:         "rows",
+				ShowHeader: true,
+				Banding: &BandingConfig{
+					OddStyle:  &StyleTemplate{Fill: &FillTemplate{Color: "#FFFFFF"}},
+					EvenStyle: &StyleTemplate{Fill: &FillTemplate{Color: "#F2F2F2"}},
+				},
+				Columns: []ColumnConfig{{FieldName: "Name", Header: "Name"}},
+			}).
+			Build()
+	}
+	rows := []map[string]interface{}{{"Name": "A"}, {"Name": "B"}, {"Name": "C"}, {"Name": "D"}, {"Name": "E"}}
+
+	exporter := banded().BindSectionData("rows", rows)
+	f, err := exporter.BuildExcel()
+	assert.NoError(t, err)
+	// Rows 0-2 are on "Banded", rows 3-4 continue on "Banded (2)" below the repeated header
+	assert.Equal(t, "FFFFFF", cellFillColor(t, f, "Banded", "A4"))
+	assert.Equal(t, "F2F2F2", cellFillColor(t, f, "Banded (2)", "A2"))
+	assert.Equal(t, "FFFFFF", cellFillColor(t, f, "Banded (2)", "A3"))
+
+	buf := new(bytes.Buffer)
+	streamer, err := banded().StartStream(buf)
+	assert.NoError(t, err)
+	assert.NoError(t, streamer.Write("rows", rows))
+	assert.NoError(t, streamer.Close())
+	f, err = excelize.OpenReader(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "F2F2F2", cellFillColor(t, f, "Banded (2)", "A2"))
+	assert.Equal(t, "FFFFFF", cellFillColor(t, f, "Banded (2)", "A3"))
+}
+
+func TestRowStylerWithLockedColumns(t *testing.T) {
+	type Employee struct {
+		Name       string
+		Terminated bool
+	}
+	data := []Employee{{"Alice", false}, {"Bob", true}}
+
+	unlocked := false
+	exporter := NewExcelDataExporter()
+	exporter.AddSheet("Employees").
+		AddSection(&SectionConfig{
+			Locked: true,
+			Data:   data,
+			RowStyler: func(rowIndex int, item interface{}) *StyleTemplate {
+				if emp, ok := item.(Employee); ok && emp.Terminated {
+					return &StyleTemplate{Font: &FontTemplate{Color: "#808080"}}
+				}
+				return nil
+			},
+			Columns: []ColumnConfig{
+				{FieldName: "Name", Header: "Name"},
+				{FieldName: "Terminated", Header: "Terminated", Locked: &unlocked},
+			},
+		})
+
+	f, err := exporter.BuildExcel()
+	assert.NoError(t, err)
+
+	// Locked column keeps the auto-gray fill, unlocked column has none
+	assert.Equal(t, DefaultLockedColor, cellFillColor(t, f, "Employees", "A1"))
+	assert.Equal(t, "", cellFillColor(t, f, "Employees", "B1"))
+
+	styleID, _ := f.GetCellStyle("Employees", "A1")
+	style, _ := f.GetStyle(styleID)
+	assert.True(t, style.Font == nil || style.Font.Color == "")
+
+	styleID, _ = f.GetCellStyle("Employees", "A2")
+	style, _ = f.GetStyle(styleID)
+	if assert.NotNil(t, style.Font) {
+		assert.Equal(t, "808080", style.Font.Color)
+	}
+	assert.True(t, style.Protection.Locked)
+	assert.Equal(t, DefaultLockedColor, cellFillColor(t, f, "Employees", "A2"))
+}
+
+func TestBandingStreaming(t *testing.T) {
+	exporter := NewExcelDataExporter()
+	exporter.AddSheet("Stream").
+		AddSection(&SectionConfig{
+			ID: "rows",
+			Banding: &BandingConfig{
+				EvenStyle: &StyleTemplate{Fill: &FillTemplate{Color: "#DDEBF7"}},
+			},
+			Columns: []ColumnConfig{{FieldName: "ID", Header: "ID"}},
+		})
+
+	buf := new(bytes.Buffer)
+	streamer, err := exporter.StartStream(buf)
+	assert.NoError(t, err)
+
+	type Row struct{ ID int }
+	assert.NoError(t, streamer.Write("rows", []Row{{1}, {2}}))
+	assert.NoError(t, streamer.Write("rows", []Row{{3}, {4}}))
+	assert.NoError(t, streamer.Close())
+
+	f, err := excelize.OpenReader(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	defer f.Close()
+
+	// Banding continues across batches
+	assert.Equal(t, "", cellFillColor(t, f, "Stream", "A1"))
+	assert.Equal(t, "DDEBF7", cellFillColor(t, f, "Stream", "A2"))
+	assert.Equal(t, "", cellFillColor(t, f, "Stream", "A3"))
+	assert.Equal(t, "DDEBF7", cellFillColor(t, f, "Stream", "A4"))
+}
@@ -0,0 +1,116 @@
+package excel
+
+import "fmt"
+
+// exportRun is the state of a single Excel export: the sheets as rendered, where each section was placed,
+// the sheet splits and the section layout. BuildExcel and every stream have their own run, so exports
+// sharing an exporter neither race on this state nor see the placements of an earlier export.
+type exportRun struct {
+	// sheets are copies of the exporter sheets with the section data bound; resolving the columns of a
+	// section changes the copy, not the configuration
+	sheets []*SheetBuilder
+	// placements maps section IDs (and part IDs of split sections) to where their data was placed
+	placements map[string]SectionPlacement
+	// parts lists the part IDs of each section ID in order, and partRows the index of the first data
+	// row of each part within its section; sections split across sheets have several parts
+	parts    map[string][]string
+	partRows map[string]int
+	splits   []SheetSplit
+	// layout records where the sections were rendered, embedded for DiffWorkbooks
+	layout []SectionLayout
+}
+
+// newRun starts an export run on copies of the sheets and sections, binding the data of the exporter to
+// the sections by ID and applying the redaction policy. Every export resolves columns on its run, never on
+// the configured sections.
+func (e *ExcelDataExporter) newRun() *exportRun {
+	run := &exportRun{
+		sheets:     make([]*SheetBuilder, len(e.sheets)),
+		placements: make(map[string]SectionPlacement),
+		parts:      make(map[string][]string),
+		partRows:   make(map[string]int),
+	}
+	for i, sb := range e.sheets {
+		c := *sb
+		c.sections = make([]*SectionConfig, len(sb.sections))
+		for j, sec := range sb.sections {
+			s := *sec
+			s.Columns = append([]ColumnConfig(nil), sec.Columns...)
+			s.dropped = nil
+			e.redactSection(&s)
+			if s.ID != "" {
+				if data, ok := e.data[s.ID]; ok {
+					s.Data = data
+				}
+			}
+			c.sections[j] = &s
+		}
+		run.sheets[i] = &c
+	}
+	return run
+}
+
+// placement returns the placement of a section ID. A nil run has no placements.
+func (r *exportRun) placement(id string) (SectionPlacement, bool) {
+	if r == nil {
+		return SectionPlacement{}, false
+	}
+	p, ok := r.placements[id]
+	return p, ok
+}
+
+// addPart records that part id of a section starts at data row from of the section.
+func (r *exportRun) addPart(sectionID, id string, from int) {
+	if sectionID == "" {
+		return
+	}
+	if r.parts == nil {
+		r.parts = make(map[string][]string)
+		r.partRows = make(map[string]int)
+	}
+	r.parts[sectionID] = append(r.parts[sectionID], id)
+	r.partRows[id] = from
+}
+
+// sectionRow returns the index within its section of data row rowOffset of a placement, which is the
+// placement of a part for split sections.
+func (r *exportRun) sectionRow(placement SectionPlacement, rowOffset int) int {
+	if r == nil {
+		return rowOffset
+	}
+	return r.partRows[placement.SectionID] + rowOffset
+}
+
+// locate returns the placement holding data row row of a section and the offset of the row within it.
+// Rows of split sections resolve to their part; rows past the last part resolve to the last part.
+func (r *exportRun) locate(sectionID string, row int) (SectionPlacement, int, error) {
+	id := sectionID
+	if r != nil {
+		for _, part := range r.parts[sectionID] {
+			if r.partRows[part] > row {
+				break
+			}
+			id = part
+		}
+	}
+	p, ok := r.placement(id)
+	if !ok {
+		return SectionPlacement{}, 0, fmt.Errorf("section %s not found", id)
+	}
+	return p, row - r.sectionRow(p, 0), nil
+}
+
+// dataLen returns the number of data rows placed for a section ID, across its parts.
+func (r *exportRun) dataLen(sectionID string) int {
+	parts := r.parts[sectionID]
+	if len(parts) == 0 {
+		parts = []string{sectionID}
+	}
+	n := 0
+	for _, id := range parts {
+		if p, ok := r.placement(id); ok {
+			n += p.DataLen
+		}
+	}
+	return n
+}
@@ -0,0 +1,102 @@
+package excel
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func runExporter() *ExcelDataExporter {
+	rows := make([]accessorRow, 120)
+	for i := range rows {
+		rows[i] = accessorRow{ID: i, Name: fmt.Sprintf("name %d", i), Salary: float64(i) * 10}
+	}
+	e := NewExcelDataExporter().SetMaxRowsPerSheet(50)
+	e.AddSheet("People").
+		AddSection(&SectionConfig{ID: "people", Title: "People", ShowHeader: true, Columns: []ColumnConfig{
+			{FieldName: "ID"},
+			{FieldName: "Salary"},
+			{FieldName: "Bonus", Formula: "{Salary}*0.1"},
+		}})
+	e.AddSheet("Log").
+		AddSection(&SectionConfig{ID: "log", ShowHeader: true})
+	e.BindSectionData("people", rows)
+	return e
+}
+
+func TestConcurrentBuildAndStream(t *testing.T) {
+	e := runExporter()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				res, err := e.BuildExcelResult()
+				if !assert.NoError(t, err) {
+					return
+				}
+				f := res.File
+				defer f.Close()
+				assert.Len(t, res.Splits, 2)
+				assert.Equal(t, []string{"People", "People (2)", "People (3)", "Log"}, f.GetSheetList())
+				formula, _ := f.GetCellFormula("People (2)", "C3")
+				assert.Equal(t, "B3*0.1", formula)
+				return
+			}
+
+			var buf bytes.Buffer
+			s, err := e.StartStream(&buf)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NoError(t, s.Write("log", []accessorRow{{ID: i}}))
+			assert.NoError(t, s.Close())
+			assert.Len(t, s.Splits(), 2)
+			f, err := excelize.OpenReader(&buf)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer f.Close()
+			assert.Equal(t, []string{"People", "People (2)", "People (3)", "Log"}, f.GetSheetList())
+		}(i)
+	}
+	wg.Wait()
+
+	// The configuration keeps its columns
+	assert.Len(t, e.sheets[0].sections[0].Columns, 3)
+	assert.Empty(t, e.sheets[1].sections[0].Columns)
+}
+
+func TestExportsFollowRebinding(t *testing.T) {
+	e := NewExcelDataExporter()
+	e.AddSheet("People").AddSection(&SectionConfig{ID: "people", ShowHeader: true})
+	e.BindSectionData("people", []accessorRow{{ID: 1, Name: "first"}})
+
+	export := func() []string {
+		var csv, bundle, js, nd, html bytes.Buffer
+		assert.NoError(t, e.ToCSV(&csv))
+		assert.NoError(t, e.ToCSVBundle(&bundle, CSVOptions{}))
+		assert.NoError(t, e.ToJSON(&js))
+		assert.NoError(t, e.ToNDJSON(&nd))
+		assert.NoError(t, e.ToHTML(&html, HTMLOptions{}))
+		return []string{csv.String(), js.String(), nd.String(), html.String()}
+	}
+	for _, out := range export() {
+		assert.Contains(t, out, "first")
+	}
+
+	// Data bound after an export is used by the next one; exports do not change the configuration
+	e.BindSectionData("people", []accessorRow{{ID: 2, Name: "second"}})
+	for _, out := range export() {
+		assert.Contains(t, out, "second")
+		assert.NotContains(t, out, "first")
+	}
+	assert.Nil(t, e.sheets[0].sections[0].Data)
+	assert.Empty(t, e.sheets[0].sections[0].Columns)
+}
@@ -0,0 +1,177 @@
+package excel
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/cron"
+)
+
+// defaultScheduleFileName is the file name pattern of schedules without file_name.
+const defaultScheduleFileName = `{{.Report}}_{{.Schedule}}_{{date "20060102_150405" .Time}}.{{.Ext}}`
+
+// ReportSchedule declares a recurring export of a template (YAML: schedules). Parameter values and the file
+// name are text/template patterns rendered with the run time, e.g. an as-of date parameter:
+//
+//	schedules:
+//	  - name: weekly
+//	    cron: "0 7 * * MON"
+//	    timezone: "Europe/Berlin"
+//	    format: xlsx
+//	    output_dir: finance
+//	    file_name: 'salaries_{{date "2006-01-02" .Time}}.xlsx'
+//	    params:
+//	      as_of: '{{.Time | addDays -1 | date "2006-01-02"}}'
+type ReportSchedule struct {
+	Name      string            `yaml:"name" json:"name"`
+	Cron      string            `yaml:"cron" json:"cron"`                     // Five-field cron expression or macro such as @daily
+	Timezone  string            `yaml:"timezone" json:"timezone,omitempty"`   // IANA time zone of the cron expression (default: local)
+	Format    string            `yaml:"format" json:"format"`                 // xlsx (default), csv or json
+	Params    map[string]string `yaml:"params" json:"params,omitempty"`       // Section provider parameters (patterns)
+	OutputDir string            `yaml:"output_dir" json:"output_dir"`         // Directory below the outbox
+	FileName  string            `yaml:"file_name" json:"file_name,omitempty"` // File name pattern
+	Role      string            `yaml:"role" json:"role,omitempty"`           // Redaction role of the export
+	Lang      string            `yaml:"lang" json:"lang,omitempty"`           // Locale of the export
+
+	cron     *cron.Schedule
+	location *time.Location
+	params   map[string]*template.Template
+	fileName *template.Template
+}
+
+// ScheduleData is the data of the parameter and file name patterns of a schedule.
+type ScheduleData struct {
+	Time     time.Time // Run time, in the time zone of the schedule
+	Report   string
+	Schedule string
+	Format   string
+	Ext      string // File extension of the format, e.g. "zip" for csv (see ExportFormat.ReportExtension)
+}
+
+// scheduleFuncs are the functions of schedule patterns, written for pipelines: {{.Time | addDays -1 | date "2006-01-02"}}.
+var scheduleFuncs = template.FuncMap{
+	"date":      func(layout string, t time.Time) string { return t.Format(layout) },
+	"addDays":   func(n int, t time.Time) time.Time { return t.AddDate(0, 0, n) },
+	"addMonths": func(n int, t time.Time) time.Time { return t.AddDate(0, n, 0) },
+	"startOfWeek": func(t time.Time) time.Time { // Monday
+		return time.Date(t.Year(), t.Month(), t.Day()-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	},
+	"startOfMonth": func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	},
+}
+
+// Validate checks the schedule and compiles its cron expression and patterns.
+func (s *ReportSchedule) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("schedule name is required")
+	}
+	var err error
+	if s.cron, err = cron.Parse(s.Cron); err != nil {
+		return err
+	}
+	if s.location, err = time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("timezone: %w", err)
+	}
+	format, err := ParseReportFormat(s.Format)
+	if err != nil {
+		return err
+	}
+	s.Format = string(format)
+	if s.OutputDir != "" {
+		if dir := path.Clean(s.OutputDir); path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+			return fmt.Errorf("output_dir %q must be a relative directory below the outbox", s.OutputDir)
+		}
+	}
+
+	s.params = make(map[string]*template.Template, len(s.Params))
+	for _, key := range sortedKeys(s.Params) {
+		if s.params[key], err = template.New(key).Funcs(scheduleFuncs).Option("missingkey=error").Parse(s.Params[key]); err != nil {
+			return fmt.Errorf("param %s: %w", key, err)
+		}
+	}
+	pattern := s.FileName
+	if pattern == "" {
+		pattern = defaultScheduleFileName
+	}
+	if s.fileName, err = template.New("file_name").Funcs(scheduleFuncs).Option("missingkey=error").Parse(pattern); err != nil {
+		return fmt.Errorf("file_name: %w", err)
+	}
+
+	// Render once, so errors such as calling date on a string are reported with the template
+	_, _, err = s.Render("report", time.Now())
+	return err
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Next returns the first run time of the schedule after t, or the zero time if there is none.
+func (s *ReportSchedule) Next(t time.Time) time.Time {
+	if s.cron == nil {
+		return time.Time{}
+	}
+	return s.cron.Next(t.In(s.location))
+}
+
+// Render renders the provider parameters and the file name of a run of report at time t.
+func (s *ReportSchedule) Render(report string, t time.Time) (url.Values, string, error) {
+	if s.fileName == nil {
+		return nil, "", fmt.Errorf("schedule %s is not validated", s.Name)
+	}
+	data := ScheduleData{Time: t.In(s.location), Report: report, Schedule: s.Name, Format: s.Format, Ext: ExportFormat(s.Format).ReportExtension()}
+	params := make(url.Values, len(s.params))
+	var sb strings.Builder
+	for key, tmpl := range s.params {
+		sb.Reset()
+		if err := tmpl.Execute(&sb, data); err != nil {
+			return nil, "", fmt.Errorf("param %s: %w", key, err)
+		}
+		params.Set(key, sb.String())
+	}
+
+	sb.Reset()
+	if err := s.fileName.Execute(&sb, data); err != nil {
+		return nil, "", fmt.Errorf("file_name: %w", err)
+	}
+	name := sb.String()
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return nil, "", fmt.Errorf("file_name: %q is not a file name", name)
+	}
+	return params, name, nil
+}
+
+// validateSchedules validates the schedules of a template.
+func validateSchedules(tmpl *ReportTemplate) error {
+	seen := make(map[string]bool)
+	for i := range tmpl.Schedules {
+		s := &tmpl.Schedules[i]
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("schedule %s: %w", s.Name, err)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("schedule %s: duplicate schedule name", s.Name)
+		}
+		seen[s.Name] = true
+	}
+	return nil
+}
+
+// Schedules returns the schedules declared by the YAML template.
+func (e *ExcelDataExporter) Schedules() []ReportSchedule {
+	if e.template == nil {
+		return nil
+	}
+	return e.template.Schedules
+}
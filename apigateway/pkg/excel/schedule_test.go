@@ -0,0 +1,79 @@
+package excel
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const scheduleConfig = `
+name: "Salaries"
+schedules:
+  - name: "weekly"
+    cron: "0 7 * * MON"
+    timezone: "UTC"
+    output_dir: "finance"
+    file_name: 'salaries_{{date "2006-01-02" .Time}}.{{.Format}}'
+    params:
+      as_of: '{{.Time | addDays -1 | date "2006-01-02"}}'
+      month: '{{.Time | startOfMonth | date "2006-01-02"}}'
+      week: '{{.Time | startOfWeek | date "2006-01-02"}}'
+  - name: "daily"
+    cron: "@daily"
+    format: "csv"
+sheets:
+  - name: "Salaries"
+    sections:
+      - id: "salaries"
+`
+
+func TestReportSchedules(t *testing.T) {
+	e, err := NewExcelDataExporterFromYamlConfig(scheduleConfig)
+	assert.NoError(t, err)
+	schedules := e.Schedules()
+	if !assert.Len(t, schedules, 2) {
+		return
+	}
+
+	weekly := schedules[0]
+	assert.Equal(t, "xlsx", weekly.Format)
+	wed := time.Date(2024, 3, 13, 10, 0, 0, 0, time.UTC)
+	monday := weekly.Next(wed)
+	assert.Equal(t, time.Date(2024, 3, 18, 7, 0, 0, 0, time.UTC), monday)
+
+	params, name, err := weekly.Render("salaries", monday)
+	assert.NoError(t, err)
+	assert.Equal(t, "salaries_2024-03-18.xlsx", name)
+	assert.Equal(t, "2024-03-17", params.Get("as_of"))
+	assert.Equal(t, "2024-03-01", params.Get("month"))
+	assert.Equal(t, "2024-03-18", params.Get("week"))
+
+	_, name, err = schedules[1].Render("salaries", wed)
+	assert.NoError(t, err)
+	// The default file name has the extension of the format: csv reports are ZIP bundles
+	assert.Equal(t, "salaries_daily_20240313_100000.zip", name)
+}
+
+func TestReportScheduleErrors(t *testing.T) {
+	tests := []struct {
+		old, new, err string
+	}{
+		{`cron: "0 7 * * MON"`, `cron: "0 7 * * FUN"`, `schedule weekly: cron "0 7 * * FUN": day of week: invalid value "FUN"`},
+		{`timezone: "UTC"`, `timezone: "Mars/Olympus"`, `schedule weekly: timezone: unknown time zone Mars/Olympus`},
+		{`output_dir: "finance"`, `output_dir: "../finance"`, `schedule weekly: output_dir "../finance" must be a relative directory below the outbox`},
+		{`name: "daily"`, `name: "weekly"`, `schedule weekly: duplicate schedule name`},
+		{`format: "csv"`, `format: "pdf"`, `schedule daily: unsupported export format "pdf"`},
+		{`'{{.Time | startOfWeek | date "2006-01-02"}}'`, `'{{.Tim | startOfWeek}}'`, `schedule weekly: param week: template: week:1:2: executing "week" at <.Tim>: can't evaluate field Tim`},
+	}
+	for _, tt := range tests {
+		_, err := NewExcelDataExporterFromYamlConfig(strings.Replace(scheduleConfig, tt.old, tt.new, 1))
+		if assert.Error(t, err, tt.new) {
+			assert.Contains(t, err.Error(), tt.err, tt.new)
+		}
+	}
+
+	s := ReportSchedule{Name: "bad", Cron: "@daily", FileName: "{{.Report}}/x.csv"}
+	assert.EqualError(t, s.Validate(), `file_name: "report/x.csv" is not a file name`)
+}
@@ -0,0 +1,276 @@
+package excel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// cancelCheckInterval is the number of data rows written between context checks.
+const cancelCheckInterval = 1000
+
+var (
+	// ErrStreamCanceled matches (errors.Is) every *StreamCanceledError.
+	ErrStreamCanceled = errors.New("stream canceled")
+	// ErrMaxRowsExceeded is returned when a stream exceeds StreamOptions.MaxRows.
+	ErrMaxRowsExceeded = errors.New("stream exceeded the maximum number of rows")
+)
+
+// StreamCanceledError is returned when the context of a stream is canceled or its deadline expires.
+// The stream is aborted and its temporary files are removed.
+type StreamCanceledError struct {
+	Cause error // context.Canceled or context.DeadlineExceeded
+	Rows  int   // Data rows written before the stream was aborted
+}
+
+func (e *StreamCanceledError) Error() string {
+	return fmt.Sprintf("stream canceled after %d rows: %v", e.Rows, e.Cause)
+}
+
+// Unwrap returns the context error.
+func (e *StreamCanceledError) Unwrap() error { return e.Cause }
+
+// Is reports whether target is ErrStreamCanceled.
+func (e *StreamCanceledError) Is(target error) bool { return target == ErrStreamCanceled }
+
+// StreamOptions configures a streaming export started with StartStreamContext or StartCSVStreamContext.
+type StreamOptions struct {
+	// OnProgress is called after every Write and once more when the stream is closed.
+	OnProgress func(StreamProgress)
+	// MaxRows aborts the stream with ErrMaxRowsExceeded when more data rows are written (0 = unlimited).
+	MaxRows int
+}
+
+// StreamProgress reports the state of a streaming export.
+type StreamProgress struct {
+	Sheet       string         // Sheet being written
+	SectionID   string         // Section of the last Write
+	SectionRows int            // Data rows written to the section so far
+	Sections    map[string]int // Data rows written so far per section ID
+	TotalRows   int            // Data rows written to all sections so far
+	// BytesFlushed is the number of bytes written to the output so far. CSV streams flush every Write; xlsx
+	// streams write the workbook when they are closed, so it is only set in the final report.
+	BytesFlushed int64
+	Elapsed      time.Duration // Time since the stream was started
+	Done         bool          // The stream was closed and the file written
+}
+
+// StartStreamContext initializes a streaming export session bound to ctx.
+// Canceling ctx (e.g. when the client disconnects) aborts the stream: Write and Close return a
+// *StreamCanceledError and the excelize temporary files are removed.
+func (e *ExcelDataExporter) StartStreamContext(ctx context.Context, w io.Writer, opts StreamOptions) (*Streamer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &StreamCanceledError{Cause: err}
+	}
+	return e.startStream(ctx, w, opts)
+}
+
+// StreamSession holds what every streaming export shares: the workbook being streamed, its cell renderer,
+// the context and options of the stream and the rows written so far. Streamer is built on a session; stream
+// writers outside this package (e.g. the layouts of pkg/simpleexcelv3) use one to get the same cells,
+// cancellation, row limit and progress reporting.
+type StreamSession struct {
+	exporter *ExcelDataExporter
+	file     *excelize.File
+	cells    *CellRenderer
+	counter  *streamCounter
+	// err is set when the stream was aborted; later calls return it
+	err error
+}
+
+// streamCounter holds the context, options and row counts of a stream, shared by the xlsx and CSV streams.
+type streamCounter struct {
+	ctx         context.Context
+	opts        StreamOptions
+	out         *contextWriter
+	started     time.Time
+	totalRows   int
+	sectionRows map[string]int
+}
+
+func newStreamCounter(ctx context.Context, w io.Writer, opts StreamOptions) *streamCounter {
+	return &streamCounter{
+		ctx:         ctx,
+		opts:        opts,
+		out:         &contextWriter{ctx: ctx, w: w},
+		started:     time.Now(),
+		sectionRows: make(map[string]int),
+	}
+}
+
+// addRow counts a data row of a section before it is written. It returns an ErrMaxRowsExceeded error beyond
+// StreamOptions.MaxRows, and checks the context every cancelCheckInterval rows.
+func (c *streamCounter) addRow(sectionID string) error {
+	if c.opts.MaxRows > 0 && c.totalRows >= c.opts.MaxRows {
+		return fmt.Errorf("%w (%d)", ErrMaxRowsExceeded, c.opts.MaxRows)
+	}
+	if c.totalRows > 0 && c.totalRows%cancelCheckInterval == 0 {
+		if err := c.checkContext(); err != nil {
+			return err
+		}
+	}
+	c.totalRows++
+	c.sectionRows[sectionID]++
+	return nil
+}
+
+// checkContext returns a *StreamCanceledError if the context is done.
+func (c *streamCounter) checkContext() error {
+	if err := c.ctx.Err(); err != nil {
+		return &StreamCanceledError{Cause: err, Rows: c.totalRows}
+	}
+	return nil
+}
+
+// progress calls the progress callback, if any, with the rows written so far.
+func (c *streamCounter) progress(sheet, sectionID string, done bool) {
+	if c.opts.OnProgress == nil {
+		return
+	}
+	p := StreamProgress{
+		Sheet:        sheet,
+		SectionID:    sectionID,
+		Sections:     make(map[string]int, len(c.sectionRows)),
+		TotalRows:    c.totalRows,
+		BytesFlushed: c.out.written,
+		Elapsed:      time.Since(c.started),
+		Done:         done,
+	}
+	for id, n := range c.sectionRows {
+		p.Sections[id] = n
+	}
+	if sectionID != "" {
+		p.SectionRows = c.sectionRows[sectionID]
+	}
+	c.opts.OnProgress(p)
+}
+
+// NewStreamSession starts a stream session writing a new workbook to w. The workbook is saved with the
+// save options of the exporter (e.g. encryption). It returns a *StreamCanceledError if ctx is done.
+func (e *ExcelDataExporter) NewStreamSession(ctx context.Context, w io.Writer, opts StreamOptions) (*StreamSession, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, &StreamCanceledError{Cause: err}
+	}
+	return e.newStreamSession(ctx, w, opts, &exportRun{placements: make(map[string]SectionPlacement)}), nil
+}
+
+func (e *ExcelDataExporter) newStreamSession(ctx context.Context, w io.Writer, opts StreamOptions, run *exportRun) *StreamSession {
+	f := excelize.NewFile()
+	return &StreamSession{
+		exporter: e,
+		file:     f,
+		cells:    e.newCellRenderer(f, run),
+		counter:  newStreamCounter(ctx, w, opts),
+	}
+}
+
+// contextWriter fails once the context is done, so that writing a large workbook stops when the
+// client goes away. It counts the bytes written for StreamProgress.BytesFlushed.
+type contextWriter struct {
+	ctx     context.Context
+	w       io.Writer
+	written int64
+}
+
+func (cw *contextWriter) Write(p []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := cw.w.Write(p)
+	cw.written += int64(n)
+	return n, err
+}
+
+// File returns the workbook being streamed.
+func (s *StreamSession) File() *excelize.File {
+	return s.file
+}
+
+// Cells returns the cell renderer of the workbook.
+func (s *StreamSession) Cells() *CellRenderer {
+	return s.cells
+}
+
+// Check returns the error that aborted the stream, an error if the workbook was already saved, or a
+// *StreamCanceledError (aborting the stream) if the context is done.
+func (s *StreamSession) Check() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.file == nil {
+		return fmt.Errorf("stream is closed or not initialized")
+	}
+	return s.checkContext()
+}
+
+// AddRow counts a data row of a section before it is written. It aborts the stream with
+// ErrMaxRowsExceeded beyond StreamOptions.MaxRows, and checks the context every cancelCheckInterval rows.
+func (s *StreamSession) AddRow(sectionID string) error {
+	if err := s.counter.addRow(sectionID); err != nil {
+		return s.fail(err)
+	}
+	return nil
+}
+
+// Save writes the workbook to the output of the stream and removes the temporary files of its stream
+// writers, which must be flushed first. The session cannot be used afterwards.
+func (s *StreamSession) Save() error {
+	if err := s.Check(); err != nil {
+		return err
+	}
+	defer s.release()
+	opts, err := s.exporter.saveOptions()
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.WriteTo(s.counter.out, opts...); err != nil {
+		if ctxErr := s.counter.checkContext(); ctxErr != nil {
+			return s.fail(ctxErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// Abort discards the stream without writing the file and removes its temporary files.
+// Use it when the export cannot be completed for reasons outside the streamer.
+func (s *StreamSession) Abort() {
+	if s.err == nil {
+		s.err = fmt.Errorf("stream was aborted")
+	}
+	s.release()
+}
+
+// Progress calls the progress callback, if any, with the rows written so far. sectionID is the section
+// written last (empty if none) and done is set once the workbook is saved.
+func (s *StreamSession) Progress(sheet, sectionID string, done bool) {
+	s.counter.progress(sheet, sectionID, done)
+}
+
+// checkContext aborts the stream if its context is done.
+func (s *StreamSession) checkContext() error {
+	if err := s.counter.checkContext(); err != nil {
+		return s.fail(err)
+	}
+	return nil
+}
+
+// fail aborts the stream, removing its temporary files. Later calls return err.
+func (s *StreamSession) fail(err error) error {
+	s.err = err
+	s.release()
+	return err
+}
+
+// release closes the excelize file, which removes the stream writers' temporary files.
+func (s *StreamSession) release() {
+	if s.file != nil {
+		s.exporter.releaseStyles(s.file)
+		s.file.Close()
+		s.file = nil
+	}
+}
@@ -0,0 +1,133 @@
+package excel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func streamOptionsExporter() *ExcelDataExporter {
+	return NewExcelDataExporter().
+		AddSheet("Data").
+		AddSection(&SectionConfig{
+			ID:         "items",
+			ShowHeader: true,
+			Columns: []ColumnConfig{
+				{FieldName: "ID", Header: "ID"},
+				{FieldName: "Name", Header: "Name"},
+			},
+		}).
+		Build()
+}
+
+func TestStreamProgress(t *testing.T) {
+	var progress []StreamProgress
+	buf := new(bytes.Buffer)
+	streamer, err := streamOptionsExporter().StartStreamContext(context.Background(), buf, StreamOptions{
+		OnProgress: func(p StreamProgress) { progress = append(progress, p) },
+	})
+	assert.NoError(t, err)
+
+	items := rolloverItems(5)
+	assert.NoError(t, streamer.Write("items", items[:3]))
+	assert.NoError(t, streamer.Write("items", items[3:]))
+	assert.NoError(t, streamer.Close())
+
+	if !assert.Len(t, progress, 3) {
+		return
+	}
+	assert.Equal(t, "Data", progress[0].Sheet)
+	assert.Equal(t, "items", progress[0].SectionID)
+	assert.Equal(t, 3, progress[0].SectionRows)
+	assert.Equal(t, 5, progress[1].TotalRows)
+	assert.False(t, progress[1].Done)
+
+	assert.True(t, progress[2].Done)
+	assert.Equal(t, 5, progress[2].SectionRows)
+	assert.True(t, progress[2].Elapsed > 0)
+	assert.NotZero(t, buf.Len())
+	// The workbook is written when the stream is closed
+	assert.Zero(t, progress[1].BytesFlushed)
+	assert.Equal(t, int64(buf.Len()), progress[2].BytesFlushed)
+	assert.Equal(t, map[string]int{"items": 5}, progress[2].Sections)
+
+	// The file is released after Close
+	assert.Error(t, streamer.Close())
+}
+
+func TestStreamCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	buf := new(bytes.Buffer)
+	streamer, err := streamOptionsExporter().StartStreamContext(ctx, buf, StreamOptions{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, streamer.Write("items", rolloverItems(2)))
+	cancel()
+	err = streamer.Write("items", rolloverItems(2))
+
+	var canceled *StreamCanceledError
+	assert.True(t, errors.As(err, &canceled))
+	assert.Equal(t, 2, canceled.Rows)
+	assert.True(t, errors.Is(err, ErrStreamCanceled))
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	// The stream stays aborted and nothing is written
+	assert.Equal(t, err, streamer.Close())
+	assert.Zero(t, buf.Len())
+}
+
+func TestStreamCanceledBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := streamOptionsExporter().StartStreamContext(ctx, new(bytes.Buffer), StreamOptions{})
+	assert.True(t, errors.Is(err, ErrStreamCanceled))
+}
+
+func TestStreamMaxRows(t *testing.T) {
+	buf := new(bytes.Buffer)
+	streamer, err := streamOptionsExporter().StartStreamContext(context.Background(), buf, StreamOptions{MaxRows: 4})
+	assert.NoError(t, err)
+
+	assert.NoError(t, streamer.Write("items", rolloverItems(3)))
+	err = streamer.Write("items", rolloverItems(2))
+	assert.True(t, errors.Is(err, ErrMaxRowsExceeded))
+	assert.False(t, errors.Is(err, ErrStreamCanceled))
+	assert.Equal(t, err, streamer.Close())
+	assert.Zero(t, buf.Len())
+}
+
+func TestStreamSession(t *testing.T) {
+	var last StreamProgress
+	buf := new(bytes.Buffer)
+	session, err := NewExcelDataExporter().NewStreamSession(context.Background(), buf, StreamOptions{
+		MaxRows:    2,
+		OnProgress: func(p StreamProgress) { last = p },
+	})
+	assert.NoError(t, err)
+
+	sw, err := session.File().NewStreamWriter("Sheet1")
+	assert.NoError(t, err)
+	for i := 1; i <= 2; i++ {
+		assert.NoError(t, session.AddRow("items"))
+		assert.NoError(t, sw.SetRow(fmt.Sprintf("A%d", i), []interface{}{i}))
+	}
+	assert.True(t, errors.Is(session.AddRow("items"), ErrMaxRowsExceeded))
+	assert.True(t, errors.Is(session.Save(), ErrMaxRowsExceeded))
+	assert.Zero(t, buf.Len())
+
+	session, err = NewExcelDataExporter().NewStreamSession(context.Background(), buf, StreamOptions{
+		OnProgress: func(p StreamProgress) { last = p },
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, session.AddRow("items"))
+	assert.NoError(t, session.Save())
+	session.Progress("Sheet1", "items", true)
+	assert.Equal(t, StreamProgress{Sheet: "Sheet1", SectionID: "items", SectionRows: 1, Sections: map[string]int{"items": 1}, TotalRows: 1,
+		BytesFlushed: int64(buf.Len()), Elapsed: last.Elapsed, Done: true}, last)
+	assert.NotZero(t, buf.Len())
+	assert.Error(t, session.Check())
+}
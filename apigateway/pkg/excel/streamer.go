@@ -0,0 +1,591 @@
+package excel
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Streamer manages a streaming export session.
+type Streamer struct {
+	// session holds the context, progress reporting and limits (see StreamOptions)
+	session  *StreamSession
+	exporter *ExcelDataExporter
+	// run holds the sheets being streamed, the section placements and the splits of this stream
+	run  *exportRun
+	file *excelize.File
+	// streamWriters holds active stream writers for each sheet
+	streamWriters map[string]*excelize.StreamWriter
+	// currentSheetIndex tracks which sheet we are currently processing
+	currentSheetIndex int
+	// currentSectionIndex tracks which section we are in within the current sheet
+	currentSectionIndex int
+	// currentRow keeps track of the current row number for the current sheet
+	currentRow int
+	// sectionStarted indicates whether the current section's title/header has been written
+	sectionStarted bool
+	// placements tracks where each rendered section's data starts and how many rows it has (current part)
+	placements map[*SectionConfig]*SectionPlacement
+	// parts lists every rendered section part in order; sections split by rollover have several parts
+	parts []streamPart
+	// activeSheet is the output sheet being written: the current template sheet or one of its continuations
+	activeSheet string
+	// continuations holds the continuation sheets created for each template sheet
+	continuations map[string][]string
+	// accessors holds the compiled row accessor of each section with data
+	accessors map[*SectionConfig]*rowAccessor
+	// lastSection is the section of the last Write
+	lastSection *SectionConfig
+}
+
+// streamPart is the rows of a section on one output sheet.
+type streamPart struct {
+	sec       *SectionConfig
+	placement *SectionPlacement
+}
+
+// Write appends a batch of data to the specified section.
+// The sectionID must match the ID of the current section or a future section.
+// Strict ordering is enforced: you must write to sections in the order they are defined.
+func (s *Streamer) Write(sectionID string, data interface{}) error {
+	// 1. Validation
+	if err := s.session.Check(); err != nil {
+		return err
+	}
+
+	sheet := s.getCurrentSheet()
+	if sheet == nil {
+		return fmt.Errorf("no active sheet to write to")
+	}
+
+	// Find the target section index
+	targetIndex := -1
+	for i := s.currentSectionIndex; i < len(sheet.sections); i++ {
+		if sheet.sections[i].ID == sectionID {
+			targetIndex = i
+			break
+		}
+	}
+
+	if targetIndex == -1 {
+		return fmt.Errorf("section '%s' not found in remaining sections of sheet '%s' (already passed or does not exist)", sectionID, sheet.name)
+	}
+
+	// 2. Advance if needed
+	if targetIndex > s.currentSectionIndex {
+		// We are moving to a new section.
+		// Iterate through sections we are leaving/skipping.
+		for i := s.currentSectionIndex; i < targetIndex; i++ {
+			sec := sheet.sections[i]
+			// If we are leaving the current section and we already started it (wrote Title/Header),
+			// we don't need to do anything (data provided manually).
+			// If we skipped it (sectionStarted == false), we render it as static (Title/Header only potentially).
+			if i == s.currentSectionIndex && s.sectionStarted {
+				// Just leaving.
+			} else {
+				// Skipping or Static section.
+				if err := s.renderStaticSection(sec); err != nil {
+					return err
+				}
+			}
+		}
+		s.currentSectionIndex = targetIndex
+		s.sectionStarted = false
+	}
+
+	// 3. Current Section
+	sec := sheet.sections[s.currentSectionIndex]
+
+	// 4. Resolve Columns (once if not done)
+	initialWrite := false
+	if len(sec.Columns) == 0 || (len(sec.Columns) > 0 && len(sec.Columns[0].FieldName) == 0) {
+		// Dynamic discovery needed
+		sec.Columns = mergeSectionColumns(sec, data)
+		initialWrite = true
+	} else if !s.sectionStarted {
+		// Columns exist but we haven't started this section (haven't written title/header)
+		initialWrite = true
+	}
+
+	// 5. Render Title & Header (Lazy)
+	if initialWrite {
+		s.sectionStarted = true
+
+		if err := s.renderSectionHead(sec); err != nil {
+			return err
+		}
+	}
+
+	// 6. Write Data Rows
+	if err := s.writeBatch(sec, data); err != nil {
+		return err
+	}
+	s.lastSection = sec
+	s.session.Progress(s.activeSheet, sec.ID, false)
+	return nil
+}
+
+// Splits returns the sheets the stream continued on a new sheet because they reached the row limit.
+// The list is complete once the stream is closed.
+func (s *Streamer) Splits() []SheetSplit {
+	return s.run.splits
+}
+
+// Abort discards the stream without writing the file and removes its temporary files.
+// Use it when the export cannot be completed for reasons outside the streamer.
+func (s *Streamer) Abort() {
+	s.session.Abort()
+}
+
+// Close finishes the stream and writes the file to the output.
+// The excelize temporary files are removed once the file is written.
+func (s *Streamer) Close() error {
+	if err := s.session.Check(); err != nil {
+		return err
+	}
+	defer s.session.release()
+
+	// Finish current and remaining sheets
+	for s.getCurrentSheet() != nil {
+		if err := s.finishCurrentSheet(); err != nil {
+			return err
+		}
+	}
+
+	// Tables and sheet protection must be added after rows are written but before Flush
+	if err := s.addSheetObjects(); err != nil {
+		return err
+	}
+
+	// Flush all stream writers
+	for _, sw := range s.streamWriters {
+		if err := sw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	// Streamed workbooks embed no section layout
+	if err := s.exporter.applyDocumentProperties(s.file, nil); err != nil {
+		return err
+	}
+
+	// Write entire file to output (encrypted if configured)
+	if err := s.session.Save(); err != nil {
+		return err
+	}
+
+	lastSection := ""
+	if s.lastSection != nil {
+		lastSection = s.lastSection.ID
+	}
+	s.session.Progress(s.activeSheet, lastSection, true)
+	return nil
+}
+
+// finishCurrentSheet finishes processing the current sheet (render remaining static sections)
+// and moves on to the next sheet. Streaming sections that never received data are skipped.
+func (s *Streamer) finishCurrentSheet() error {
+	sheet := s.getCurrentSheet()
+	if sheet == nil {
+		return nil
+	}
+
+	for i := s.currentSectionIndex + 1; i < len(sheet.sections); i++ {
+		sec := sheet.sections[i]
+		if !s.bindStaticData(sec) {
+			continue
+		}
+		if err := s.renderStaticSection(sec); err != nil {
+			return err
+		}
+	}
+
+	if err := s.startSheet(s.currentSheetIndex + 1); err != nil {
+		return err
+	}
+	return s.advanceToNextStreamingSection()
+}
+
+func (s *Streamer) getCurrentSheet() *SheetBuilder {
+	if s.currentSheetIndex >= len(s.run.sheets) {
+		return nil
+	}
+	return s.run.sheets[s.currentSheetIndex]
+}
+
+// advanceToNextStreamingSection renders all static sections until it hits a section
+// that expects streaming data.
+func (s *Streamer) advanceToNextStreamingSection() error {
+	sheet := s.getCurrentSheet()
+	if sheet == nil {
+		return nil
+	}
+
+	for s.currentSectionIndex < len(sheet.sections) {
+		sec := sheet.sections[s.currentSectionIndex]
+
+		if !s.bindStaticData(sec) {
+			// Found a streaming section!
+			s.sectionStarted = false
+			return nil
+		}
+
+		// Render Static Section
+		if err := s.renderStaticSection(sec); err != nil {
+			return err
+		}
+
+		s.currentSectionIndex++
+	}
+
+	if s.currentSectionIndex >= len(sheet.sections) {
+		if err := s.startSheet(s.currentSheetIndex + 1); err != nil {
+			return err
+		}
+		return s.advanceToNextStreamingSection()
+	}
+
+	return nil
+}
+
+// bindStaticData binds data from the exporter to the section and reports whether
+// the section is static (has data up front, or no ID to stream into).
+func (s *Streamer) bindStaticData(sec *SectionConfig) bool {
+	if sec.Data != nil {
+		return true
+	}
+	if sec.ID == "" {
+		return true
+	}
+	if data, ok := s.exporter.data[sec.ID]; ok {
+		sec.Data = data
+		return true
+	}
+	return false
+}
+
+func (s *Streamer) renderStaticSection(sec *SectionConfig) error {
+	if err := s.renderSectionHead(sec); err != nil {
+		return err
+	}
+
+	// Data
+	if sec.Data != nil {
+		return s.writeBatch(sec, sec.Data)
+	}
+
+	return nil
+}
+
+// renderSectionHead writes the title and header of a section and registers its placement.
+// If the head and a first data row do not fit on the active sheet, the sheet is continued on a new one.
+func (s *Streamer) renderSectionHead(sec *SectionConfig) error {
+	head := 0
+	if sec.Title != nil {
+		head++
+	}
+	if sec.ShowHeader && len(sec.Columns) > 0 {
+		head++
+	}
+	need := head
+	if sec.Type != SectionTypeTitleOnly {
+		need++
+	}
+	if s.currentRow+need-1 > s.exporter.maxRows() {
+		if s.currentRow == 1 {
+			return fmt.Errorf("section %s needs %d rows, more than the %d rows allowed per sheet", sec.ID, need, s.exporter.maxRows())
+		}
+		if err := s.rollover(sec, 0); err != nil {
+			return err
+		}
+	}
+
+	sw := s.activeWriter()
+
+	// 1. Title
+	if sec.Title != nil {
+		cell, _ := excelize.CoordinatesToCellName(1, s.currentRow)
+		title, err := s.session.cells.Title(sec)
+		if err != nil {
+			return err
+		}
+
+		colSpan := sec.ColSpan
+		if colSpan <= 0 {
+			colSpan = len(sec.Columns)
+		}
+		if colSpan < 1 {
+			colSpan = 1
+		}
+
+		if err := sw.SetRow(cell, []interface{}{title}); err != nil {
+			return err
+		}
+
+		if colSpan > 1 {
+			endCell, _ := excelize.CoordinatesToCellName(colSpan, s.currentRow)
+			sw.MergeCell(cell, endCell)
+		}
+		s.currentRow++
+	}
+
+	// 2. Header
+	if sec.ShowHeader && len(sec.Columns) > 0 {
+		cell, _ := excelize.CoordinatesToCellName(1, s.currentRow)
+
+		cells, err := s.session.cells.Headers(sec)
+		if err != nil {
+			return err
+		}
+		headers := make([]interface{}, len(cells))
+		for i, col := range sec.Columns {
+			headers[i] = cells[i]
+			if col.Width > 0 {
+				sw.SetColWidth(i+1, i+1, col.Width)
+			}
+		}
+
+		if err := sw.SetRow(cell, headers); err != nil {
+			return err
+		}
+		s.currentRow++
+	}
+
+	// REGISTER METADATA: s.currentRow is where data starts
+	s.registerPlacement(s.activeSheet, sec)
+	return nil
+}
+
+// activeWriter returns the stream writer of the active sheet.
+func (s *Streamer) activeWriter() *excelize.StreamWriter {
+	return s.streamWriters[s.activeSheet]
+}
+
+// startSheet makes the template sheet at index the active sheet, creating it and its stream writer.
+// Sheets are created in order: a template sheet after the continuations of the previous one.
+func (s *Streamer) startSheet(index int) error {
+	s.currentSheetIndex = index
+	s.currentSectionIndex = 0
+	s.currentRow = 1
+	s.sectionStarted = false
+	sheet := s.getCurrentSheet()
+	if sheet == nil {
+		return nil
+	}
+	s.activeSheet = sheet.name
+	if index == 0 {
+		s.file.SetSheetName("Sheet1", sheet.name)
+	} else if _, err := s.file.NewSheet(sheet.name); err != nil {
+		return fmt.Errorf("failed to create sheet %s: %w", sheet.name, err)
+	}
+	sw, err := s.file.NewStreamWriter(sheet.name)
+	if err != nil {
+		return fmt.Errorf("failed to create stream writer for sheet %s: %w", sheet.name, err)
+	}
+	s.streamWriters[sheet.name] = sw
+	return nil
+}
+
+// rollover continues the current template sheet on a new sheet, e.g. "Large Export (2)",
+// and records the split. rowsBefore is the number of data rows of sec already written.
+func (s *Streamer) rollover(sec *SectionConfig, rowsBefore int) error {
+	sheet := s.getCurrentSheet()
+	name := continuationSheetName(sheet.name, len(s.continuations[sheet.name])+2, s.sheetNameUsed)
+	if _, err := s.file.NewSheet(name); err != nil {
+		return fmt.Errorf("failed to create continuation sheet %s: %w", name, err)
+	}
+	sw, err := s.file.NewStreamWriter(name)
+	if err != nil {
+		return fmt.Errorf("failed to create stream writer for sheet %s: %w", name, err)
+	}
+	s.streamWriters[name] = sw
+	s.continuations[sheet.name] = append(s.continuations[sheet.name], name)
+	s.run.splits = append(s.run.splits, SheetSplit{Sheet: s.activeSheet, NextSheet: name, SectionID: sec.ID, RowsBefore: rowsBefore})
+
+	s.activeSheet = name
+	s.currentRow = 1
+	return nil
+}
+
+// sheetNameUsed reports whether a sheet name is taken by an output sheet or a template sheet not created yet.
+func (s *Streamer) sheetNameUsed(name string) bool {
+	if _, ok := s.streamWriters[name]; ok {
+		return true
+	}
+	for _, sb := range s.run.sheets {
+		if sb.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// rowsWritten returns the number of data rows written for a section across its parts.
+func (s *Streamer) rowsWritten(sec *SectionConfig) int {
+	n := 0
+	for _, p := range s.parts {
+		if p.sec == sec {
+			n += p.placement.DataLen
+		}
+	}
+	return n
+}
+
+func (s *Streamer) writeBatch(sec *SectionConfig, data interface{}) error {
+	// Resolve Columns
+	if len(sec.Columns) == 0 {
+		sec.Columns = mergeSectionColumns(sec, data)
+	}
+
+	dataVal := reflect.ValueOf(data)
+	if dataVal.Kind() == reflect.Ptr {
+		dataVal = dataVal.Elem()
+	}
+	if dataVal.Kind() != reflect.Slice {
+		return nil
+	}
+
+	// Prepare styles
+	colStyles, err := s.session.cells.dataStyles(sec, dataVal)
+	if err != nil {
+		return err
+	}
+
+	// Get metadata for formula resolution
+	placement, hasMetadata := s.placements[sec]
+	if !hasMetadata {
+		placement = &SectionPlacement{SectionID: sec.ID}
+	}
+
+	// Write rows
+	accessor := s.rowAccessor(sec, data)
+	sw := s.activeWriter()
+	for i := 0; i < dataVal.Len(); i++ {
+		if err := s.session.AddRow(sec.ID); err != nil {
+			return err
+		}
+
+		// Continue on a new sheet with the title and header repeated
+		if s.currentRow > s.exporter.maxRows() {
+			if err := s.rollover(sec, s.rowsWritten(sec)); err != nil {
+				return err
+			}
+			if hasMetadata {
+				s.run.placements[placement.SectionID] = *placement
+			}
+			if err := s.renderSectionHead(sec); err != nil {
+				return err
+			}
+			sw = s.activeWriter()
+			placement, hasMetadata = s.placements[sec], true
+		}
+
+		// Calculate rowOffset for formula.
+		// If we have metadata, use (s.currentRow - placement.StartRow).
+		rowOffset := 0
+		if hasMetadata {
+			rowOffset = s.currentRow - placement.StartRow
+		}
+
+		cell, _ := excelize.CoordinatesToCellName(1, s.currentRow)
+		// Banding and row stylers count the rows across the parts of the section
+		rowIndex := s.run.sectionRow(*placement, rowOffset)
+		rowVals, err := s.session.cells.rowCells(sec, *placement, accessor, colStyles, dataVal.Index(i), rowIndex, rowOffset)
+		if err != nil {
+			return err
+		}
+		if err := sw.SetRow(cell, rowVals); err != nil {
+			return err
+		}
+		s.currentRow++
+		placement.DataLen++
+	}
+	if hasMetadata {
+		s.run.placements[placement.SectionID] = *placement
+	}
+	return nil
+}
+
+// rowAccessor returns the accessor of a section for the item type of data, compiled on the first batch.
+func (s *Streamer) rowAccessor(sec *SectionConfig, data interface{}) *rowAccessor {
+	a := s.accessors[sec]
+	if a == nil || a.itemType != sliceItemType(data) {
+		a = s.exporter.compileRowAccessor(sec.Columns, data)
+		s.accessors[sec] = a
+	}
+	return a
+}
+
+// registerPlacement records where the data of a section starts on the given sheet.
+// The placement is used for formula resolution and, at Close, for tables and defined names.
+func (s *Streamer) registerPlacement(sheetName string, sec *SectionConfig) {
+	fieldOffsets := make(map[string]int)
+	for j, col := range sec.Columns {
+		fieldOffsets[col.FieldName] = j
+	}
+	part := 1
+	for _, p := range s.parts {
+		if p.sec == sec {
+			part++
+		}
+	}
+	placement := &SectionPlacement{
+		SectionID:    partID(sec.ID, part),
+		Sheet:        sheetName,
+		StartRow:     s.currentRow, // Current stream row is the data start row
+		StartCol:     1,            // Streamer always starts at col 1 for now
+		FieldOffsets: fieldOffsets,
+		DataLen:      0, // Grows as batches are written
+	}
+	s.run.addPart(sec.ID, placement.SectionID, s.rowsWritten(sec))
+	s.placements[sec] = placement
+	s.parts = append(s.parts, streamPart{sec: sec, placement: placement})
+	s.run.placements[placement.SectionID] = *placement
+}
+
+// addSheetObjects adds tables, defined names and protection for every rendered sheet.
+// Excelize supports a single table per stream writer, so only one as_table section is allowed per sheet.
+// Parts of split sections are named after partID, e.g. "products_2_table".
+func (s *Streamer) addSheetObjects() error {
+	hasTable := make(map[string]bool)
+	for _, p := range s.parts {
+		sec := p.sec
+		if p.placement.SectionID != sec.ID {
+			c := *sec
+			c.ID = p.placement.SectionID
+			sec = &c
+		}
+		if sec.AsTable {
+			if hasTable[p.placement.Sheet] {
+				return fmt.Errorf("section %s: only one as_table section per sheet is supported when streaming", sec.ID)
+			}
+			table, err := s.exporter.sectionTable(sec, *p.placement)
+			if err != nil {
+				return err
+			}
+			if err := s.streamWriters[p.placement.Sheet].AddTable(table); err != nil {
+				return fmt.Errorf("add table for section %s: %w", sec.ID, err)
+			}
+			hasTable[p.placement.Sheet] = true
+		}
+		if err := registerSectionNames(s.file, sec, *p.placement); err != nil {
+			return err
+		}
+	}
+
+	for _, sb := range s.run.sheets {
+		// Stream writers cannot unlock unused cells, so only explicitly configured sheets are protected
+		for _, name := range s.outputSheets(sb) {
+			if err := s.exporter.protectSheet(s.file, name, sb.protection, false); err != nil {
+				return err
+			}
+		}
+	}
+	return s.exporter.protectWorkbook(s.file)
+}
+
+// outputSheets returns a template sheet followed by its continuation sheets.
+func (s *Streamer) outputSheets(sb *SheetBuilder) []string {
+	return append([]string{sb.name}, s.continuations[sb.name]...)
+}
@@ -0,0 +1,131 @@
+package excel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// DefaultTableStyle is the built-in table style used when as_table is set without table_style.
+const DefaultTableStyle = "TableStyleMedium2"
+
+// cellRefLikeName matches names Excel would read as a cell reference (A1, XFD10, R1C1).
+var cellRefLikeName = regexp.MustCompile(`^(?i:[a-z]{1,3}\d+|r\d*c\d*)$`)
+
+// definedName converts an arbitrary identifier (section ID, field name) into a valid Excel defined name.
+// Invalid characters are replaced with "_", and names that start with a digit or look like a cell
+// reference are prefixed with "_".
+func definedName(parts ...string) string {
+	var sb strings.Builder
+	for _, r := range strings.Join(parts, "_") {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	name := sb.String()
+	if name == "" {
+		return ""
+	}
+	if unicode.IsDigit([]rune(name)[0]) || cellRefLikeName.MatchString(name) {
+		name = "_" + name
+	}
+	return name
+}
+
+// tableName returns the Excel table name for a section. Tables share the defined name namespace,
+// so the name is suffixed to avoid clashing with the section's data range name.
+func tableName(sec *SectionConfig) string {
+	if sec.ID == "" {
+		return ""
+	}
+	return definedName(sec.ID, "table")
+}
+
+// absoluteRangeRef returns a sheet-qualified absolute range reference, e.g. 'Sheet1'!$A$2:$C$10.
+func absoluteRangeRef(sheet string, startCol, startRow, endCol, endRow int) (string, error) {
+	start, err := excelize.CoordinatesToCellName(startCol, startRow, true)
+	if err != nil {
+		return "", err
+	}
+	end, err := excelize.CoordinatesToCellName(endCol, endRow, true)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s!%s:%s", quoteSheetName(sheet), start, end), nil
+}
+
+// validateTableSection checks the constraints Excel puts on tables, for the headers as written.
+func (e *ExcelDataExporter) validateTableSection(sec *SectionConfig) error {
+	if !sec.ShowHeader {
+		return fmt.Errorf("section %s: as_table requires show_header", sec.ID)
+	}
+	seen := make(map[string]bool)
+	for _, col := range sec.Columns {
+		header := e.localizedHeader(col)
+		key := strings.ToLower(header)
+		if header == "" || seen[key] {
+			return fmt.Errorf("section %s: as_table requires unique, non-empty headers (got %q)", sec.ID, header)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// sectionTable builds the table definition covering the header and data rows of a placed section.
+func (e *ExcelDataExporter) sectionTable(sec *SectionConfig, placement SectionPlacement) (*excelize.Table, error) {
+	if err := e.validateTableSection(sec); err != nil {
+		return nil, err
+	}
+	headerRow := placement.StartRow - 1
+	endRow := placement.StartRow + placement.DataLen - 1
+	if endRow <= headerRow {
+		endRow = headerRow + 1 // Tables need at least one data row
+	}
+	start, _ := excelize.CoordinatesToCellName(placement.StartCol, headerRow)
+	end, _ := excelize.CoordinatesToCellName(placement.StartCol+len(sec.Columns)-1, endRow)
+
+	style := sec.TableStyle
+	if style == "" {
+		style = DefaultTableStyle
+	}
+	return &excelize.Table{
+		Range:     start + ":" + end,
+		Name:      tableName(sec),
+		StyleName: style,
+	}, nil
+}
+
+// registerSectionNames registers the section data range (and, with name_columns, each column's data range)
+// as workbook defined names. Sections without an ID or without data rows are skipped.
+func registerSectionNames(f *excelize.File, sec *SectionConfig, placement SectionPlacement) error {
+	if sec.ID == "" || placement.DataLen == 0 || len(sec.Columns) == 0 {
+		return nil
+	}
+	endRow := placement.StartRow + placement.DataLen - 1
+	ref, err := absoluteRangeRef(placement.Sheet, placement.StartCol, placement.StartRow, placement.StartCol+len(sec.Columns)-1, endRow)
+	if err != nil {
+		return err
+	}
+	if err := f.SetDefinedName(&excelize.DefinedName{Name: definedName(sec.ID), RefersTo: ref}); err != nil {
+		return fmt.Errorf("define name for section %s: %w", sec.ID, err)
+	}
+
+	if !sec.NameColumns {
+		return nil
+	}
+	for j, col := range sec.Columns {
+		ref, err := absoluteRangeRef(placement.Sheet, placement.StartCol+j, placement.StartRow, placement.StartCol+j, endRow)
+		if err != nil {
+			return err
+		}
+		if err := f.SetDefinedName(&excelize.DefinedName{Name: definedName(sec.ID, col.FieldName), RefersTo: ref}); err != nil {
+			return fmt.Errorf("define name for column %s.%s: %w", sec.ID, col.FieldName, err)
+		}
+	}
+	return nil
+}
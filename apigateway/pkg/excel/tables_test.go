@@ -0,0 +1,124 @@
+package excel
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func definedNames(f *excelize.File) map[string]string {
+	names := make(map[string]string)
+	for _, dn := range f.GetDefinedName() {
+		names[dn.Name] = dn.RefersTo
+	}
+	return names
+}
+
+func TestSectionAsTableAndDefinedNames(t *testing.T) {
+	yamlConfig := `
+sheets:
+  - name: "Executive Report"
+    sections:
+      - id: "product_section_editable"
+        title: "Products"
+        show_header: true
+        has_filter: true
+        as_table: true
+        table_style: "TableStyleLight9"
+        name_columns: true
+        columns:
+          - field_name: "Name"
+            header: "Product Name"
+            hidden_field_name: "db_name"
+          - field_name: "Unit Price"
+            header: "Price"
+`
+	exporter, err := NewExcelDataExporterFromYamlConfig(yamlConfig)
+	assert.NoError(t, err)
+
+	exporter.BindSectionData("product_section_editable", []map[string]interface{}{
+		{"Name": "Laptop", "Unit Price": 1299.99},
+		{"Name": "Phone", "Unit Price": 899.99},
+	})
+
+	f, err := exporter.BuildExcel()
+	assert.NoError(t, err)
+
+	// Row 1: Title, Row 2: Hidden, Row 3: Header, Rows 4-5: Data
+	tables, err := f.GetTables("Executive Report")
+	assert.NoError(t, err)
+	if assert.Len(t, tables, 1) {
+		assert.Equal(t, "A3:B5", tables[0].Range)
+		assert.Equal(t, "product_section_editable_table", tables[0].Name)
+		assert.Equal(t, "TableStyleLight9", tables[0].StyleName)
+	}
+
+	names := definedNames(f)
+	assert.Equal(t, "'Executive Report'!$A$4:$B$5", names["product_section_editable"])
+	assert.Equal(t, "'Executive Report'!$A$4:$A$5", names["product_section_editable_Name"])
+	assert.Equal(t, "'Executive Report'!$B$4:$B$5", names["product_section_editable_Unit_Price"])
+}
+
+func TestSectionAsTableRequiresUniqueHeaders(t *testing.T) {
+	exporter := NewExcelDataExporter()
+	exporter.AddSheet("Dupes").
+		AddSection(&SectionConfig{
+			ID:         "dupes",
+			ShowHeader: true,
+			AsTable:    true,
+			Data:       []map[string]interface{}{{"A": 1, "B": 2}},
+			Columns: []ColumnConfig{
+				{FieldName: "A", Header: "Value"},
+				{FieldName: "B", Header: "Value"},
+			},
+		})
+
+	_, err := exporter.BuildExcel()
+	assert.Error(t, err)
+}
+
+func TestDefinedName(t *testing.T) {
+	assert.Equal(t, "wiki_data", definedName("wiki-data"))
+	assert.Equal(t, "_2024_report", definedName("2024 report"))
+	assert.Equal(t, "_A1", definedName("A1"))
+	assert.Equal(t, "sales_Q1", definedName("sales", "Q1"))
+}
+
+func TestStreamingTableAndDefinedNames(t *testing.T) {
+	exporter := NewExcelDataExporter()
+	exporter.AddSheet("Stream").
+		AddSection(&SectionConfig{
+			ID:         "wiki-data",
+			Title:      "People",
+			ShowHeader: true,
+			AsTable:    true,
+			Columns: []ColumnConfig{
+				{FieldName: "Name", Header: "Person Name"},
+				{FieldName: "URL", Header: "Wiki URL"},
+			},
+		})
+
+	type Person struct{ Name, URL string }
+
+	buf := new(bytes.Buffer)
+	streamer, err := exporter.StartStream(buf)
+	assert.NoError(t, err)
+	assert.NoError(t, streamer.Write("wiki-data", []Person{{"Ada", "a"}, {"Alan", "b"}}))
+	assert.NoError(t, streamer.Write("wiki-data", []Person{{"Grace", "c"}}))
+	assert.NoError(t, streamer.Close())
+
+	f, err := excelize.OpenReader(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	defer f.Close()
+
+	// Row 1: Title, Row 2: Header, Rows 3-5: Data
+	tables, err := f.GetTables("Stream")
+	assert.NoError(t, err)
+	if assert.Len(t, tables, 1) {
+		assert.Equal(t, "A2:B5", tables[0].Range)
+		assert.Equal(t, "wiki_data_table", tables[0].Name)
+	}
+	assert.Equal(t, "'Stream'!$A$3:$B$5", definedNames(f)["wiki_data"])
+}
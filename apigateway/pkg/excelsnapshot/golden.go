@@ -0,0 +1,237 @@
+package excelsnapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// update rewrites golden files instead of comparing them: go test ./pkg/excel -args -update
+var update = flag.Bool("update", false, "update the golden files of excelsnapshot")
+
+// maxDiffCells bounds the line diff (lines of golden x lines of snapshot); larger diffs only show the first
+// difference.
+const maxDiffCells = 4000000
+
+// Text returns the snapshot in its text format, one line per fact:
+//
+//	sheet "Products"
+//	  protection: formatCells=0 password=set sheet=1
+//	  col A width=35
+//	  row 1 height=30 hidden
+//	  merge A1:C1
+//	  A1 string "Product Name" s1
+//	  C2 number "" =A2*B2 s2
+//	style s1 {"Font":{"Bold":true}}
+func (s *Snapshot) Text() string {
+	var sb strings.Builder
+	if len(s.Protection) > 0 {
+		fmt.Fprintf(&sb, "workbook protection: %s\n", strings.Join(s.Protection, " "))
+	}
+	for _, name := range s.DefinedNames {
+		fmt.Fprintf(&sb, "name %s\n", name)
+	}
+	for _, sheet := range s.Sheets {
+		fmt.Fprintf(&sb, "sheet %q", sheet.Name)
+		if sheet.Hidden {
+			sb.WriteString(" hidden")
+		}
+		sb.WriteString("\n")
+		if len(sheet.Protection) > 0 {
+			fmt.Fprintf(&sb, "  protection: %s\n", strings.Join(sheet.Protection, " "))
+		}
+		for _, col := range sheet.Columns {
+			fmt.Fprintf(&sb, "  col %s%s\n", col.Col, sizeText("width", col.Width, col.Hidden))
+		}
+		for _, row := range sheet.Rows {
+			fmt.Fprintf(&sb, "  row %d%s\n", row.Row, sizeText("height", row.Height, row.Hidden))
+		}
+		for _, merge := range sheet.Merges {
+			fmt.Fprintf(&sb, "  merge %s\n", merge)
+		}
+		for _, v := range sheet.Validations {
+			fmt.Fprintf(&sb, "  validation %s\n", v)
+		}
+		for _, cell := range sheet.Cells {
+			fmt.Fprintf(&sb, "  %s %s %q", cell.Cell, cell.Type, cell.Value)
+			if cell.Formula != "" {
+				fmt.Fprintf(&sb, " =%s", cell.Formula)
+			}
+			if cell.Style != "" {
+				fmt.Fprintf(&sb, " %s", cell.Style)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	names := make([]string, 0, len(s.Styles))
+	for name := range s.Styles {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, _ := strconv.Atoi(names[i][1:])
+		b, _ := strconv.Atoi(names[j][1:])
+		return a < b
+	})
+	for _, name := range names {
+		fmt.Fprintf(&sb, "style %s %s\n", name, s.Styles[name])
+	}
+	return sb.String()
+}
+
+func sizeText(name string, size float64, hidden bool) string {
+	var sb strings.Builder
+	if size != 0 {
+		fmt.Fprintf(&sb, " %s=%s", name, strconv.FormatFloat(size, 'f', -1, 64))
+	}
+	if hidden {
+		sb.WriteString(" hidden")
+	}
+	return sb.String()
+}
+
+// JSON returns the snapshot as indented JSON.
+func (s *Snapshot) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// format renders the snapshot for a golden file: JSON for .json files, text otherwise.
+func (s *Snapshot) format(golden string) ([]byte, error) {
+	if strings.EqualFold(filepath.Ext(golden), ".json") {
+		return s.JSON()
+	}
+	return []byte(s.Text()), nil
+}
+
+// Compare compares the snapshot with a golden file (JSON for .json files, text otherwise) and returns an
+// error with a line diff if they differ. With update, the golden file is written instead.
+func (s *Snapshot) Compare(golden string, update bool) error {
+	got, err := s.format(golden)
+	if err != nil {
+		return err
+	}
+	if update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(golden, got, 0o644)
+	}
+	want, err := os.ReadFile(golden)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("golden file %s does not exist; run the test with -update to create it", golden)
+	}
+	if err != nil {
+		return err
+	}
+	if string(want) == string(got) {
+		return nil
+	}
+	return fmt.Errorf("snapshot differs from %s (run the test with -update to accept it):\n%s", golden, lineDiff(string(want), string(got)))
+}
+
+// AssertGolden takes the snapshot of f and compares it with a golden file, failing t with a line diff if they
+// differ. Run the tests with -update to write the golden files:
+//
+//	go test ./pkg/excel -run TestReportGolden -args -update
+func AssertGolden(t testing.TB, f *excelize.File, golden string, opts Options) {
+	t.Helper()
+	s, err := Take(f, opts)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if err := s.Compare(golden, *update); err != nil {
+		t.Error(err)
+	}
+}
+
+// lineDiff returns the lines removed from (-) and added to (+) want, with up to two lines of context.
+func lineDiff(want, got string) string {
+	a := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if len(a)*len(b) > maxDiffCells {
+		for i := 0; i < len(a) || i < len(b); i++ {
+			if i >= len(a) || i >= len(b) || a[i] != b[i] {
+				return fmt.Sprintf("first difference at line %d:\n- %s\n+ %s\n", i+1, lineAt(a, i), lineAt(b, i))
+			}
+		}
+	}
+
+	// Longest common subsequence of the lines
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type line struct {
+		op   byte
+		text string
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, line{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, line{'+', b[j]})
+			j++
+		}
+	}
+
+	const context = 2
+	var sb strings.Builder
+	last := -1
+	for k, l := range lines {
+		near := false
+		for d := k - context; d <= k+context; d++ {
+			if d >= 0 && d < len(lines) && lines[d].op != ' ' {
+				near = true
+				break
+			}
+		}
+		if !near {
+			continue
+		}
+		if last >= 0 && k > last+1 {
+			sb.WriteString("  ...\n")
+		}
+		fmt.Fprintf(&sb, "%c %s\n", l.op, l.text)
+		last = k
+	}
+	return sb.String()
+}
+
+func lineAt(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return "<end of file>"
+}
@@ -0,0 +1,494 @@
+// Package excelsnapshot turns workbooks into canonical, deterministic snapshots (text or JSON) and compares
+// them with golden files, so changes to generated workbooks are reviewable as text diffs.
+package excelsnapshot
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Default column width and row height of excelize; only other values are part of a snapshot.
+const (
+	defaultColWidth  = 9.140625
+	defaultRowHeight = 15
+)
+
+// Options selects what a snapshot contains.
+type Options struct {
+	Sheets   []string // Sheets to include (default: all, in workbook order)
+	NoStyles bool     // Leave out cell styles
+}
+
+// Snapshot is the canonical content of a workbook. Styles are numbered s1, s2, ... in order of first use,
+// so snapshots do not depend on style IDs. Volatile values (document properties, password hashes and salts)
+// are left out.
+type Snapshot struct {
+	Protection   []string          `json:"protection,omitempty"` // Workbook protection attributes, "name=value"
+	DefinedNames []string          `json:"defined_names,omitempty"`
+	Sheets       []Sheet           `json:"sheets"`
+	Styles       map[string]string `json:"styles,omitempty"` // Canonical JSON of each style
+}
+
+// Sheet is the snapshot of a worksheet.
+type Sheet struct {
+	Name        string   `json:"name"`
+	Hidden      bool     `json:"hidden,omitempty"`
+	Protection  []string `json:"protection,omitempty"` // Sheet protection attributes, "name=value"
+	Columns     []Column `json:"columns,omitempty"`    // Columns with a custom width or hidden
+	Rows        []Row    `json:"rows,omitempty"`       // Rows with a custom height or hidden
+	Merges      []string `json:"merges,omitempty"`
+	Validations []string `json:"validations,omitempty"` // Canonical JSON of each data validation
+	Cells       []Cell   `json:"cells"`                 // Cells with a value or formula, by row and column
+}
+
+// Column is a column with a custom width or hidden.
+type Column struct {
+	Col    string  `json:"col"`
+	Width  float64 `json:"width,omitempty"`
+	Hidden bool    `json:"hidden,omitempty"`
+}
+
+// Row is a row with a custom height or hidden.
+type Row struct {
+	Row    int     `json:"row"`
+	Height float64 `json:"height,omitempty"`
+	Hidden bool    `json:"hidden,omitempty"`
+}
+
+// Cell is a cell with a value or formula. Value is the raw value, without number formats applied.
+type Cell struct {
+	Cell    string `json:"cell"`
+	Type    string `json:"type,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Formula string `json:"formula,omitempty"`
+	Style   string `json:"style,omitempty"`
+}
+
+// cellTypeNames names the cell types; cells without a type attribute are numbers.
+var cellTypeNames = map[excelize.CellType]string{
+	excelize.CellTypeUnset:        "number",
+	excelize.CellTypeBool:         "bool",
+	excelize.CellTypeDate:         "date",
+	excelize.CellTypeError:        "error",
+	excelize.CellTypeFormula:      "str",
+	excelize.CellTypeInlineString: "inline",
+	excelize.CellTypeNumber:       "number",
+	excelize.CellTypeSharedString: "string",
+}
+
+// Take takes the snapshot of a workbook. The workbook is read from a serialized copy, so f is not modified
+// and protection settings, which excelize cannot read back, are included.
+func Take(f *excelize.File, opts Options) (*Snapshot, error) {
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("serialize workbook: %w", err)
+	}
+	data := buf.Bytes()
+	wb, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("reopen workbook: %w", err)
+	}
+	defer wb.Close()
+	parts, err := readProtection(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Snapshot{Protection: parts.workbook, Styles: make(map[string]string)}
+	for _, dn := range wb.GetDefinedName() {
+		scope := dn.Scope
+		if scope == "" {
+			scope = "Workbook"
+		}
+		s.DefinedNames = append(s.DefinedNames, fmt.Sprintf("%s!%s = %s", scope, dn.Name, dn.RefersTo))
+	}
+	sort.Strings(s.DefinedNames)
+
+	sheets := opts.Sheets
+	if len(sheets) == 0 {
+		sheets = wb.GetSheetList()
+	}
+	styles := make(map[int]string)
+	for _, name := range sheets {
+		sheet, err := takeSheet(wb, name, opts, s, styles)
+		if err != nil {
+			return nil, fmt.Errorf("sheet %s: %w", name, err)
+		}
+		sheet.Protection = parts.sheets[name]
+		s.Sheets = append(s.Sheets, *sheet)
+	}
+	return s, nil
+}
+
+func takeSheet(f *excelize.File, name string, opts Options, s *Snapshot, styles map[int]string) (*Sheet, error) {
+	if idx, err := f.GetSheetIndex(name); err != nil || idx < 0 {
+		return nil, fmt.Errorf("sheet does not exist")
+	}
+	sheet := &Sheet{Name: name, Cells: []Cell{}}
+	visible, err := f.GetSheetVisible(name)
+	if err != nil {
+		return nil, err
+	}
+	sheet.Hidden = !visible
+
+	maxCol, maxRow, err := dimension(f, name)
+	if err != nil {
+		return nil, err
+	}
+	for c := 1; c <= maxCol; c++ {
+		col, _ := excelize.ColumnNumberToName(c)
+		width, err := f.GetColWidth(name, col)
+		if err != nil {
+			return nil, err
+		}
+		colVisible, err := f.GetColVisible(name, col)
+		if err != nil {
+			return nil, err
+		}
+		if width != defaultColWidth || !colVisible {
+			column := Column{Col: col, Hidden: !colVisible}
+			if width != defaultColWidth {
+				column.Width = width
+			}
+			sheet.Columns = append(sheet.Columns, column)
+		}
+	}
+	for r := 1; r <= maxRow; r++ {
+		height, err := f.GetRowHeight(name, r)
+		if err != nil {
+			return nil, err
+		}
+		rowVisible, err := f.GetRowVisible(name, r)
+		if err != nil {
+			return nil, err
+		}
+		if height != defaultRowHeight || !rowVisible {
+			row := Row{Row: r, Hidden: !rowVisible}
+			if height != defaultRowHeight {
+				row.Height = height
+			}
+			sheet.Rows = append(sheet.Rows, row)
+		}
+	}
+
+	merges, err := f.GetMergeCells(name)
+	if err != nil {
+		return nil, err
+	}
+	// excelize reads every cell of a merged range as its first cell; only the first cell is kept
+	merged := make(map[string]bool)
+	for _, m := range merges {
+		sheet.Merges = append(sheet.Merges, m.GetStartAxis()+":"+m.GetEndAxis())
+		startCol, startRow, _ := excelize.CellNameToCoordinates(m.GetStartAxis())
+		endCol, endRow, _ := excelize.CellNameToCoordinates(m.GetEndAxis())
+		for r := startRow; r <= endRow; r++ {
+			for c := startCol; c <= endCol; c++ {
+				if r != startRow || c != startCol {
+					merged[cellNameOf(c, r)] = true
+				}
+			}
+		}
+	}
+	sort.Strings(sheet.Merges)
+
+	validations, err := f.GetDataValidations(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, dv := range validations {
+		v, err := canonicalJSON(dv)
+		if err != nil {
+			return nil, err
+		}
+		sheet.Validations = append(sheet.Validations, v)
+	}
+	sort.Strings(sheet.Validations)
+
+	for r := 1; r <= maxRow; r++ {
+		for c := 1; c <= maxCol; c++ {
+			addr := cellNameOf(c, r)
+			if merged[addr] {
+				continue
+			}
+			value, err := f.GetCellValue(name, addr, excelize.Options{RawCellValue: true})
+			if err != nil {
+				return nil, err
+			}
+			formula, err := f.GetCellFormula(name, addr)
+			if err != nil {
+				return nil, err
+			}
+			if value == "" && formula == "" {
+				continue
+			}
+			cellType, err := f.GetCellType(name, addr)
+			if err != nil {
+				return nil, err
+			}
+			cell := Cell{Cell: addr, Type: cellTypeNames[cellType], Value: value, Formula: formula}
+			if !opts.NoStyles {
+				if cell.Style, err = styleName(f, name, addr, s, styles); err != nil {
+					return nil, err
+				}
+			}
+			sheet.Cells = append(sheet.Cells, cell)
+		}
+	}
+	return sheet, nil
+}
+
+// dimension returns the last column and row of the used range of a sheet.
+func dimension(f *excelize.File, sheet string) (int, int, error) {
+	ref, err := f.GetSheetDimension(sheet)
+	if err != nil {
+		return 0, 0, err
+	}
+	cells := strings.Split(ref, ":")
+	col, row, err := excelize.CellNameToCoordinates(cells[len(cells)-1])
+	if err != nil {
+		return 0, 0, nil // Empty sheet
+	}
+	// The dimension is not always maintained and leaves out empty rows, e.g. hidden ones
+	rows, err := f.Rows(sheet)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+	for r := 1; rows.Next(); r++ {
+		values, err := rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return 0, 0, err
+		}
+		if r > row {
+			row = r
+		}
+		if len(values) > col {
+			col = len(values)
+		}
+	}
+	return col, row, rows.Error()
+}
+
+func cellNameOf(col, row int) string {
+	name, _ := excelize.CoordinatesToCellName(col, row)
+	return name
+}
+
+// styleName returns the snapshot name of the style of a cell, registering it on first use. Cells without
+// a style have no name.
+func styleName(f *excelize.File, sheet, cell string, s *Snapshot, styles map[int]string) (string, error) {
+	id, err := f.GetCellStyle(sheet, cell)
+	if err != nil || id == 0 {
+		return "", err
+	}
+	if name, ok := styles[id]; ok {
+		return name, nil
+	}
+	style, err := f.GetStyle(id)
+	if err != nil {
+		return "", err
+	}
+	canonical, err := canonicalJSON(style)
+	if err != nil {
+		return "", err
+	}
+	// Different IDs may hold equal styles
+	for name, existing := range s.Styles {
+		if existing == canonical {
+			styles[id] = name
+			return name, nil
+		}
+	}
+	name := fmt.Sprintf("s%d", len(s.Styles)+1)
+	s.Styles[name] = canonical
+	styles[id] = name
+	return name, nil
+}
+
+// canonicalJSON encodes v as JSON with sorted keys and without zero values. Protection settings are kept
+// even when false, because an unlocked cell differs from the locked default.
+func canonicalJSON(v interface{}) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return "", err
+	}
+	// Keep formulas such as "<formula1>" readable
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(stripZero(generic, false)); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func stripZero(v interface{}, keep bool) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			item = stripZero(item, keep || k == "Protection")
+			if item != nil {
+				out[k] = item
+			}
+		}
+		if len(out) == 0 && !keep {
+			return nil
+		}
+		return out
+	case []interface{}:
+		var out []interface{}
+		for _, item := range t {
+			if item = stripZero(item, keep); item != nil {
+				out = append(out, item)
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	case string:
+		if t == "" && !keep {
+			return nil
+		}
+	case float64:
+		if t == 0 && !keep {
+			return nil
+		}
+	case bool:
+		if !t && !keep {
+			return nil
+		}
+	}
+	return v
+}
+
+// protectionParts are the protection attributes read from the package XML.
+type protectionParts struct {
+	workbook []string
+	sheets   map[string][]string
+}
+
+// readProtection reads the workbookProtection and sheetProtection elements of a serialized workbook.
+func readProtection(data []byte) (*protectionParts, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open workbook package: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, zf := range zr.File {
+		files[zf.Name] = zf
+	}
+
+	parts := &protectionParts{sheets: make(map[string][]string)}
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodePart(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if err := decodePart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	if parts.workbook, err = elementAttrs(files, "xl/workbook.xml", "workbookProtection"); err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		target := strings.TrimPrefix(rel.Target, "/")
+		if !strings.HasPrefix(target, "xl/") {
+			target = path.Join("xl", target)
+		}
+		targets[rel.ID] = target
+	}
+	for _, sheet := range workbook.Sheets {
+		attrs, err := elementAttrs(files, targets[sheet.RID], "sheetProtection")
+		if err != nil {
+			return nil, err
+		}
+		parts.sheets[sheet.Name] = attrs
+	}
+	return parts, nil
+}
+
+func openPart(files map[string]*zip.File, name string) (io.ReadCloser, error) {
+	zf, ok := files[name]
+	if !ok {
+		return nil, fmt.Errorf("workbook package has no %s", name)
+	}
+	return zf.Open()
+}
+
+func decodePart(files map[string]*zip.File, name string, v interface{}) error {
+	rc, err := openPart(files, name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("decode %s: %w", name, err)
+	}
+	return nil
+}
+
+// elementAttrs returns the sorted "name=value" attributes of the first element named local in a part.
+// Password hashes and salts are random per save, so they are reduced to "password=set".
+func elementAttrs(files map[string]*zip.File, name, local string) ([]string, error) {
+	rc, err := openPart(files, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode %s: %w", name, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != local {
+			continue
+		}
+		seen := make(map[string]bool)
+		var attrs []string
+		for _, attr := range start.Attr {
+			lower := strings.ToLower(attr.Name.Local)
+			entry := attr.Name.Local + "=" + attr.Value
+			if strings.HasSuffix(lower, "password") || strings.HasSuffix(lower, "hashvalue") || strings.HasSuffix(lower, "saltvalue") {
+				entry = "password=set"
+			}
+			if !seen[entry] {
+				seen[entry] = true
+				attrs = append(attrs, entry)
+			}
+		}
+		sort.Strings(attrs)
+		return attrs, nil
+	}
+}
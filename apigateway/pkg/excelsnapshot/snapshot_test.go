@@ -0,0 +1,105 @@
+package excelsnapshot
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+// newWorkbook builds a workbook using every feature of a snapshot.
+func newWorkbook(t *testing.T) *excelize.File {
+	f := excelize.NewFile()
+	t.Cleanup(func() { f.Close() })
+	const sheet = "Sheet1"
+	require.NoError(t, f.SetSheetName(sheet, "Products"))
+	require.NoError(t, f.SetSheetRow("Products", "A1", &[]interface{}{"Products"}))
+	require.NoError(t, f.MergeCell("Products", "A1", "C1"))
+	require.NoError(t, f.SetSheetRow("Products", "A2", &[]interface{}{"Name", "Price", "Total"}))
+	require.NoError(t, f.SetSheetRow("Products", "A3", &[]interface{}{"Bolt", 1.25, nil}))
+	require.NoError(t, f.SetCellFormula("Products", "C3", "B3*2"))
+	require.NoError(t, f.SetColWidth("Products", "A", "A", 30))
+	require.NoError(t, f.SetRowHeight("Products", 2, 20))
+	require.NoError(t, f.SetRowVisible("Products", 5, false))
+
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	require.NoError(t, err)
+	boldAgain, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}, Fill: excelize.Fill{}})
+	require.NoError(t, err)
+	unlocked, err := f.NewStyle(&excelize.Style{NumFmt: 2, Protection: &excelize.Protection{Locked: false}})
+	require.NoError(t, err)
+	require.NoError(t, f.SetCellStyle("Products", "A1", "A1", bold))
+	require.NoError(t, f.SetCellStyle("Products", "A2", "C2", boldAgain))
+	require.NoError(t, f.SetCellStyle("Products", "B3", "B3", unlocked))
+
+	dv := excelize.NewDataValidation(true)
+	dv.Sqref = "B3:B10"
+	require.NoError(t, dv.SetRange(0, 1000, excelize.DataValidationTypeDecimal, excelize.DataValidationOperatorBetween))
+	require.NoError(t, f.AddDataValidation("Products", dv))
+	require.NoError(t, f.SetDefinedName(&excelize.DefinedName{Name: "Prices", RefersTo: "Products!$B$3:$B$10"}))
+	require.NoError(t, f.ProtectSheet("Products", &excelize.SheetProtectionOptions{Password: "secret", AlgorithmName: "SHA-512", FormatCells: true}))
+	require.NoError(t, f.ProtectWorkbook(&excelize.WorkbookProtectionOptions{Password: "secret", LockStructure: true}))
+
+	_, err = f.NewSheet("Notes")
+	require.NoError(t, err)
+	require.NoError(t, f.SetCellValue("Notes", "A1", true))
+	require.NoError(t, f.SetSheetVisible("Notes", false))
+	return f
+}
+
+func TestAssertGolden(t *testing.T) {
+	AssertGolden(t, newWorkbook(t), filepath.Join("testdata", "workbook.golden.txt"), Options{})
+	AssertGolden(t, newWorkbook(t), filepath.Join("testdata", "workbook.golden.json"), Options{Sheets: []string{"Notes"}})
+}
+
+func TestTakeIsDeterministic(t *testing.T) {
+	f := newWorkbook(t)
+	first, err := Take(f, Options{})
+	require.NoError(t, err)
+	second, err := Take(f, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, first.Text(), second.Text())
+
+	// Equal styles with different IDs share a name
+	assert.Equal(t, "s1", first.Sheets[0].Cells[0].Style)
+	assert.Equal(t, "s1", first.Sheets[0].Cells[1].Style)
+	assert.Len(t, first.Styles, 2)
+
+	noStyles, err := Take(f, Options{NoStyles: true})
+	require.NoError(t, err)
+	assert.Empty(t, noStyles.Styles)
+	assert.Empty(t, noStyles.Sheets[0].Cells[0].Style)
+}
+
+func TestCompare(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "book.golden.txt")
+	f := newWorkbook(t)
+	s, err := Take(f, Options{})
+	require.NoError(t, err)
+
+	err = s.Compare(golden, false)
+	assert.EqualError(t, err, "golden file "+golden+" does not exist; run the test with -update to create it")
+	require.NoError(t, s.Compare(golden, true))
+	require.NoError(t, s.Compare(golden, false))
+
+	require.NoError(t, f.SetCellValue("Products", "A3", "Nut"))
+	changed, err := Take(f, Options{})
+	require.NoError(t, err)
+	err = changed.Compare(golden, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `-   A3 string "Bolt"`)
+	assert.Contains(t, err.Error(), `+   A3 string "Nut"`)
+}
+
+func TestLineDiff(t *testing.T) {
+	want := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	got := "a\nb\nX\nd\ne\nf\ng\nh\ni\n"
+	assert.Equal(t, "  a\n  b\n- c\n+ X\n  d\n  e\n  ...\n  g\n  h\n+ i\n", lineDiff(want, got))
+}
+
+func TestTakeMissingSheet(t *testing.T) {
+	_, err := Take(newWorkbook(t), Options{Sheets: []string{"Missing"}})
+	assert.EqualError(t, err, "sheet Missing: sheet does not exist")
+}
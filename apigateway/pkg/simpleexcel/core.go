@@ -0,0 +1,176 @@
+package simpleexcel
+
+import (
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
+)
+
+// core translates the configuration into an exporter of the unified core, binding the section data.
+// Call done after the export: it copies the resolved columns (configured columns followed by the detected
+// data fields) back to the v1 sections, as v1 rendering did.
+func (e *DataExporter) core() (*excel.ExcelDataExporter, func()) {
+	c := excel.NewExcelDataExporter()
+	for key, password := range e.passwords {
+		c.SetPassword(key, password)
+	}
+	if e.workbookProtection != nil {
+		c.ProtectWorkbook(e.workbookProtection)
+	}
+	if e.encryption != nil {
+		c.Encrypt(e.encryption)
+	}
+
+	type translated struct {
+		v1   *SectionConfig
+		core *excel.SectionConfig
+	}
+	var sections []translated
+	for _, sb := range e.sheets {
+		csb := c.AddSheet(sb.name)
+		locked := false
+		for _, sec := range sb.sections {
+			// Perform Late Binding for any section that has an ID and matching data in e.data
+			if sec.ID != "" {
+				if data, ok := e.data[sec.ID]; ok {
+					sec.Data = data
+				}
+			}
+			cs := e.coreSection(sec)
+			csb.AddSection(cs)
+			sections = append(sections, translated{sec, cs})
+			locked = locked || sectionLocked(sec)
+		}
+		if p := sheetProtection(sb.protection, locked); p != nil {
+			csb.Protect(p)
+		}
+	}
+
+	return c, func() {
+		for _, s := range sections {
+			s.v1.Columns = v1Columns(s.v1.Columns, s.core.ResolvedColumns())
+		}
+	}
+}
+
+// coreSection translates a section. Titles and headers keep the v1 defaults: bold, without alignment.
+func (e *DataExporter) coreSection(sec *SectionConfig) *excel.SectionConfig {
+	cs := &excel.SectionConfig{
+		ID:         sec.ID,
+		ColSpan:    sec.ColSpan,
+		Data:       sec.Data,
+		Type:       sec.Type,
+		Locked:     sec.Locked,
+		ShowHeader: sec.ShowHeader,
+		Direction:  sec.Direction,
+		Position:   sec.Position,
+		DataStyle:  coreStyle(sec.DataStyle),
+		Columns:    make([]excel.ColumnConfig, len(sec.Columns)),
+	}
+	if sec.Title != "" {
+		cs.Title = sec.Title
+	}
+
+	bold := &StyleTemplate{Font: &FontTemplate{Bold: true}}
+	if sec.Type == SectionTypeTitleOnly {
+		// v1 title-only sections use the title style as it is, without the bold default font
+		cs.TitleStyle = unaligned(coreStyle(styleOr(sec.TitleStyle, &StyleTemplate{})))
+		if cs.TitleStyle.Font == nil {
+			cs.TitleStyle.Font = &FontTemplate{}
+		}
+	} else {
+		cs.TitleStyle = unaligned(coreStyle(styleOr(sec.TitleStyle, bold)))
+	}
+	cs.HeaderStyle = unaligned(coreStyle(styleOr(sec.HeaderStyle, bold)))
+
+	for i, col := range sec.Columns {
+		cs.Columns[i] = e.coreColumn(col)
+	}
+	return cs
+}
+
+// coreColumn translates a column. v1 formatter names only refer to registered formatters, so they are
+// resolved here instead of by the core, which also knows built-in formatters and formatter specs.
+func (e *DataExporter) coreColumn(col ColumnConfig) excel.ColumnConfig {
+	cc := excel.ColumnConfig{
+		FieldName:       col.FieldName,
+		Header:          col.Header,
+		Width:           col.Width,
+		Locked:          col.Locked,
+		Formatter:       col.Formatter,
+		HiddenFieldName: col.HiddenFieldName,
+	}
+	if cc.Formatter == nil && col.FormatterName != "" {
+		cc.Formatter = e.formatters[col.FormatterName]
+	}
+	return cc
+}
+
+// coreStyle translates a style.
+func coreStyle(s *StyleTemplate) *excel.StyleTemplate {
+	if s == nil {
+		return nil
+	}
+	return &excel.StyleTemplate{Font: s.Font, Fill: s.Fill, Locked: s.Locked}
+}
+
+// unaligned sets an empty alignment, which keeps the centered title and header defaults of the core from
+// applying.
+func unaligned(s *excel.StyleTemplate) *excel.StyleTemplate {
+	if s.Alignment == nil {
+		s.Alignment = &excel.AlignmentTemplate{}
+	}
+	return s
+}
+
+func styleOr(s, def *StyleTemplate) *StyleTemplate {
+	if s != nil {
+		return s
+	}
+	return def
+}
+
+// sectionLocked returns true if a section or one of its columns is locked.
+func sectionLocked(sec *SectionConfig) bool {
+	if sec.Locked {
+		return true
+	}
+	for _, col := range sec.Columns {
+		if col.Locked != nil && *col.Locked {
+			return true
+		}
+	}
+	return false
+}
+
+// sheetProtection returns the protection of a sheet with the v1 defaults, which do not allow the auto filter,
+// or nil for sheets that are not protected.
+func sheetProtection(p *ProtectionConfig, locked bool) *ProtectionConfig {
+	if p == nil && !locked {
+		return nil
+	}
+	cfg := ProtectionConfig{}
+	if p != nil {
+		cfg = *p
+	}
+	if cfg.AutoFilter == nil {
+		autoFilter := false
+		cfg.AutoFilter = &autoFilter
+	}
+	return &cfg
+}
+
+// v1Columns returns the resolved columns of a section, keeping the configured v1 columns.
+func v1Columns(configured []ColumnConfig, resolved []excel.ColumnConfig) []ColumnConfig {
+	byField := make(map[string]ColumnConfig, len(configured))
+	for _, col := range configured {
+		byField[col.FieldName] = col
+	}
+	cols := make([]ColumnConfig, 0, len(resolved))
+	for _, col := range resolved {
+		if v1, ok := byField[col.FieldName]; ok {
+			cols = append(cols, v1)
+			continue
+		}
+		cols = append(cols, ColumnConfig{FieldName: col.FieldName, Header: col.Header, Width: col.Width})
+	}
+	return cols
+}
@@ -0,0 +1,168 @@
+package simpleexcel
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestCoreKeepsV1Defaults(t *testing.T) {
+	type Product struct {
+		Name  string
+		Price float64
+	}
+
+	sec := &SectionConfig{
+		Title:      "Products",
+		ShowHeader: true,
+		Data:       []Product{{"Item 1", 10}},
+		Columns: []ColumnConfig{
+			{FieldName: "Name", Header: "Product Name", Width: 30},
+		},
+	}
+	exporter := NewDataExporter()
+	exporter.AddSheet("Products").AddSection(sec)
+
+	f, err := exporter.BuildExcel()
+	if err != nil {
+		t.Fatalf("Failed to build excel: %v", err)
+	}
+
+	// Titles and headers are bold and not centered
+	for _, cell := range []string{"A1", "A2"} {
+		styleID, err := f.GetCellStyle("Products", cell)
+		if err != nil {
+			t.Fatalf("Failed to get style for %s: %v", cell, err)
+		}
+		style, _ := f.GetStyle(styleID)
+		if style.Font == nil || !style.Font.Bold {
+			t.Errorf("Expected %s to be bold", cell)
+		}
+		if style.Alignment != nil && style.Alignment.Horizontal != "" {
+			t.Errorf("Expected %s to keep the default alignment, got %q", cell, style.Alignment.Horizontal)
+		}
+	}
+
+	// The resolved columns are written back, keeping the configured ones
+	if len(sec.Columns) != 2 {
+		t.Fatalf("Expected 2 resolved columns, got %d", len(sec.Columns))
+	}
+	if sec.Columns[0].Header != "Product Name" || sec.Columns[0].Width != 30 {
+		t.Errorf("Expected the configured Name column, got %+v", sec.Columns[0])
+	}
+	if sec.Columns[1].FieldName != "Price" {
+		t.Errorf("Expected the detected Price column, got %+v", sec.Columns[1])
+	}
+}
+
+func TestSheetProtectionDefaults(t *testing.T) {
+	if p := sheetProtection(nil, false); p != nil {
+		t.Errorf("Expected no protection for a sheet without locked cells, got %+v", p)
+	}
+
+	p := sheetProtection(nil, true)
+	if p == nil || p.AutoFilter == nil || *p.AutoFilter {
+		t.Errorf("Expected locked sheets to disallow the auto filter, got %+v", p)
+	}
+
+	allow := true
+	cfg := &ProtectionConfig{PasswordKey: "sheet", AutoFilter: &allow}
+	p = sheetProtection(cfg, false)
+	if p.PasswordKey != "sheet" || !*p.AutoFilter {
+		t.Errorf("Expected the configured protection, got %+v", p)
+	}
+	if p == cfg {
+		t.Errorf("Expected a copy of the configured protection")
+	}
+}
+
+func TestMigratedTemplateRendersLikeV1(t *testing.T) {
+	config := `
+sheets:
+  - name: "Products"
+    sections:
+      - type: "title"
+        title: "Product Report"
+        col_span: 2
+      - id: "products"
+        title: "Products"
+        show_header: true
+        columns:
+          - field_name: "Name"
+            header: "Name"
+            locked: true
+          - field_name: "Price"
+            header: "Price"
+`
+	type Product struct {
+		Name  string
+		Price float64
+	}
+	data := []Product{{"Item 1", 10}, {"Item 2", 20}}
+
+	v1, err := NewDataExporterFromYamlConfig(config)
+	if err != nil {
+		t.Fatalf("Failed to load v1 template: %v", err)
+	}
+	v1File, err := v1.BindSectionData("products", data).BuildExcel()
+	if err != nil {
+		t.Fatalf("Failed to build v1 excel: %v", err)
+	}
+
+	res, err := excel.MigrateTemplate([]byte(config), excel.SchemaV1)
+	if err != nil {
+		t.Fatalf("Failed to migrate template: %v", err)
+	}
+	migrated, err := excel.NewExcelDataExporterFromYamlConfig(string(res.YAML))
+	if err != nil {
+		t.Fatalf("Failed to load migrated template: %v", err)
+	}
+	migratedFile, err := migrated.BindSectionData("products", data).BuildExcel()
+	if err != nil {
+		t.Fatalf("Failed to build migrated excel: %v", err)
+	}
+
+	for _, cell := range []string{"A1", "A2", "A3", "B3", "A4", "B4"} {
+		want := cellStyle(t, v1File, "Products", cell)
+		got := cellStyle(t, migratedFile, "Products", cell)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("Expected %s to be styled as in v1: want %+v, got %+v", cell, want, got)
+		}
+	}
+}
+
+// renderedStyle is the part of a cell style that shows: empty alignments and the default font look the same as
+// none.
+type renderedStyle struct {
+	Bold       bool
+	Horizontal string
+	Vertical   string
+	Fill       []string
+	Locked     bool
+}
+
+func cellStyle(t *testing.T, f *excelize.File, sheet, cell string) renderedStyle {
+	t.Helper()
+	styleID, err := f.GetCellStyle(sheet, cell)
+	if err != nil {
+		t.Fatalf("Failed to get style for %s: %v", cell, err)
+	}
+	style, err := f.GetStyle(styleID)
+	if err != nil {
+		t.Fatalf("Failed to read style of %s: %v", cell, err)
+	}
+	var r renderedStyle
+	if style.Font != nil {
+		r.Bold = style.Font.Bold
+	}
+	if style.Alignment != nil {
+		r.Horizontal, r.Vertical = style.Alignment.Horizontal, style.Alignment.Vertical
+	}
+	r.Fill = style.Fill.Color
+	if style.Protection != nil {
+		r.Locked = style.Protection.Locked
+	}
+	return r
+}
@@ -0,0 +1,40 @@
+package simpleexcel
+
+import (
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
+)
+
+// ProtectionConfig controls sheet protection (see excel.ProtectionConfig).
+// Passwords are never stored in the template: PasswordKey names a password
+// supplied at runtime via SetPassword. Unset options keep the exporter defaults.
+type ProtectionConfig = excel.ProtectionConfig
+
+// WorkbookProtectionConfig protects the workbook structure (add/delete/rename/move sheets).
+type WorkbookProtectionConfig = excel.WorkbookProtectionConfig
+
+// EncryptionConfig encrypts the whole file with an open password.
+type EncryptionConfig = excel.EncryptionConfig
+
+// SetPassword supplies the runtime value of a password referenced by password_key.
+func (e *DataExporter) SetPassword(key, password string) *DataExporter {
+	e.passwords[key] = password
+	return e
+}
+
+// ProtectWorkbook enables workbook structure protection (Programmatic).
+func (e *DataExporter) ProtectWorkbook(cfg *WorkbookProtectionConfig) *DataExporter {
+	e.workbookProtection = cfg
+	return e
+}
+
+// Encrypt enables whole-file encryption with the open password named by cfg.PasswordKey (Programmatic).
+func (e *DataExporter) Encrypt(cfg *EncryptionConfig) *DataExporter {
+	e.encryption = cfg
+	return e
+}
+
+// Protect sets the protection options of the sheet. A configured sheet is always protected.
+func (sb *SheetBuilder) Protect(cfg *ProtectionConfig) *SheetBuilder {
+	sb.protection = cfg
+	return sb
+}
@@ -0,0 +1,95 @@
+package simpleexcel
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func sheetXML(t *testing.T, data []byte) string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	for _, zf := range zr.File {
+		if zf.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := zf.Open()
+			defer rc.Close()
+			b, _ := io.ReadAll(rc)
+			return string(b)
+		}
+	}
+	t.Fatalf("sheet1.xml not found")
+	return ""
+}
+
+func TestProtectionAutoFilterFromYAML(t *testing.T) {
+	yamlConfig := `
+workbook_protection:
+  password_key: "structure"
+encryption:
+  password_key: "open"
+sheets:
+  - name: "Salaries"
+    protection:
+      password_key: "sheet"
+      auto_filter: true
+      sort: true
+    sections:
+      - id: "salaries"
+        locked: true
+        columns:
+          - field_name: "Name"
+`
+	exporter, err := NewDataExporterFromYamlConfig(yamlConfig)
+	assert.NoError(t, err)
+	exporter.BindSectionData("salaries", []map[string]interface{}{{"Name": "Alice"}})
+	exporter.SetPassword("sheet", "sheet-pw").SetPassword("structure", "structure-pw")
+
+	// Encryption password missing
+	_, err = exporter.ToBytes()
+	assert.Error(t, err)
+
+	exporter.SetPassword("open", "open-pw")
+	data, err := exporter.ToBytes()
+	assert.NoError(t, err)
+
+	f, err := excelize.OpenReader(bytes.NewReader(data), excelize.Options{Password: "open-pw"})
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.NoError(t, f.UnprotectWorkbook("structure-pw"))
+	assert.Error(t, f.UnprotectSheet("Salaries", "wrong"))
+	assert.NoError(t, f.UnprotectSheet("Salaries", "sheet-pw"))
+}
+
+func TestProtectionDefaultsUnchanged(t *testing.T) {
+	exporter := NewDataExporter()
+	exporter.AddSheet("Locked").
+		AddSection(&SectionConfig{
+			Locked:  true,
+			Data:    []map[string]interface{}{{"A": 1}},
+			Columns: []ColumnConfig{{FieldName: "A"}},
+		})
+	data, err := exporter.ToBytes()
+	assert.NoError(t, err)
+	// AutoFilter stays disallowed unless configured
+	assert.Contains(t, sheetXML(t, data), `autoFilter="true"`)
+
+	allow := true
+	exporter = NewDataExporter()
+	exporter.AddSheet("Filterable").
+		Protect(&ProtectionConfig{AutoFilter: &allow}).
+		AddSection(&SectionConfig{
+			Locked:  true,
+			Data:    []map[string]interface{}{{"A": 1}},
+			Columns: []ColumnConfig{{FieldName: "A"}},
+		})
+	data, err = exporter.ToBytes()
+	assert.NoError(t, err)
+	assert.Contains(t, sheetXML(t, data), `autoFilter="false"`)
+}
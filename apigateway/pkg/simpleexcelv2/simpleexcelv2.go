@@ -0,0 +1,218 @@
+// Package simpleexcelv2 keeps the v2 API working on top of the unified exporter core in pkg/excel.
+// Every type is an alias of its excel counterpart, so values move freely between the two packages;
+// new code should import pkg/excel directly.
+package simpleexcelv2
+
+import (
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	SectionDirectionHorizontal = excel.SectionDirectionHorizontal
+	SectionDirectionVertical   = excel.SectionDirectionVertical
+	SectionTypeFull            = excel.SectionTypeFull
+	SectionTypeTitleOnly       = excel.SectionTypeTitleOnly
+	SectionTypeHidden          = excel.SectionTypeHidden
+	DefaultLockedColor         = excel.DefaultLockedColor
+	DefaultTableStyle          = excel.DefaultTableStyle
+
+	FormatXLSX = excel.FormatXLSX
+	FormatCSV  = excel.FormatCSV
+	FormatJSON = excel.FormatJSON
+
+	CSVQuoteMinimal = excel.CSVQuoteMinimal
+	CSVQuoteAll     = excel.CSVQuoteAll
+
+	RedactFull = excel.RedactFull
+	RedactMask = excel.RedactMask
+	RedactHash = excel.RedactHash
+	RedactYear = excel.RedactYear
+	RedactDrop = excel.RedactDrop
+
+	PropReportName    = excel.PropReportName
+	PropConfigVersion = excel.PropConfigVersion
+	PropConfigHash    = excel.PropConfigHash
+	PropGeneratedAt   = excel.PropGeneratedAt
+	PropRequestID     = excel.PropRequestID
+	PropRedaction     = excel.PropRedaction
+	PropSectionLayout = excel.PropSectionLayout
+
+	DiffChangedColor = excel.DiffChangedColor
+	DiffLockedColor  = excel.DiffLockedColor
+	DiffAddedColor   = excel.DiffAddedColor
+
+	SchemaV1 = excel.SchemaV1
+	SchemaV2 = excel.SchemaV2
+	SchemaV3 = excel.SchemaV3
+)
+
+var (
+	ErrMaxRowsExceeded  = excel.ErrMaxRowsExceeded
+	ErrStreamCanceled   = excel.ErrStreamCanceled
+	ErrNoReportMetadata = excel.ErrNoReportMetadata
+	ErrStaleTemplate    = excel.ErrStaleTemplate
+	ErrNoSectionLayout  = excel.ErrNoSectionLayout
+	ErrNoRedactionRoles = excel.ErrNoRedactionRoles
+	ErrReportNotFound   = excel.ErrReportNotFound
+)
+
+// Exporter and templates
+type (
+	ExcelDataExporter        = excel.ExcelDataExporter
+	SheetBuilder             = excel.SheetBuilder
+	Logger                   = excel.Logger
+	ReportTemplate           = excel.ReportTemplate
+	SheetTemplate            = excel.SheetTemplate
+	SectionConfig            = excel.SectionConfig
+	ColumnConfig             = excel.ColumnConfig
+	CompareConfig            = excel.CompareConfig
+	StyleTemplate            = excel.StyleTemplate
+	FontTemplate             = excel.FontTemplate
+	FillTemplate             = excel.FillTemplate
+	AlignmentTemplate        = excel.AlignmentTemplate
+	BandingConfig            = excel.BandingConfig
+	RowStyler                = excel.RowStyler
+	FormatterFactory         = excel.FormatterFactory
+	SectionPlacement         = excel.SectionPlacement
+	SheetSplit               = excel.SheetSplit
+	ExcelResult              = excel.ExcelResult
+	ProtectionConfig         = excel.ProtectionConfig
+	WorkbookProtectionConfig = excel.WorkbookProtectionConfig
+	EncryptionConfig         = excel.EncryptionConfig
+	DocumentProperties       = excel.DocumentProperties
+	ReportMetadata           = excel.ReportMetadata
+	RedactionConfig          = excel.RedactionConfig
+	RedactionPolicy          = excel.RedactionPolicy
+	LocaleBundle             = excel.LocaleBundle
+	Locales                  = excel.Locales
+)
+
+// Streaming and other formats
+type (
+	Streamer            = excel.Streamer
+	StreamSession       = excel.StreamSession
+	CellRenderer        = excel.CellRenderer
+	StreamOptions       = excel.StreamOptions
+	StreamProgress      = excel.StreamProgress
+	StreamCanceledError = excel.StreamCanceledError
+	SectionStreamer     = excel.SectionStreamer
+	CSVStreamer         = excel.CSVStreamer
+	CSVOptions          = excel.CSVOptions
+	CSVQuoteMode        = excel.CSVQuoteMode
+	CSVManifest         = excel.CSVManifest
+	CSVManifestFile     = excel.CSVManifestFile
+	CSVManifestColumn   = excel.CSVManifestColumn
+	ExportFormat        = excel.ExportFormat
+	HTMLOptions         = excel.HTMLOptions
+	JSONDocument        = excel.JSONDocument
+	JSONSheet           = excel.JSONSheet
+	JSONSection         = excel.JSONSection
+	JSONColumn          = excel.JSONColumn
+)
+
+// Registry, schedules, workbook diffs and template migration
+type (
+	ReportRegistry  = excel.ReportRegistry
+	ReportInfo      = excel.ReportInfo
+	SectionProvider = excel.SectionProvider
+	ReportSchedule  = excel.ReportSchedule
+	ScheduleData    = excel.ScheduleData
+	SectionLayout   = excel.SectionLayout
+	WorkbookDiff    = excel.WorkbookDiff
+	DiffSummary     = excel.DiffSummary
+	SectionDiff     = excel.SectionDiff
+	RowDiff         = excel.RowDiff
+	CellDiff        = excel.CellDiff
+	MigrationResult = excel.MigrationResult
+)
+
+// NewExcelDataExporter creates an empty exporter (see excel.NewExcelDataExporter).
+func NewExcelDataExporter() *ExcelDataExporter {
+	return excel.NewExcelDataExporter()
+}
+
+// NewExcelDataExporterFromYamlConfig creates an exporter from a YAML template (see excel.NewExcelDataExporterFromYamlConfig).
+func NewExcelDataExporterFromYamlConfig(yamlConfig string) (*ExcelDataExporter, error) {
+	return excel.NewExcelDataExporterFromYamlConfig(yamlConfig)
+}
+
+// ConvertToFlattenedData flattens nested structs into maps with dotted keys (see excel.ConvertToFlattenedData).
+func ConvertToFlattenedData(data interface{}) (interface{}, error) {
+	return excel.ConvertToFlattenedData(data)
+}
+
+// BuiltinFormatters returns the names of the built-in formatters.
+func BuiltinFormatters() []string {
+	return excel.BuiltinFormatters()
+}
+
+// ExprFunctions returns the names of the functions available in column expressions.
+func ExprFunctions() []string {
+	return excel.ExprFunctions()
+}
+
+// ParseExportFormat parses a format name such as a "?format=" query value. Empty means xlsx.
+func ParseExportFormat(s string) (ExportFormat, error) {
+	return excel.ParseExportFormat(s)
+}
+
+// ParseReportFormat parses the "?format=" value of a report export: xlsx (default), csv or json.
+func ParseReportFormat(s string) (ExportFormat, error) {
+	return excel.ParseReportFormat(s)
+}
+
+// NewLocales creates an empty set of locale bundles.
+func NewLocales(fallback string) *Locales {
+	return excel.NewLocales(fallback)
+}
+
+// LoadLocales loads all .yaml, .yml and .json bundles of a directory.
+func LoadLocales(dir, fallback string) (*Locales, error) {
+	return excel.LoadLocales(dir, fallback)
+}
+
+// LoadLocaleBundle loads a locale bundle from a .yaml, .yml or .json file.
+func LoadLocaleBundle(path string) (*LocaleBundle, error) {
+	return excel.LoadLocaleBundle(path)
+}
+
+// ParseLocaleBundle parses a locale bundle. format is "yaml" or "json".
+func ParseLocaleBundle(data []byte, format string) (*LocaleBundle, error) {
+	return excel.ParseLocaleBundle(data, format)
+}
+
+// NewReportRegistry creates a registry for the templates of dir. Call Reload to load them.
+func NewReportRegistry(dir string) *ReportRegistry {
+	return excel.NewReportRegistry(dir)
+}
+
+// ReadReportMetadata reads the report metadata embedded by the exporter.
+func ReadReportMetadata(f *excelize.File) (*ReportMetadata, error) {
+	return excel.ReadReportMetadata(f)
+}
+
+// HighlightDiff marks the changes of a diff in the returned workbook it was computed from.
+func HighlightDiff(f *excelize.File, diff *WorkbookDiff) error {
+	return excel.HighlightDiff(f, diff)
+}
+
+// RestrictedPolicy returns the redaction policy that drops every tagged column.
+func RestrictedPolicy() *RedactionPolicy {
+	return excel.RestrictedPolicy()
+}
+
+// DetectFields returns the field names detected in data, as used for columns without configuration.
+func DetectFields(data interface{}) []string {
+	return excel.DetectFields(data)
+}
+
+// DetectTemplateSchema returns the oldest schema that reads every key of a template.
+func DetectTemplateSchema(yamlConfig []byte) (string, error) {
+	return excel.DetectTemplateSchema(yamlConfig)
+}
+
+// MigrateTemplate upgrades a YAML template to the newest schema (see excel.MigrateTemplate).
+func MigrateTemplate(yamlConfig []byte, from string) (*MigrationResult, error) {
+	return excel.MigrateTemplate(yamlConfig, from)
+}
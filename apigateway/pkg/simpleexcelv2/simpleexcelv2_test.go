@@ -0,0 +1,92 @@
+package simpleexcelv2
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// exportedNames returns the exported package-level identifiers declared in the non-test files of dir.
+func exportedNames(t *testing.T, dir string) []string {
+	t.Helper()
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatalf("parse %s: %v", dir, err)
+	}
+	var names []string
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			for _, decl := range f.Decls {
+				switch d := decl.(type) {
+				case *ast.FuncDecl:
+					if d.Recv == nil && d.Name.IsExported() {
+						names = append(names, d.Name.Name)
+					}
+				case *ast.GenDecl:
+					for _, spec := range d.Specs {
+						switch s := spec.(type) {
+						case *ast.TypeSpec:
+							if s.Name.IsExported() {
+								names = append(names, s.Name.Name)
+							}
+						case *ast.ValueSpec:
+							for _, n := range s.Names {
+								if n.IsExported() {
+									names = append(names, n.Name)
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestAPIMatchesCore(t *testing.T) {
+	assert.Equal(t, exportedNames(t, "../excel"), exportedNames(t, "."),
+		"every exported identifier of pkg/excel must be forwarded by simpleexcelv2")
+}
+
+func TestExportThroughCore(t *testing.T) {
+	type Product struct {
+		Name  string
+		Price float64
+	}
+	config := `
+name: "Products"
+sheets:
+  - name: "Products"
+    sections:
+      - id: "products"
+        show_header: true
+        columns:
+          - field_name: "Name"
+            header: "Product"
+          - field_name: "Price"
+            header: "Price"
+`
+	exporter, err := NewExcelDataExporterFromYamlConfig(config)
+	assert.NoError(t, err)
+	f, err := exporter.BindSectionData("products", []Product{{"Widget", 9.5}}).BuildExcel()
+	assert.NoError(t, err)
+
+	rows, err := f.GetRows("Products")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"Product", "Price"}, {"Widget", "9.5"}}, rows)
+
+	meta, err := ReadReportMetadata(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "Products", meta.ReportName)
+}
@@ -0,0 +1,119 @@
+package simpleexcelv3
+
+import (
+	"fmt"
+
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
+)
+
+// core translates the configuration into an exporter of the unified core, binding the section data.
+// Call done after the export: it copies the resolved columns (configured columns followed by the detected
+// data fields) back to the v3 sections, as v3 rendering did.
+func (e *ExcelDataExporterV3) core() (*excel.ExcelDataExporter, func()) {
+	c := excel.NewExcelDataExporter()
+
+	type translated struct {
+		v3   *SectionConfigV3
+		core *excel.SectionConfig
+	}
+	var sections []translated
+	for _, sb := range e.sheets {
+		csb := c.AddSheet(sb.name)
+		for _, sec := range sb.sections {
+			// Perform Late Binding for any section that has an ID and matching data in e.data
+			if sec.ID != "" {
+				if data, ok := e.data[sec.ID]; ok {
+					sec.Data = data
+				}
+			}
+			cs := e.coreSection(sec)
+			csb.AddSection(cs)
+			sections = append(sections, translated{sec, cs})
+		}
+	}
+
+	return c, func() {
+		for _, s := range sections {
+			s.v3.Columns = v3Columns(s.v3.Columns, s.core.ResolvedColumns())
+		}
+	}
+}
+
+// coreSection translates a section. v3 sections read a subset of the core keys, with the same defaults.
+func (e *ExcelDataExporterV3) coreSection(sec *SectionConfigV3) *excel.SectionConfig {
+	cs := &excel.SectionConfig{
+		ID:             sec.ID,
+		Title:          sec.Title,
+		ColSpan:        sec.ColSpan,
+		Data:           sec.Data,
+		SourceSections: sec.SourceSections,
+		Type:           sec.Type,
+		Locked:         sec.Locked,
+		ShowHeader:     sec.ShowHeader,
+		Direction:      sec.Direction,
+		Position:       sec.Position,
+		TitleStyle:     coreStyle(sec.TitleStyle),
+		HeaderStyle:    coreStyle(sec.HeaderStyle),
+		DataStyle:      coreStyle(sec.DataStyle),
+		TitleHeight:    sec.TitleHeight,
+		HeaderHeight:   sec.HeaderHeight,
+		DataHeight:     sec.DataHeight,
+		HasFilter:      sec.HasFilter,
+		Columns:        make([]excel.ColumnConfig, len(sec.Columns)),
+	}
+	for i, col := range sec.Columns {
+		cs.Columns[i] = e.coreColumn(col)
+	}
+	return cs
+}
+
+// coreColumn translates a column. v3 formatter names only refer to registered formatters, so they are
+// resolved here instead of by the core, which also knows built-in formatters and formatter specs.
+// Comparison columns render "Diff" where the compared cells differ; the core renders the changed value, so
+// they become formula columns.
+func (e *ExcelDataExporterV3) coreColumn(col ColumnConfigV3) excel.ColumnConfig {
+	cc := excel.ColumnConfig{
+		FieldName:       col.FieldName,
+		Header:          col.Header,
+		Width:           col.Width,
+		Height:          col.Height,
+		Locked:          col.Locked,
+		Formatter:       col.Formatter,
+		HiddenFieldName: col.HiddenFieldName,
+		CompareWith:     col.CompareWith,
+	}
+	if cc.Formatter == nil && col.FormatterName != "" {
+		cc.Formatter = e.formatters[col.FormatterName]
+	}
+	if col.CompareWith != nil && col.CompareAgainst != nil {
+		cc.CompareWith = nil
+		cc.Formula = fmt.Sprintf(`IF({%s.%s}<>{%s.%s}, "Diff", "")`,
+			col.CompareWith.SectionID, col.CompareWith.FieldName, col.CompareAgainst.SectionID, col.CompareAgainst.FieldName)
+	}
+	return cc
+}
+
+// coreStyle translates a style.
+func coreStyle(s *StyleTemplateV3) *excel.StyleTemplate {
+	if s == nil {
+		return nil
+	}
+	return &excel.StyleTemplate{Font: s.Font, Fill: s.Fill, Alignment: s.Alignment, Locked: s.Locked}
+}
+
+// v3Columns returns the resolved columns of a section, keeping the configured v3 columns.
+func v3Columns(configured []ColumnConfigV3, resolved []excel.ColumnConfig) []ColumnConfigV3 {
+	byField := make(map[string]ColumnConfigV3, len(configured))
+	for _, col := range configured {
+		byField[col.FieldName] = col
+	}
+	cols := make([]ColumnConfigV3, 0, len(resolved))
+	for _, col := range resolved {
+		if v3, ok := byField[col.FieldName]; ok {
+			cols = append(cols, v3)
+			continue
+		}
+		cols = append(cols, ColumnConfigV3{FieldName: col.FieldName, Header: col.Header, Width: col.Width})
+	}
+	return cols
+}
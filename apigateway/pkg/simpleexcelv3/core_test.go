@@ -0,0 +1,68 @@
+package simpleexcelv3
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestCoreKeepsV3Columns(t *testing.T) {
+	type Product struct {
+		Name  string
+		Price float64
+	}
+
+	sec := &SectionConfigV3{
+		ID:         "products",
+		Title:      "Products",
+		ShowHeader: true,
+		Columns: []ColumnConfigV3{
+			{FieldName: "Name", Header: "Product Name", Width: 30, FormatterName: "upper"},
+		},
+	}
+	exporter := NewExcelDataExporterV3V3()
+	exporter.RegisterFormatter("upper", func(v interface{}) interface{} { return "X-" + v.(string) })
+	exporter.AddSheet("Products").AddSection(sec)
+	exporter.BindSectionData("products", []Product{{"Item 1", 10}})
+
+	f, err := exporter.BuildExcel()
+	assert.NoError(t, err)
+
+	// Titles are centered, as in v3
+	styleID, _ := f.GetCellStyle("Products", "A1")
+	style, _ := f.GetStyle(styleID)
+	if assert.NotNil(t, style.Alignment) {
+		assert.Equal(t, "center", style.Alignment.Horizontal)
+	}
+
+	name, _ := f.GetCellValue("Products", "A3")
+	assert.Equal(t, "X-Item 1", name)
+
+	// The resolved columns are written back, keeping the configured ones
+	if assert.Len(t, sec.Columns, 2) {
+		assert.Equal(t, "upper", sec.Columns[0].FormatterName)
+		assert.Equal(t, "Price", sec.Columns[1].FieldName)
+	}
+}
+
+func TestStreamerV3(t *testing.T) {
+	type Row struct {
+		Name string
+	}
+
+	exporter := NewExcelDataExporterV3V3()
+	exporter.AddSheet("Rows").AddSection(&SectionConfigV3{ID: "rows", ShowHeader: true, Columns: []ColumnConfigV3{{FieldName: "Name", Header: "Name"}}})
+
+	var buf bytes.Buffer
+	streamer, err := exporter.StartStreamV3(&buf)
+	assert.NoError(t, err)
+	assert.NoError(t, streamer.Write("rows", []Row{{"a"}, {"b"}}))
+	assert.NoError(t, streamer.Close())
+
+	f, err := excelize.OpenReader(&buf)
+	assert.NoError(t, err)
+	last, _ := f.GetCellValue("Rows", "A3")
+	assert.Equal(t, "b", last)
+}
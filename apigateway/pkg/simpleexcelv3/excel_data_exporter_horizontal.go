@@ -1,6 +1,7 @@
 package simpleexcelv3
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"reflect"
@@ -144,39 +145,5 @@ func (e *ExcelDataExporterV3) StartStreamV3WithMode(w io.Writer, mode StreamMode
 
 // startStreamV3Vertical contains the existing vertical streaming logic
 func (e *ExcelDataExporterV3) startStreamV3Vertical(w io.Writer) (*StreamerV3, error) {
-	// Copy existing StartStreamV3 implementation here
-	// This ensures backward compatibility
-	f := excelize.NewFile()
-	streamer := &StreamerV3{
-		exporter:      e,
-		file:          f,
-		writer:        w,
-		streamWriters: make(map[string]*excelize.StreamWriter),
-	}
-	
-	// Initialize sheets (existing logic)
-	for i, sb := range e.sheets {
-		sheetName := sb.name
-		if i == 0 {
-			f.SetSheetName("Sheet1", sheetName)
-		} else {
-			f.NewSheet(sheetName)
-		}
-		
-		sw, err := f.NewStreamWriter(sheetName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create stream writer for sheet %s: %w", sheetName, err)
-		}
-		streamer.streamWriters[sheetName] = sw
-	}
-	
-	streamer.currentSheetIndex = 0
-	streamer.currentSectionIndex = 0
-	streamer.currentRow = 1
-	
-	if err := streamer.advanceToNextStreamingSection(); err != nil {
-		return nil, err
-	}
-	
-	return streamer, nil
+	return e.startStreamV3(context.Background(), w, StreamOptions{})
 }
\ No newline at end of file
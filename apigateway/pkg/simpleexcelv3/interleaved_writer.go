@@ -3,9 +3,9 @@ package simpleexcelv3
 import (
 	"fmt"
 	"io"
-	"strings"
 	"sync"
 
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
 	"github.com/xuri/excelize/v2"
 )
 
@@ -16,8 +16,7 @@ type InterleavedStreamWriter struct {
 	streamWriter *excelize.StreamWriter
 	coordinator  *HorizontalSectionCoordinator
 	currentRow   int
-	styleCache   map[string]int
-	colStyleCache map[int]int
+	cells        *excel.CellRenderer // Renders the title and header cells with the core styles
 	pool         *sync.Pool
 }
 
@@ -34,8 +33,7 @@ func NewInterleavedStreamWriter(file *excelize.File, sheetName string, coordinat
 		streamWriter: sw,
 		coordinator:  coordinator,
 		currentRow:   1,
-		styleCache:   make(map[string]int),
-		colStyleCache: make(map[int]int),
+		cells:        excel.NewExcelDataExporter().NewCellRenderer(file),
 		pool: &sync.Pool{
 			New: func() interface{} {
 				return &RowData{
@@ -84,9 +82,9 @@ func (w *InterleavedStreamWriter) writeHeaders() error {
 	for _, section := range w.coordinator.sections {
 		if section.Title != nil {
 			// Create title cell spanning all columns for this section
-			titleCell := excelize.Cell{
-				Value: section.Title,
-				StyleID: w.getOrCreateTitleStyle(section),
+			titleCell, err := w.cells.Title(coreHorizontalSection(section))
+			if err != nil {
+				return err
 			}
 			
 			// Add title cell at the start of this section's columns
@@ -118,11 +116,11 @@ func (w *InterleavedStreamWriter) writeHeaders() error {
 	
 	for _, section := range w.coordinator.sections {
 		if section.ShowHeader {
-			for _, col := range section.Columns {
-				headerCell := excelize.Cell{
-					Value:   col.Header,
-					StyleID: w.getOrCreateHeaderStyle(col, section),
-				}
+			headers, err := w.cells.Headers(coreHorizontalSection(section))
+			if err != nil {
+				return err
+			}
+			for _, headerCell := range headers {
 				headerRow = append(headerRow, headerCell)
 			}
 		}
@@ -162,98 +160,11 @@ func (w *InterleavedStreamWriter) writeRow(rowData *RowData) error {
 	return nil
 }
 
-// Style caching methods
-func (w *InterleavedStreamWriter) getOrCreateTitleStyle(section *HorizontalSection) int {
-	// Generate a unique key for this style
-	var sb strings.Builder
-	fmt.Fprintf(&sb, "title:%s", section.ID)
-	key := sb.String()
-
-	// Check if we already have this style cached
-	if styleID, exists := w.styleCache[key]; exists {
-		return styleID
-	}
-
-	// Create new style for title
-	styleTmpl := &StyleTemplateV3{
-		Font: &FontTemplateV3{
-			Bold:  true,
-			Color: "000000",
-		},
-		Alignment: &AlignmentTemplate{
-			Horizontal: "center",
-			Vertical:   "top",
-		},
-	}
-	styleID, err := w.createStyle(styleTmpl)
-	if err != nil {
-		return 0
-	}
-
-	// Cache the style
-	w.styleCache[key] = styleID
-	return styleID
-}
-
-func (w *InterleavedStreamWriter) getOrCreateHeaderStyle(col ColumnConfigV3, section *HorizontalSection) int {
-	// Generate a unique key for this style
-	var sb strings.Builder
-	fmt.Fprintf(&sb, "header:%s:%s", section.ID, col.FieldName)
-	key := sb.String()
-
-	// Check if we already have this style cached
-	if styleID, exists := w.styleCache[key]; exists {
-		return styleID
-	}
-
-	// Create new style for header
-	styleTmpl := &StyleTemplateV3{
-		Font: &FontTemplateV3{
-			Bold:  true,
-			Color: "000000",
-		},
-		Alignment: &AlignmentTemplate{
-			Horizontal: "center",
-			Vertical:   "top",
-		},
-		Locked: &[]bool{col.IsLocked(false)}[0],
-	}
-	styleID, err := w.createStyle(styleTmpl)
-	if err != nil {
-		return 0
+// coreHorizontalSection translates the title and columns of a horizontal section for the cell renderer.
+func coreHorizontalSection(section *HorizontalSection) *excel.SectionConfig {
+	sec := &excel.SectionConfig{ID: section.ID, Title: section.Title, ShowHeader: section.ShowHeader}
+	for _, col := range section.Columns {
+		sec.Columns = append(sec.Columns, excel.ColumnConfig{FieldName: col.FieldName, Header: col.Header, Locked: col.Locked})
 	}
-
-	// Cache the style
-	w.styleCache[key] = styleID
-	return styleID
+	return sec
 }
-
-// createStyle creates a new style in the Excel file
-func (w *InterleavedStreamWriter) createStyle(tmpl *StyleTemplateV3) (int, error) {
-	style := &excelize.Style{}
-	if tmpl.Font != nil {
-		style.Font = &excelize.Font{
-			Bold:  tmpl.Font.Bold,
-			Color: strings.TrimPrefix(tmpl.Font.Color, "#"),
-		}
-	}
-	if tmpl.Fill != nil {
-		style.Fill = excelize.Fill{
-			Type:    "pattern",
-			Color:   []string{strings.TrimPrefix(tmpl.Fill.Color, "#")},
-			Pattern: 1,
-		}
-	}
-	if tmpl.Alignment != nil {
-		style.Alignment = &excelize.Alignment{
-			Horizontal: tmpl.Alignment.Horizontal,
-			Vertical:   tmpl.Alignment.Vertical,
-		}
-	}
-	if tmpl.Locked != nil {
-		style.Protection = &excelize.Protection{
-			Locked: *tmpl.Locked,
-		}
-	}
-	return w.file.NewStyle(style)
-}
\ No newline at end of file
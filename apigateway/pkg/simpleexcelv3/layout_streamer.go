@@ -0,0 +1,417 @@
+package simpleexcelv3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/locvowork/employee_management_sample/apigateway/pkg/excel"
+	"github.com/xuri/excelize/v2"
+)
+
+// layoutBlock is a band of rows in a streamed sheet: either one vertical section
+// or a group of consecutive horizontal sections placed side by side.
+type layoutBlock struct {
+	sections   []*SectionConfigV3
+	horizontal bool
+}
+
+// layoutBlocks groups the sections of a sheet into blocks, top to bottom.
+func layoutBlocks(sections []*SectionConfigV3) []layoutBlock {
+	var blocks []layoutBlock
+	for _, sec := range sections {
+		isHorizontal := sec.Direction == SectionDirectionV3Horizontal
+		if isHorizontal && len(blocks) > 0 && blocks[len(blocks)-1].horizontal {
+			last := &blocks[len(blocks)-1]
+			last.sections = append(last.sections, sec)
+			continue
+		}
+		blocks = append(blocks, layoutBlock{sections: []*SectionConfigV3{sec}, horizontal: isHorizontal})
+	}
+	return blocks
+}
+
+// BindSectionProvider binds a DataProvider to a section ID for StreamLayoutV3.
+// Rows are pulled with GetRow(0), GetRow(1), ... until it returns nil; the provider is closed afterwards.
+func (e *ExcelDataExporterV3) BindSectionProvider(id string, p DataProvider) *ExcelDataExporterV3 {
+	if e.providers == nil {
+		e.providers = make(map[string]DataProvider)
+	}
+	e.providers[id] = p
+	return e
+}
+
+// StreamLayoutV3 streams all sheets to w, pulling each section's rows from its DataProvider
+// (BindSectionProvider) or from its bound slice data (BindSectionData / Data).
+//
+// Unlike StartStreamV3WithMode, vertical sections and groups of horizontal sections can be mixed
+// on one sheet: consecutive "horizontal" sections are written side by side, their rows interleaved
+// until every provider is exhausted, and vertical sections are stacked above and below them.
+// Comparison columns (CompareWith/CompareAgainst) resolve to the same row of the referenced section.
+// Position is ignored, as rows are written top to bottom.
+func (e *ExcelDataExporterV3) StreamLayoutV3(ctx context.Context, w io.Writer, opts StreamOptions) error {
+	session, err := excel.NewExcelDataExporter().NewStreamSession(ctx, w, opts)
+	if err != nil {
+		return err
+	}
+	ls := &layoutStreamer{StreamSession: session, exporter: e, cells: session.Cells()}
+	defer ls.closeProviders()
+
+	f := session.File()
+	for i, sb := range e.sheets {
+		if i == 0 {
+			f.SetSheetName("Sheet1", sb.name)
+		} else {
+			f.NewSheet(sb.name)
+		}
+		if err := ls.writeSheet(sb); err != nil {
+			session.Abort()
+			return err
+		}
+	}
+
+	if err := session.Save(); err != nil {
+		return err
+	}
+	session.Progress(ls.sheet, "", true)
+	return nil
+}
+
+// layoutStreamer holds the state of a StreamLayoutV3 export.
+type layoutStreamer struct {
+	// StreamSession holds the file, the context, progress reporting and limits (see StreamOptions)
+	*excel.StreamSession
+	exporter  *ExcelDataExporterV3
+	cells     *excel.CellRenderer
+	sw        *excelize.StreamWriter
+	sheet     string
+	row       int
+	providers []DataProvider
+}
+
+// layoutSection is a section of a block together with its column and row source.
+type layoutSection struct {
+	sec      *SectionConfigV3
+	core     *excel.SectionConfig // The section translated for the core, with its resolved columns
+	col      int                  // First column of the section
+	width    int                  // Number of columns occupied by the section
+	provider DataProvider
+	rows     int // Data rows written
+	done     bool
+	// derived sections have no rows of their own (e.g. comparison columns): they get a row
+	// wherever the other sections of their block have one, or derivedRows rows when stacked vertically
+	derived     bool
+	derivedRows int
+}
+
+func (ls *layoutStreamer) writeSheet(sb *SheetBuilderV3) error {
+	sw, err := ls.File().NewStreamWriter(sb.name)
+	if err != nil {
+		return fmt.Errorf("failed to create stream writer for sheet %s: %w", sb.name, err)
+	}
+	ls.sw, ls.sheet, ls.row = sw, sb.name, 1
+
+	blocks := layoutBlocks(sb.sections)
+	laid := make([][]*layoutSection, len(blocks))
+	for i, block := range blocks {
+		col := 1
+		for _, sec := range block.sections {
+			lsec, err := ls.prepareSection(sec, col)
+			if err != nil {
+				return err
+			}
+			laid[i] = append(laid[i], lsec)
+			if block.horizontal {
+				col += lsec.width
+			}
+		}
+	}
+
+	// Column widths must be set before the first row is written
+	if err := ls.setColWidths(laid); err != nil {
+		return err
+	}
+
+	for i, block := range blocks {
+		if err := ls.writeBlock(laid[i], block.horizontal); err != nil {
+			return err
+		}
+	}
+	return sw.Flush()
+}
+
+// prepareSection resolves the columns and the row source of a section starting at column col. The
+// columns are resolved on a copy of the section, so the configured section is left as is for later exports.
+func (ls *layoutStreamer) prepareSection(cfg *SectionConfigV3, col int) (*layoutSection, error) {
+	e := ls.exporter
+	c := *cfg
+	c.Columns = append([]ColumnConfigV3(nil), cfg.Columns...)
+	sec := &c
+	lsec := &layoutSection{sec: sec, col: col}
+
+	if sec.Type != SectionTypeV3TitleOnly {
+		if p, ok := e.providers[sec.ID]; ok && sec.ID != "" {
+			lsec.provider = p
+			ls.providers = append(ls.providers, p)
+		} else {
+			if data, ok := e.data[sec.ID]; ok && sec.ID != "" {
+				sec.Data = data
+			}
+			if sec.Data != nil {
+				p, err := NewSliceDataProvider(sec.Data)
+				if err != nil {
+					return nil, fmt.Errorf("section %s: %w", sec.ID, err)
+				}
+				lsec.provider = p
+			}
+		}
+	}
+
+	lsec.core = e.coreSection(sec)
+	lsec.core.Data = nil
+	if lister, ok := lsec.provider.(ColumnLister); ok && len(sec.Columns) == 0 {
+		for _, field := range lister.Columns() {
+			lsec.core.Columns = append(lsec.core.Columns, excel.ColumnConfig{FieldName: field, Header: field, Width: 20})
+		}
+	}
+	if len(lsec.core.Columns) == 0 && lsec.provider != nil {
+		// Detect the columns from the first row
+		first, err := lsec.provider.GetRow(0)
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", sec.ID, err)
+		}
+		if first != nil {
+			sample := reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(first)), 1, 1)
+			sample.Index(0).Set(reflect.ValueOf(first))
+			lsec.core.Data = sample.Interface()
+			lsec.core.Columns = lsec.core.ResolvedColumns()
+			lsec.core.Data = nil
+		}
+	}
+	sec.Columns = v3Columns(sec.Columns, lsec.core.Columns)
+
+	lsec.width = len(sec.Columns)
+	if sec.Type == SectionTypeV3TitleOnly || lsec.width == 0 {
+		lsec.width = sec.ColSpan
+	}
+	if lsec.width < 1 {
+		lsec.width = 1
+	}
+
+	lsec.done = lsec.provider == nil
+	lsec.derived = lsec.done && sec.Type != SectionTypeV3TitleOnly && (len(sec.SourceSections) > 0 || hasCompareColumns(sec))
+	return lsec, nil
+}
+
+// hasCompareColumns returns true if any column of the section is a comparison column.
+func hasCompareColumns(sec *SectionConfigV3) bool {
+	for _, col := range sec.Columns {
+		if col.CompareWith != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// setColWidths applies the widest configured width of every column of the sheet.
+func (ls *layoutStreamer) setColWidths(laid [][]*layoutSection) error {
+	widths := make(map[int]float64)
+	maxCol := 0
+	for _, block := range laid {
+		for _, lsec := range block {
+			for j, col := range lsec.sec.Columns {
+				c := lsec.col + j
+				if col.Width > widths[c] {
+					widths[c] = col.Width
+				}
+				if c > maxCol {
+					maxCol = c
+				}
+			}
+		}
+	}
+	for c := 1; c <= maxCol; c++ {
+		if widths[c] > 0 {
+			if err := ls.sw.SetColWidth(c, c, widths[c]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeBlock writes the titles and headers of a block, registers the placements of its sections
+// and then writes the data rows, interleaving the rows of side-by-side sections.
+func (ls *layoutStreamer) writeBlock(block []*layoutSection, horizontal bool) error {
+	hasTitle, hasHeader := false, false
+	for _, lsec := range block {
+		hasTitle = hasTitle || lsec.sec.Title != nil
+		hasHeader = hasHeader || (lsec.sec.ShowHeader && lsec.sec.Type != SectionTypeV3TitleOnly && len(lsec.sec.Columns) > 0)
+	}
+
+	if hasTitle {
+		if err := ls.writeTitles(block); err != nil {
+			return err
+		}
+	}
+	if hasHeader {
+		if err := ls.writeHeaders(block); err != nil {
+			return err
+		}
+	}
+
+	for _, lsec := range block {
+		ls.cells.Place(ls.sheet, lsec.core, ls.row, lsec.col)
+		if lsec.derived && len(lsec.sec.SourceSections) > 0 {
+			source, _ := ls.cells.Placement(lsec.sec.SourceSections[0])
+			lsec.derivedRows = source.DataLen
+		}
+	}
+
+	for i := 0; ; i++ {
+		if ls.row > excelize.TotalRows {
+			return fmt.Errorf("sheet %s exceeds %d rows", ls.sheet, excelize.TotalRows)
+		}
+
+		var rowVals []interface{}
+		wrote, hidden := false, false
+		for _, lsec := range block {
+			if lsec.done {
+				continue
+			}
+			item, err := lsec.provider.GetRow(i)
+			if err != nil {
+				return fmt.Errorf("section %s row %d: %w", lsec.sec.ID, i, err)
+			}
+			if item == nil {
+				lsec.done = true
+				continue
+			}
+			if err := ls.AddRow(lsec.sec.ID); err != nil {
+				return err
+			}
+			if rowVals, err = ls.placeCells(rowVals, lsec, item, i); err != nil {
+				return err
+			}
+			lsec.rows++
+			wrote = true
+			hidden = hidden || lsec.sec.Type == SectionTypeV3Hidden
+		}
+		fromProviders := wrote
+		for _, lsec := range block {
+			if !lsec.derived || !(fromProviders || i < lsec.derivedRows) {
+				continue
+			}
+			if err := ls.AddRow(lsec.sec.ID); err != nil {
+				return err
+			}
+			var err error
+			if rowVals, err = ls.placeCells(rowVals, lsec, nil, i); err != nil {
+				return err
+			}
+			lsec.rows++
+			wrote = true
+		}
+		if !wrote {
+			break
+		}
+
+		cell, _ := excelize.CoordinatesToCellName(1, ls.row)
+		var rowOpts []excelize.RowOpts
+		if hidden && !horizontal {
+			rowOpts = append(rowOpts, excelize.RowOpts{Hidden: true})
+		}
+		if err := ls.sw.SetRow(cell, rowVals, rowOpts...); err != nil {
+			return err
+		}
+		ls.row++
+	}
+
+	for _, lsec := range block {
+		ls.cells.SetDataLen(lsec.core, lsec.rows)
+		ls.Progress(ls.sheet, lsec.sec.ID, false)
+	}
+	return nil
+}
+
+// writeTitles writes the title row of a block, merging each title across its section's columns.
+func (ls *layoutStreamer) writeTitles(block []*layoutSection) error {
+	var rowVals []interface{}
+	for _, lsec := range block {
+		if lsec.sec.Title == nil {
+			continue
+		}
+		title, err := ls.cells.Title(lsec.core)
+		if err != nil {
+			return err
+		}
+		rowVals = setCell(rowVals, lsec.col, title)
+		if lsec.width > 1 {
+			startCell, _ := excelize.CoordinatesToCellName(lsec.col, ls.row)
+			endCell, _ := excelize.CoordinatesToCellName(lsec.col+lsec.width-1, ls.row)
+			if err := ls.sw.MergeCell(startCell, endCell); err != nil {
+				return err
+			}
+		}
+	}
+	cell, _ := excelize.CoordinatesToCellName(1, ls.row)
+	if err := ls.sw.SetRow(cell, rowVals); err != nil {
+		return err
+	}
+	ls.row++
+	return nil
+}
+
+// writeHeaders writes the header row of a block.
+func (ls *layoutStreamer) writeHeaders(block []*layoutSection) error {
+	var rowVals []interface{}
+	for _, lsec := range block {
+		sec := lsec.sec
+		if !sec.ShowHeader || sec.Type == SectionTypeV3TitleOnly {
+			continue
+		}
+		headers, err := ls.cells.Headers(lsec.core)
+		if err != nil {
+			return err
+		}
+		for j, header := range headers {
+			rowVals = setCell(rowVals, lsec.col+j, header)
+		}
+	}
+	cell, _ := excelize.CoordinatesToCellName(1, ls.row)
+	if err := ls.sw.SetRow(cell, rowVals); err != nil {
+		return err
+	}
+	ls.row++
+	return nil
+}
+
+// placeCells adds the cells of one data row of a section to rowVals. A nil item renders only the
+// comparison columns.
+func (ls *layoutStreamer) placeCells(rowVals []interface{}, lsec *layoutSection, item interface{}, rowOffset int) ([]interface{}, error) {
+	cells, err := ls.cells.Row(lsec.core, item, rowOffset)
+	if err != nil {
+		return nil, err
+	}
+	for j, c := range cells {
+		rowVals = setCell(rowVals, lsec.col+j, c)
+	}
+	return rowVals, nil
+}
+
+// setCell sets the value of 1-based column col in a row, padding the row with empty cells.
+func setCell(rowVals []interface{}, col int, v interface{}) []interface{} {
+	for len(rowVals) < col {
+		rowVals = append(rowVals, nil)
+	}
+	rowVals[col-1] = v
+	return rowVals
+}
+
+// closeProviders closes the providers bound with BindSectionProvider.
+func (ls *layoutStreamer) closeProviders() {
+	for _, p := range ls.providers {
+		p.Close()
+	}
+}
@@ -0,0 +1,230 @@
+package simpleexcelv3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+type layoutRow struct {
+	Name  string
+	Value int
+}
+
+func layoutExporter() *ExcelDataExporterV3 {
+	columns := []ColumnConfigV3{
+		{FieldName: "Name", Header: "Name", Width: 20},
+		{FieldName: "Value", Header: "Value"},
+	}
+	exporter := NewExcelDataExporterV3V3()
+	exporter.AddSheet("Review").
+		AddSection(&SectionConfigV3{
+			ID:      "intro",
+			Title:   "Price Review",
+			Type:    SectionTypeV3TitleOnly,
+			ColSpan: 5,
+		}).
+		AddSection(&SectionConfigV3{
+			ID:         "editable",
+			Title:      "Editable",
+			Direction:  SectionDirectionV3Horizontal,
+			ShowHeader: true,
+			Columns:    columns,
+		}).
+		AddSection(&SectionConfigV3{
+			ID:         "original",
+			Title:      "Original",
+			Direction:  SectionDirectionV3Horizontal,
+			ShowHeader: true,
+			Locked:     true,
+			Columns:    columns,
+		}).
+		AddSection(&SectionConfigV3{
+			ID:         "diff",
+			Title:      "Diff",
+			Direction:  SectionDirectionV3Horizontal,
+			ShowHeader: true,
+			Columns: []ColumnConfigV3{{
+				FieldName:      "Diff",
+				Header:         "Diff",
+				CompareWith:    &CompareConfig{SectionID: "editable", FieldName: "Value"},
+				CompareAgainst: &CompareConfig{SectionID: "original", FieldName: "Value"},
+			}},
+		}).
+		AddSection(&SectionConfigV3{
+			ID:         "notes",
+			Title:      "Notes",
+			ShowHeader: true,
+			Columns:    columns,
+		})
+	return exporter
+}
+
+func TestStreamLayoutMixedSections(t *testing.T) {
+	editable := make(chan interface{}, 3)
+	editable <- layoutRow{"A", 10}
+	editable <- layoutRow{"B", 25}
+	editable <- layoutRow{"C", 30}
+	close(editable)
+
+	exporter := layoutExporter()
+	exporter.BindSectionProvider("editable", NewChannelDataProvider(editable))
+	exporter.BindSectionData("original", []layoutRow{{"A", 10}, {"B", 20}})
+	exporter.BindSectionData("notes", []layoutRow{{"checked", 1}})
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, exporter.StreamLayoutV3(context.Background(), buf, StreamOptions{}))
+
+	f, err := excelize.OpenReader(buf)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	// Row 1: intro, row 2: titles, row 3: headers, rows 4-6: interleaved data, row 7: notes title
+	get := func(cell string) string {
+		v, _ := f.GetCellValue("Review", cell)
+		return v
+	}
+	assert.Equal(t, "Price Review", get("A1"))
+	assert.Equal(t, "Editable", get("A2"))
+	assert.Equal(t, "Original", get("C2"))
+	assert.Equal(t, "Diff", get("E2"))
+	assert.Equal(t, "Value", get("D3"))
+
+	assert.Equal(t, "A", get("A4"))
+	assert.Equal(t, "20", get("D5"))
+	assert.Equal(t, "C", get("A6"))
+	assert.Equal(t, "", get("C6")) // Original is exhausted
+
+	formula, _ := f.GetCellFormula("Review", "E4")
+	assert.Equal(t, `IF(B4<>D4, "Diff", "")`, formula)
+	formula, _ = f.GetCellFormula("Review", "E6")
+	assert.Equal(t, `IF(B6<>D6, "Diff", "")`, formula)
+
+	assert.Equal(t, "Notes", get("A7"))
+	assert.Equal(t, "Name", get("A8"))
+	assert.Equal(t, "checked", get("A9"))
+
+	merged, _ := f.GetMergeCells("Review")
+	var ranges []string
+	for _, m := range merged {
+		ranges = append(ranges, m.GetStartAxis()+":"+m.GetEndAxis())
+	}
+	assert.Contains(t, ranges, "A1:E1")
+	assert.Contains(t, ranges, "C2:D2")
+}
+
+func TestStreamLayoutOptions(t *testing.T) {
+	exporter := layoutExporter()
+	exporter.BindSectionData("editable", []layoutRow{{"A", 1}, {"B", 2}})
+	exporter.BindSectionData("original", []layoutRow{{"A", 1}, {"B", 2}})
+
+	var last StreamProgress
+	err := exporter.StreamLayoutV3(context.Background(), new(bytes.Buffer), StreamOptions{
+		OnProgress: func(p StreamProgress) { last = p },
+	})
+	assert.NoError(t, err)
+	assert.True(t, last.Done)
+	assert.Equal(t, 6, last.TotalRows) // Includes the rows of the comparison section
+
+	err = layoutExporter().
+		BindSectionData("editable", []layoutRow{{"A", 1}, {"B", 2}}).
+		StreamLayoutV3(context.Background(), new(bytes.Buffer), StreamOptions{MaxRows: 1})
+	assert.True(t, errors.Is(err, ErrMaxRowsExceeded))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = layoutExporter().StreamLayoutV3(ctx, new(bytes.Buffer), StreamOptions{})
+	assert.True(t, errors.Is(err, ErrStreamCanceled))
+}
+
+// rowsOnly hides the Columns method of a provider, so its columns are detected from the first row.
+type rowsOnly struct{ DataProvider }
+
+func TestStreamLayoutDetectsColumnsFromFirstRow(t *testing.T) {
+	var names, ids []string
+	for i := 0; i < 25; i++ {
+		names = append(names, "Row "+strconv.Itoa(i))
+		ids = append(ids, strconv.Itoa(i))
+	}
+	rows := func() []layoutRow {
+		data := make([]layoutRow, len(names))
+		for i, name := range names {
+			data[i] = layoutRow{Name: name, Value: i}
+		}
+		return data
+	}
+	cases := []struct {
+		name   string
+		bind   func(t *testing.T, e *ExcelDataExporterV3)
+		header string
+		col    int
+		want   []string // Values of column col, neither the first row repeated nor dropped
+	}{
+		{
+			name:   "data",
+			bind:   func(t *testing.T, e *ExcelDataExporterV3) { e.BindSectionData("detected", rows()) },
+			header: "Name",
+			want:   names,
+		},
+		{
+			name: "channel",
+			bind: func(t *testing.T, e *ExcelDataExporterV3) {
+				ch := make(chan interface{}, len(names))
+				for _, row := range rows() {
+					ch <- row
+				}
+				close(ch)
+				e.BindSectionProvider("detected", NewChannelDataProvider(ch))
+			},
+			header: "Name",
+			want:   names,
+		},
+		{
+			name: "sql",
+			bind: func(t *testing.T, e *ExcelDataExporterV3) {
+				p, err := NewSQLStructDataProvider(querySalaries(t), salaryRow{})
+				assert.NoError(t, err)
+				e.BindSectionProvider("detected", rowsOnly{p})
+			},
+			header: "Amount",
+			col:    1,
+			want:   []string{"60117", "62102", "66074"},
+		},
+		{
+			name: "prefetch",
+			bind: func(t *testing.T, e *ExcelDataExporterV3) {
+				e.BindSectionProvider("detected", NewPagedDataProvider(context.Background(), pagesOf(25, 10, 0), PrefetchOptions{Workers: 2}))
+			},
+			header: "ID",
+			want:   ids,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			section := &SectionConfigV3{ID: "detected", ShowHeader: true}
+			exporter := NewExcelDataExporterV3V3()
+			exporter.AddSheet("Detected").AddSection(section)
+			tc.bind(t, exporter)
+
+			buf := new(bytes.Buffer)
+			assert.NoError(t, exporter.StreamLayoutV3(context.Background(), buf, StreamOptions{}))
+			// The columns are detected on the copy of the section made for the export
+			assert.Empty(t, section.Columns)
+			assert.Nil(t, section.Data)
+
+			f, err := excelize.OpenReader(buf)
+			assert.NoError(t, err)
+			defer f.Close()
+			cols, err := f.GetCols("Detected")
+			assert.NoError(t, err)
+			if assert.Greater(t, len(cols), tc.col) {
+				assert.Equal(t, append([]string{tc.header}, tc.want...), cols[tc.col])
+			}
+		})
+	}
+}
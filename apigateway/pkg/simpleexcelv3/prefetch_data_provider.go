@@ -0,0 +1,296 @@
+package simpleexcelv3
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PageFunc fetches one page of rows of a batch-oriented source (e.g. an Elasticsearch scroll or a
+// Datastore cursor). Pages are requested in order starting at 0; an empty page ends the source.
+// Cursor-based sources can ignore page and keep their cursor in the closure (with Workers = 1).
+type PageFunc func(ctx context.Context, page int) ([]interface{}, error)
+
+// PrefetchOptions configures a PrefetchDataProvider.
+type PrefetchOptions struct {
+	BatchSize int // Rows read per batch from a DataProvider source (default 500)
+	Buffer    int // Batches or pages buffered ahead of the reader (default 4)
+	Workers   int // Pages fetched concurrently from a PageFunc source (default 1)
+}
+
+// PrefetchStats reports the fetch and stall statistics of a PrefetchDataProvider.
+type PrefetchStats struct {
+	Batches     int64         // Batches or pages fetched from the source
+	RowsFetched int64         // Rows fetched from the source
+	RowsRead    int64         // Rows returned by GetRow
+	FetchTime   time.Duration // Time spent in the source (summed over workers)
+	Stalls      int64         // Times the reader had to wait for the next batch
+	StallTime   time.Duration // Time the reader spent waiting
+}
+
+// rowBatch is a batch of rows, or the error that ended the source.
+type rowBatch struct {
+	rows []interface{}
+	err  error
+}
+
+// PrefetchDataProvider is a DataProvider decorator that fetches rows ahead on background goroutines
+// into a bounded buffer, so that a slow source does not stall the stream writer.
+// Rows must be read in order. Source errors are returned by the GetRow call that reaches them.
+type PrefetchDataProvider struct {
+	// Statistics, updated atomically (first for 64-bit alignment)
+	nBatches    int64
+	rowsFetched int64
+	rowsRead    int64
+	fetchNanos  int64
+	stalls      int64
+	stallNanos  int64
+
+	parent   context.Context
+	batches  chan rowBatch
+	cancel   context.CancelFunc
+	finished chan struct{}
+	closeFn  func() error
+
+	mu    sync.Mutex
+	batch []interface{}
+	base  int // Index of the first row of batch
+	done  bool
+	err   error
+}
+
+func (o PrefetchOptions) withDefaults() PrefetchOptions {
+	if o.BatchSize <= 0 {
+		o.BatchSize = 500
+	}
+	if o.Buffer <= 0 {
+		o.Buffer = 4
+	}
+	if o.Workers <= 0 {
+		o.Workers = 1
+	}
+	return o
+}
+
+func newPrefetchDataProvider(parent context.Context, opts PrefetchOptions) (*PrefetchDataProvider, context.Context) {
+	ctx, cancel := context.WithCancel(parent)
+	return &PrefetchDataProvider{
+		parent:   parent,
+		batches:  make(chan rowBatch, opts.Buffer),
+		cancel:   cancel,
+		finished: make(chan struct{}),
+	}, ctx
+}
+
+// NewPrefetchDataProvider wraps a DataProvider, reading its rows in batches on a background goroutine.
+// The source is read until GetRow returns nil; it is closed by Close once its pending GetRow returns.
+func NewPrefetchDataProvider(ctx context.Context, source DataProvider, opts PrefetchOptions) *PrefetchDataProvider {
+	opts = opts.withDefaults()
+	p, ctx := newPrefetchDataProvider(ctx, opts)
+	p.closeFn = source.Close
+
+	go func() {
+		defer close(p.finished)
+		defer close(p.batches)
+		for i := 0; ; {
+			start := time.Now()
+			rows := make([]interface{}, 0, opts.BatchSize)
+			var err error
+			for len(rows) < opts.BatchSize {
+				var row interface{}
+				if row, err = source.GetRow(i); err != nil || row == nil {
+					break
+				}
+				rows = append(rows, row)
+				i++
+			}
+			p.recordFetch(len(rows), time.Since(start))
+
+			if len(rows) > 0 && !p.send(ctx, rowBatch{rows: rows}) {
+				return
+			}
+			if err != nil {
+				p.send(ctx, rowBatch{err: err})
+				return
+			}
+			if len(rows) < opts.BatchSize {
+				return
+			}
+		}
+	}()
+	return p
+}
+
+// NewPagedDataProvider creates a DataProvider from a PageFunc. Up to opts.Workers pages are fetched
+// concurrently and returned in page order; with more than one worker, up to Workers-1 pages past the
+// end may be requested.
+func NewPagedDataProvider(ctx context.Context, fetch PageFunc, opts PrefetchOptions) *PrefetchDataProvider {
+	opts = opts.withDefaults()
+	p, ctx := newPrefetchDataProvider(ctx, opts)
+
+	// pending holds the result channels of the pages in flight, in page order
+	pending := make(chan chan rowBatch, opts.Workers)
+	workers := make(chan struct{}, opts.Workers)
+
+	go func() {
+		defer close(pending)
+		for page := 0; ; page++ {
+			select {
+			case workers <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			result := make(chan rowBatch, 1)
+			select {
+			case pending <- result:
+			case <-ctx.Done():
+				<-workers
+				return
+			}
+			go func(page int) {
+				defer func() { <-workers }()
+				start := time.Now()
+				rows, err := fetch(ctx, page)
+				p.recordFetch(len(rows), time.Since(start))
+				result <- rowBatch{rows: rows, err: err}
+			}(page)
+		}
+	}()
+
+	go func() {
+		defer close(p.finished)
+		defer close(p.batches)
+		defer func() {
+			p.cancel() // Stop the dispatcher and let it drain
+			for range pending {
+			}
+		}()
+		page := 0
+		for result := range pending {
+			var b rowBatch
+			select {
+			case b = <-result:
+			case <-ctx.Done():
+				return
+			}
+			if b.err != nil {
+				p.send(ctx, rowBatch{err: fmt.Errorf("page %d: %w", page, b.err)})
+				return
+			}
+			if len(b.rows) == 0 || !p.send(ctx, b) {
+				return
+			}
+			page++
+		}
+	}()
+	return p
+}
+
+// send buffers a batch, returning false if the provider was closed.
+func (p *PrefetchDataProvider) send(ctx context.Context, b rowBatch) bool {
+	select {
+	case p.batches <- b:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (p *PrefetchDataProvider) recordFetch(rows int, d time.Duration) {
+	atomic.AddInt64(&p.nBatches, 1)
+	atomic.AddInt64(&p.rowsFetched, int64(rows))
+	atomic.AddInt64(&p.fetchNanos, int64(d))
+}
+
+// next returns the next batch, recording a stall if none is buffered.
+func (p *PrefetchDataProvider) next() (rowBatch, bool) {
+	select {
+	case b, ok := <-p.batches:
+		return b, ok
+	default:
+	}
+	start := time.Now()
+	b, ok := <-p.batches
+	atomic.AddInt64(&p.stalls, 1)
+	atomic.AddInt64(&p.stallNanos, int64(time.Since(start)))
+	return b, ok
+}
+
+func (p *PrefetchDataProvider) GetRow(rowIndex int) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if rowIndex < p.base {
+		return nil, fmt.Errorf("cannot access row %d, already passed", rowIndex)
+	}
+	for rowIndex >= p.base+len(p.batch) {
+		if p.done {
+			return nil, p.err
+		}
+		p.base += len(p.batch)
+		p.batch = nil
+		b, ok := p.next()
+		if !ok && p.parent.Err() != nil {
+			b.err = p.parent.Err() // Fetching stopped because the context is done
+		}
+		if !ok || b.err != nil {
+			p.done = true
+			p.err = b.err
+			return nil, p.err
+		}
+		p.batch = b.rows
+	}
+	atomic.AddInt64(&p.rowsRead, 1)
+	return p.batch[rowIndex-p.base], nil
+}
+
+func (p *PrefetchDataProvider) GetRowCount() (int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done {
+		return p.base + len(p.batch), true
+	}
+	return 0, false // Unknown until the source is exhausted
+}
+
+func (p *PrefetchDataProvider) HasMoreRows() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.done
+}
+
+// Stats returns a snapshot of the fetch and stall statistics.
+func (p *PrefetchDataProvider) Stats() PrefetchStats {
+	return PrefetchStats{
+		Batches:     atomic.LoadInt64(&p.nBatches),
+		RowsFetched: atomic.LoadInt64(&p.rowsFetched),
+		RowsRead:    atomic.LoadInt64(&p.rowsRead),
+		FetchTime:   time.Duration(atomic.LoadInt64(&p.fetchNanos)),
+		Stalls:      atomic.LoadInt64(&p.stalls),
+		StallTime:   time.Duration(atomic.LoadInt64(&p.stallNanos)),
+	}
+}
+
+// Close stops prefetching. A wrapped DataProvider is closed once its pending GetRow returns.
+func (p *PrefetchDataProvider) Close() error {
+	p.mu.Lock()
+	p.done = true
+	p.mu.Unlock()
+	p.cancel()
+
+	if p.closeFn == nil {
+		return nil
+	}
+	select {
+	case <-p.finished:
+		return p.closeFn()
+	default:
+		go func() {
+			<-p.finished
+			p.closeFn()
+		}()
+		return nil
+	}
+}
@@ -0,0 +1,140 @@
+package simpleexcelv3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+type streamItem struct {
+	ID    int
+	Value string
+}
+
+// pagesOf returns a PageFunc serving n rows in pages of size rows, with later pages answering faster.
+func pagesOf(n, size int, delay time.Duration) PageFunc {
+	return func(ctx context.Context, page int) ([]interface{}, error) {
+		time.Sleep(delay / time.Duration(page+1))
+		var rows []interface{}
+		for i := page * size; i < (page+1)*size && i < n; i++ {
+			rows = append(rows, streamItem{ID: i})
+		}
+		return rows, nil
+	}
+}
+
+func readAll(t *testing.T, p DataProvider) ([]interface{}, error) {
+	var rows []interface{}
+	for i := 0; ; i++ {
+		row, err := p.GetRow(i)
+		if err != nil || row == nil {
+			return rows, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestPagedDataProviderOrder(t *testing.T) {
+	p := NewPagedDataProvider(context.Background(), pagesOf(95, 10, 20*time.Millisecond), PrefetchOptions{Workers: 4})
+	defer p.Close()
+
+	rows, err := readAll(t, p)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 95)
+	for i, row := range rows {
+		assert.Equal(t, i, row.(streamItem).ID)
+	}
+
+	count, known := p.GetRowCount()
+	assert.True(t, known)
+	assert.Equal(t, 95, count)
+	assert.False(t, p.HasMoreRows())
+
+	stats := p.Stats()
+	assert.GreaterOrEqual(t, stats.Batches, int64(11)) // 10 pages and the empty page
+	assert.Equal(t, int64(95), stats.RowsFetched)
+	assert.Equal(t, int64(95), stats.RowsRead)
+	assert.Greater(t, stats.FetchTime, time.Duration(0))
+	assert.Greater(t, stats.Stalls, int64(0))
+}
+
+func TestPagedDataProviderError(t *testing.T) {
+	sourceErr := errors.New("scroll expired")
+	fetch := func(ctx context.Context, page int) ([]interface{}, error) {
+		if page == 2 {
+			return nil, sourceErr
+		}
+		return []interface{}{streamItem{ID: page}}, nil
+	}
+	p := NewPagedDataProvider(context.Background(), fetch, PrefetchOptions{})
+	defer p.Close()
+
+	rows, err := readAll(t, p)
+	assert.Len(t, rows, 2)
+	assert.True(t, errors.Is(err, sourceErr))
+	assert.Contains(t, err.Error(), "page 2")
+
+	_, err = p.GetRow(5)
+	assert.True(t, errors.Is(err, sourceErr))
+}
+
+func TestPrefetchDataProviderWrapsSource(t *testing.T) {
+	data := make([]streamItem, 7)
+	for i := range data {
+		data[i].ID = i
+	}
+	source, err := NewSliceDataProvider(data)
+	assert.NoError(t, err)
+
+	p := NewPrefetchDataProvider(context.Background(), source, PrefetchOptions{BatchSize: 2, Buffer: 1})
+	rows, err := readAll(t, p)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 7)
+	assert.Equal(t, int64(4), p.Stats().Batches)
+
+	_, err = p.GetRow(0)
+	assert.Error(t, err) // Already passed
+	assert.NoError(t, p.Close())
+}
+
+func TestPrefetchDataProviderCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := NewPagedDataProvider(ctx, pagesOf(1<<30, 100, 0), PrefetchOptions{Workers: 2})
+	defer p.Close()
+
+	_, err := p.GetRow(0)
+	assert.NoError(t, err)
+	cancel()
+
+	_, err = readAll(t, p)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestPrefetchDataProviderStreamLayout(t *testing.T) {
+	exporter := NewExcelDataExporterV3V3()
+	exporter.AddSheet("StreamOps").AddSection(&SectionConfigV3{
+		ID:         "stream-data",
+		ShowHeader: true,
+		Columns: []ColumnConfigV3{
+			{FieldName: "ID", Header: "ID"},
+			{FieldName: "Value", Header: "Value"},
+		},
+	})
+	exporter.BindSectionProvider("stream-data", NewPagedDataProvider(context.Background(), pagesOf(250, 100, 0), PrefetchOptions{Workers: 2}))
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, exporter.StreamLayoutV3(context.Background(), buf, StreamOptions{}))
+
+	f, err := excelize.OpenReader(buf)
+	assert.NoError(t, err)
+	defer f.Close()
+	rows, err := f.GetRows("StreamOps")
+	assert.NoError(t, err)
+	assert.Len(t, rows, 251)
+	assert.Equal(t, "249", rows[250][0])
+}
@@ -0,0 +1,192 @@
+package simpleexcelv3
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ColumnLister is implemented by DataProviders that know their field names before the first row,
+// so that sections without configured columns get them in result set order.
+type ColumnLister interface {
+	Columns() []string
+}
+
+// SQLRowsDataProvider implements DataProvider for *sql.Rows.
+// Rows are scanned one at a time, either into a map[string]interface{} keyed by column name
+// or into a struct mapped by `db` tags, so a query can be exported with constant memory.
+// Rows must be read in order; the provider closes the rows on Close.
+type SQLRowsDataProvider struct {
+	rows    *sql.Rows
+	columns []string
+	// structType and fieldIndex are set for struct rows; fieldIndex is -1 for discarded columns
+	structType reflect.Type
+	fieldIndex []int
+	current    int // Index of item, -1 before the first row
+	item       interface{}
+	done       bool
+	err        error
+	mu         sync.Mutex
+}
+
+// NewSQLRowsDataProvider creates a DataProvider returning each row as a map[string]interface{}
+// keyed by column name. []byte values are returned as strings.
+func NewSQLRowsDataProvider(rows *sql.Rows) (*SQLRowsDataProvider, error) {
+	if rows == nil {
+		return nil, fmt.Errorf("rows cannot be nil")
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	return &SQLRowsDataProvider{rows: rows, columns: columns, current: -1}, nil
+}
+
+// NewSQLStructDataProvider creates a DataProvider returning each row as a struct of the type of prototype
+// (e.g. domain.Salary{}). Columns are matched to fields by `db` tag, then by field name (case-insensitive);
+// unmatched columns are discarded.
+func NewSQLStructDataProvider(rows *sql.Rows, prototype interface{}) (*SQLRowsDataProvider, error) {
+	p, err := NewSQLRowsDataProvider(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	t := reflect.TypeOf(prototype)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("prototype must be a struct, got %v", t)
+	}
+
+	p.structType = t
+	p.fieldIndex = make([]int, len(p.columns))
+	for i, column := range p.columns {
+		p.fieldIndex[i] = dbFieldIndex(t, column)
+	}
+	return p, nil
+}
+
+// dbFieldIndex returns the index of the exported field of t mapped to a column, or -1.
+func dbFieldIndex(t reflect.Type, column string) int {
+	byName := -1
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("db"), ",")[0]
+		if tag == column {
+			return i
+		}
+		if tag == "" && byName == -1 && strings.EqualFold(field.Name, column) {
+			byName = i
+		}
+	}
+	return byName
+}
+
+// Columns returns the field names of the rows: the column names for map rows,
+// or the names of the mapped struct fields in column order.
+func (p *SQLRowsDataProvider) Columns() []string {
+	if p.structType == nil {
+		return p.columns
+	}
+	var fields []string
+	for _, idx := range p.fieldIndex {
+		if idx >= 0 {
+			fields = append(fields, p.structType.Field(idx).Name)
+		}
+	}
+	return fields
+}
+
+func (p *SQLRowsDataProvider) GetRow(rowIndex int) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if rowIndex < p.current {
+		return nil, fmt.Errorf("cannot access row %d, already passed", rowIndex)
+	}
+	for p.current < rowIndex {
+		if p.done {
+			return nil, p.err
+		}
+		if !p.rows.Next() {
+			p.done = true
+			p.item = nil
+			p.err = p.rows.Err()
+			return nil, p.err
+		}
+		item, err := p.scan()
+		if err != nil {
+			p.done = true
+			p.err = err
+			return nil, err
+		}
+		p.item = item
+		p.current++
+	}
+	return p.item, nil
+}
+
+// scan reads the current row of the result set.
+func (p *SQLRowsDataProvider) scan() (interface{}, error) {
+	dest := make([]interface{}, len(p.columns))
+
+	if p.structType != nil {
+		v := reflect.New(p.structType).Elem()
+		for i, idx := range p.fieldIndex {
+			if idx >= 0 {
+				dest[i] = v.Field(idx).Addr().Interface()
+			} else {
+				dest[i] = new(interface{})
+			}
+		}
+		if err := p.rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		return v.Interface(), nil
+	}
+
+	values := make([]interface{}, len(p.columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := p.rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	row := make(map[string]interface{}, len(p.columns))
+	for i, column := range p.columns {
+		if b, ok := values[i].([]byte); ok {
+			row[column] = string(b)
+		} else {
+			row[column] = values[i]
+		}
+	}
+	return row, nil
+}
+
+func (p *SQLRowsDataProvider) GetRowCount() (int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done {
+		return p.current + 1, true
+	}
+	return 0, false // Unknown until the result set is exhausted
+}
+
+func (p *SQLRowsDataProvider) HasMoreRows() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.done
+}
+
+func (p *SQLRowsDataProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done = true
+	return p.rows.Close()
+}
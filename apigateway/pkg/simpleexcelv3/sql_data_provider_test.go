@@ -0,0 +1,128 @@
+package simpleexcelv3
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+// rowsDriver is a minimal database/sql driver returning a fixed salary result set for any query.
+type rowsDriver struct{}
+
+type rowsConn struct{}
+
+type rowsStmt struct{}
+
+type fixedRows struct{ i int }
+
+var salaryColumns = []string{"employee_id", "salary", "from_date", "note"}
+
+var salaryValues = [][]driver.Value{
+	{int64(10001), int64(60117), time.Date(1986, 6, 26, 0, 0, 0, 0, time.UTC), []byte("hired")},
+	{int64(10001), int64(62102), time.Date(1987, 6, 26, 0, 0, 0, 0, time.UTC), []byte("raise")},
+	{int64(10001), int64(66074), time.Date(1988, 6, 25, 0, 0, 0, 0, time.UTC), nil},
+}
+
+func init() {
+	sql.Register("simpleexcelv3-rows", rowsDriver{})
+}
+
+func (rowsDriver) Open(string) (driver.Conn, error)         { return rowsConn{}, nil }
+func (rowsConn) Prepare(string) (driver.Stmt, error)        { return rowsStmt{}, nil }
+func (rowsConn) Close() error                               { return nil }
+func (rowsConn) Begin() (driver.Tx, error)                  { return nil, errors.New("not supported") }
+func (rowsStmt) Close() error                               { return nil }
+func (rowsStmt) NumInput() int                              { return -1 }
+func (rowsStmt) Exec([]driver.Value) (driver.Result, error) { return nil, errors.New("not supported") }
+func (rowsStmt) Query([]driver.Value) (driver.Rows, error)  { return &fixedRows{}, nil }
+func (r *fixedRows) Columns() []string                      { return salaryColumns }
+func (r *fixedRows) Close() error                           { return nil }
+
+func (r *fixedRows) Next(dest []driver.Value) error {
+	if r.i >= len(salaryValues) {
+		return io.EOF
+	}
+	copy(dest, salaryValues[r.i])
+	r.i++
+	return nil
+}
+
+func querySalaries(t *testing.T) *sql.Rows {
+	db, err := sql.Open("simpleexcelv3-rows", "")
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	rows, err := db.Query("SELECT employee_id, salary, from_date, note FROM employees.salary")
+	assert.NoError(t, err)
+	return rows
+}
+
+type salaryRow struct {
+	EmployeeID int       `db:"employee_id"`
+	Amount     int       `db:"salary"`
+	FromDate   time.Time `db:"from_date"`
+}
+
+func TestSQLRowsDataProviderMap(t *testing.T) {
+	p, err := NewSQLRowsDataProvider(querySalaries(t))
+	assert.NoError(t, err)
+	assert.Equal(t, salaryColumns, p.Columns())
+
+	row, err := p.GetRow(0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(60117), row.(map[string]interface{})["salary"])
+	assert.Equal(t, "hired", row.(map[string]interface{})["note"])
+
+	_, known := p.GetRowCount()
+	assert.False(t, known)
+
+	row, err = p.GetRow(2)
+	assert.NoError(t, err)
+	assert.Nil(t, row.(map[string]interface{})["note"])
+
+	_, err = p.GetRow(1)
+	assert.Error(t, err)
+
+	row, err = p.GetRow(3)
+	assert.NoError(t, err)
+	assert.Nil(t, row)
+	assert.False(t, p.HasMoreRows())
+	count, known := p.GetRowCount()
+	assert.True(t, known)
+	assert.Equal(t, 3, count)
+	assert.NoError(t, p.Close())
+}
+
+func TestSQLStructDataProviderExport(t *testing.T) {
+	p, err := NewSQLStructDataProvider(querySalaries(t), salaryRow{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"EmployeeID", "Amount", "FromDate"}, p.Columns())
+
+	exporter := NewExcelDataExporterV3V3()
+	exporter.AddSheet("Salaries").AddSection(&SectionConfigV3{ID: "salaries", ShowHeader: true})
+	exporter.BindSectionProvider("salaries", p)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, exporter.StreamLayoutV3(context.Background(), buf, StreamOptions{}))
+
+	f, err := excelize.OpenReader(buf)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	rows, err := f.GetRows("Salaries")
+	assert.NoError(t, err)
+	assert.Len(t, rows, 4)
+	assert.Equal(t, []string{"EmployeeID", "Amount", "FromDate"}, rows[0])
+	assert.Equal(t, "10001", rows[1][0])
+	assert.Equal(t, "66074", rows[3][1])
+
+	_, err = NewSQLStructDataProvider(querySalaries(t), 42)
+	assert.Error(t, err)
+}